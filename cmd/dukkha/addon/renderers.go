@@ -3,7 +3,13 @@ package addon
 import (
 	// Add default disabled renderers
 	_ "arhat.dev/dukkha/pkg/renderer/af"
+	_ "arhat.dev/dukkha/pkg/renderer/cmd"
+	_ "arhat.dev/dukkha/pkg/renderer/cue"
 	_ "arhat.dev/dukkha/pkg/renderer/git"
 	_ "arhat.dev/dukkha/pkg/renderer/http"
 	_ "arhat.dev/dukkha/pkg/renderer/input"
+	_ "arhat.dev/dukkha/pkg/renderer/jsonnet"
+	_ "arhat.dev/dukkha/pkg/renderer/oci"
+	_ "arhat.dev/dukkha/pkg/renderer/secret"
+	_ "arhat.dev/dukkha/pkg/renderer/ssh"
 )
@@ -0,0 +1,8 @@
+//go:build add_tool_s3 || docs
+// +build add_tool_s3 docs
+
+package addon
+
+import (
+	_ "arhat.dev/dukkha/pkg/tools/s3"
+)
@@ -27,6 +27,7 @@ import (
 	"arhat.dev/pkg/versionhelper"
 
 	"arhat.dev/dukkha/pkg/cmd"
+	"arhat.dev/dukkha/pkg/plugin"
 
 	_ "arhat.dev/dukkha/cmd/dukkha/addon"
 )
@@ -38,6 +39,10 @@ func main() {
 	rootCmd.AddCommand(versionhelper.NewVersionCmd(os.Stdout))
 
 	err := rootCmd.Execute()
+
+	// stop plugins started on demand
+	_ = plugin.CloseAll()
+
 	if err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, err.Error())
 
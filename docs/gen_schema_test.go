@@ -192,6 +192,24 @@ func generateSchemaJSON(pkgPath, topLevelStructName string) ([]byte, error) {
 		scm.Definitions[k] = def
 	}
 
+	// include base definitions of custom tools and tasks, project specific
+	// fields are added by `dukkha debug schema` (see custom.ExtendJSONSchema)
+	for _, name := range []string{"Tool", "Task"} {
+		const customPkg = "arhat.dev/dukkha/pkg/tools/custom"
+		customScm, err := schema.GenerateSchema(customPkg, name, "yaml", formatRefName, false)
+		if err != nil {
+			return nil, err
+		}
+
+		for k, def := range customScm.Definitions {
+			if k == name {
+				k = formatRefName(customPkg, name)
+			}
+
+			scm.Definitions[k] = def
+		}
+	}
+
 	for kind, def := range scm.Definitions {
 		if len(def.Properties) == 0 {
 			continue
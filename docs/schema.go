@@ -0,0 +1,10 @@
+package docs
+
+import (
+	_ "embed" // for go:embed
+)
+
+// Schema is the generated json schema of dukkha config
+//
+//go:embed generated/schema.json
+var Schema []byte
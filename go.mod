@@ -5,12 +5,16 @@ go 1.16
 require (
 	arhat.dev/pkg v0.9.0
 	arhat.dev/rs v0.8.4
+	cuelang.org/go v0.4.2
+	filippo.io/age v1.0.0
+	github.com/BurntSushi/toml v0.4.1
 	github.com/Masterminds/goutils v1.1.1
 	github.com/Masterminds/sprig/v3 v3.2.2
 	github.com/aoldershaw/ansi v0.0.0-20210128170437-8c5426635e02
 	github.com/bmatcuk/doublestar/v4 v4.0.2
 	github.com/die-net/lrucache v0.0.0-20210908122246-903d43d14082
 	github.com/dsnet/compress v0.0.2-0.20210315054119-f66993602bf5
+	github.com/google/go-jsonnet v0.18.0
 	github.com/google/uuid v1.3.0
 	github.com/gosimple/slug v1.11.2
 	github.com/h2non/filetype v1.1.3
@@ -23,6 +27,9 @@ require (
 	github.com/nwaples/rardecode v1.1.2
 	github.com/pierrec/lz4/v4 v4.1.12
 	github.com/pkg/errors v0.9.1
+	github.com/santhosh-tekuri/jsonschema/v5 v5.0.0
+	github.com/saracen/go7z v0.0.0-20191010121135-9c09b6bd7fda
+	github.com/saracen/solidblock v0.0.0-20190426153529-45df20abab6f // indirect
 	github.com/spf13/afero v1.6.0
 	github.com/spf13/cobra v1.2.1
 	github.com/spf13/pflag v1.0.5
@@ -36,6 +43,7 @@ require (
 	golang.org/x/term v0.0.0-20210927222741-03fcf44c2211
 	golang.org/x/tools v0.1.8
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
+	gopkg.in/ini.v1 v1.62.0
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 	mvdan.cc/sh/v3 v3.4.1
 )
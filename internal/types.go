@@ -21,4 +21,24 @@ type (
 	VALUESetter interface {
 		SetVALUE(v interface{})
 	}
+
+	TemplateLibraryGetter interface {
+		TemplateLibrary() interface{}
+	}
+
+	TemplateLibrarySetter interface {
+		SetTemplateLibrary(lib interface{})
+	}
+
+	RenderingTracerGetter interface {
+		RenderingTracer() interface{}
+	}
+
+	RenderingTracerSetter interface {
+		SetRenderingTracer(tracer interface{})
+	}
+
+	RenderConcurrencySetter interface {
+		SetRenderConcurrency(n int)
+	}
 )
@@ -27,4 +27,19 @@ func TestInternalTypes(t *testing.T) {
 
 	_, ok = ctx.(VALUESetter)
 	assert.True(t, ok)
+
+	_, ok = ctx.(TemplateLibraryGetter)
+	assert.True(t, ok)
+
+	_, ok = ctx.(TemplateLibrarySetter)
+	assert.True(t, ok)
+
+	_, ok = ctx.(RenderingTracerGetter)
+	assert.True(t, ok)
+
+	_, ok = ctx.(RenderingTracerSetter)
+	assert.True(t, ok)
+
+	_, ok = ctx.(RenderConcurrencySetter)
+	assert.True(t, ok)
 }
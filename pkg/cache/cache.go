@@ -51,9 +51,11 @@ func (c *Cache) Get(obj IdentifiableObject, refresh LocalCacheRefreshFunc) ([]by
 	key := obj.ScopeUniqueID()
 	data, ok := c.cache.Get(key)
 	if ok {
+		countHit()
 		return data, nil
 	}
 
+	countMiss()
 	data, err := refresh(obj)
 	if err != nil {
 		return nil, err
@@ -67,3 +67,24 @@ func TestCache_Get(t *testing.T) {
 		assert.True(t, ok)
 	})
 }
+
+func TestStats(t *testing.T) {
+	cache := NewCache(-1, -1, 0)
+	refresh := func(IdentifiableObject) ([]byte, error) { return []byte("data"), nil }
+
+	hits, misses := Stats()
+
+	_, err := cache.Get(IdentifiableString("stats"), refresh)
+	assert.NoError(t, err)
+
+	currentHits, currentMisses := Stats()
+	assert.Equal(t, hits, currentHits)
+	assert.Equal(t, misses+1, currentMisses)
+
+	_, err = cache.Get(IdentifiableString("stats"), refresh)
+	assert.NoError(t, err)
+
+	currentHits, currentMisses = Stats()
+	assert.Equal(t, hits+1, currentHits)
+	assert.Equal(t, misses+1, currentMisses)
+}
@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"arhat.dev/pkg/log"
+)
+
+// LimitLocalCache sets size limits of local cache files
+//
+// itemMaxBytes > 0, files larger than this limit are removed once not in use
+//
+// maxBytes > 0, least recently used files are removed when total size of
+// local cache files exceeds this limit
+func (c *TwoTierCache) LimitLocalCache(itemMaxBytes, maxBytes int64) {
+	c.localItemMaxBytes = itemMaxBytes
+	c.localMaxBytes = maxBytes
+}
+
+// localCacheFilenameRegex matches filename formatted by formatLocalCacheFilename
+var localCacheFilenameRegex = regexp.MustCompile(`^[0-9a-f]{32}-[0-9]{20}`)
+
+// isLocalCacheFile checks whether the file (base name) is a local cache
+// file created by TwoTierCache
+func isLocalCacheFile(name string) bool {
+	return localCacheFilenameRegex.MatchString(name)
+}
+
+// touchLocalCache updates modification time of the local cache file to
+// track last use when size limit is set
+func (c *TwoTierCache) touchLocalCache(file string) {
+	if c.localMaxBytes <= 0 {
+		return
+	}
+
+	now := time.Now()
+	err := c.cacheFS.Chtimes(file, now, now)
+	if err != nil {
+		log.Log.D("updating cache file mtime", log.String("file", file), log.Error(err))
+	}
+}
+
+// evictLocalCache removes local cache files exceeding size limits,
+// the file in use is never removed
+func (c *TwoTierCache) evictLocalCache(inUse string) {
+	if c.localItemMaxBytes <= 0 && c.localMaxBytes <= 0 {
+		return
+	}
+
+	entries, err := fs.ReadDir(c.cacheFS, ".")
+	if err != nil {
+		log.Log.I("listing local cache for eviction", log.Error(err))
+		return
+	}
+
+	type cacheFile struct {
+		name     string
+		size     int64
+		lastUsed time.Time
+	}
+
+	var (
+		files []cacheFile
+		total int64
+	)
+
+	for _, entry := range entries {
+		if entry.IsDir() || !isLocalCacheFile(entry.Name()) {
+			continue
+		}
+
+		info, err2 := entry.Info()
+		if err2 != nil {
+			continue
+		}
+
+		name := entry.Name()
+		if name != inUse && c.localItemMaxBytes > 0 && info.Size() > c.localItemMaxBytes {
+			c.removeLocalCache(name)
+			continue
+		}
+
+		files = append(files, cacheFile{name: name, size: info.Size(), lastUsed: info.ModTime()})
+		total += info.Size()
+	}
+
+	if c.localMaxBytes <= 0 || total <= c.localMaxBytes {
+		return
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].lastUsed.Before(files[j].lastUsed)
+	})
+
+	for _, f := range files {
+		if total <= c.localMaxBytes {
+			break
+		}
+
+		if f.name == inUse {
+			continue
+		}
+
+		if c.removeLocalCache(f.name) {
+			total -= f.size
+		}
+	}
+}
+
+func (c *TwoTierCache) removeLocalCache(name string) bool {
+	// best effort
+	_ = c.cacheFS.Chmod(name, 0600)
+	err := c.cacheFS.Remove(name)
+	if err != nil {
+		log.Log.I("evicting local cache", log.String("file", name), log.Error(err))
+		return false
+	}
+
+	c.removeLocalMetadata(strings.SplitN(name, "-", 2)[0])
+	return true
+}
@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"arhat.dev/pkg/fshelper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTwoTierCache_evictLocalCache(t *testing.T) {
+	fetch := RemoteCacheRefreshFunc(func(_ IdentifiableObject) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("test-data")), nil
+	})
+
+	newCache := func(t *testing.T, itemMaxBytes, maxBytes int64) (*TwoTierCache, string) {
+		cacheDir := t.TempDir()
+		c := NewTwoTierCache(fshelper.NewOSFS(false, func() (string, error) {
+			return cacheDir, nil
+		}), 0, 0, 100)
+		c.LimitLocalCache(itemMaxBytes, maxBytes)
+
+		return c, cacheDir
+	}
+
+	// getPath caches obj and sets its mtime to make last use deterministic
+	getPath := func(t *testing.T, c *TwoTierCache, obj string, lastUsed int64) string {
+		path, _, err := c.GetPath(IdentifiableString(obj), 1111111111, true, fetch)
+		assert.NoError(t, err)
+		assert.NoError(t, os.Chtimes(path, time.Unix(lastUsed, 0), time.Unix(lastUsed, 0)))
+
+		return filepath.Base(path)
+	}
+
+	listFiles := func(t *testing.T, dir string) []string {
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+
+		var ret []string
+		for _, e := range entries {
+			ret = append(ret, e.Name())
+		}
+
+		return ret
+	}
+
+	t.Run("Max Size", func(t *testing.T) {
+		c, dir := newCache(t, 0, 20)
+
+		foo := getPath(t, c, "foo", 100)
+		bar := getPath(t, c, "bar", 300)
+		assert.ElementsMatch(t, []string{foo, bar}, listFiles(t, dir))
+
+		// foo is least recently used
+		baz := getPath(t, c, "baz", 200)
+		assert.ElementsMatch(t, []string{bar, baz}, listFiles(t, dir))
+
+		// active cache hit updates last use time
+		_ = getPath(t, c, "bar", 400)
+		_, _, err := c.GetPath(IdentifiableString("baz"), 1111111111, true, fetch)
+		assert.NoError(t, err)
+
+		foo = getPath(t, c, "foo", 500)
+		assert.ElementsMatch(t, []string{baz, foo}, listFiles(t, dir))
+	})
+
+	t.Run("Max Item Size", func(t *testing.T) {
+		c, dir := newCache(t, 5, 0)
+
+		foo := getPath(t, c, "foo", 100)
+		assert.Equal(t, []string{foo}, listFiles(t, dir))
+
+		bar := getPath(t, c, "bar", 200)
+		assert.Equal(t, []string{bar}, listFiles(t, dir))
+	})
+
+	t.Run("No Limit", func(t *testing.T) {
+		c, dir := newCache(t, 0, 0)
+
+		foo := getPath(t, c, "foo", 100)
+		bar := getPath(t, c, "bar", 200)
+		assert.ElementsMatch(t, []string{foo, bar}, listFiles(t, dir))
+	})
+
+	t.Run("Metadata", func(t *testing.T) {
+		c, dir := newCache(t, 0, 20)
+
+		_ = getPath(t, c, "foo", 100)
+		assert.NoError(t, c.WriteMetadata(IdentifiableString("foo"), []byte("foo-meta")))
+		_ = getPath(t, c, "bar", 300)
+		assert.NoError(t, c.WriteMetadata(IdentifiableString("bar"), []byte("bar-meta")))
+
+		data, err := c.ReadMetadata(IdentifiableString("foo"))
+		assert.NoError(t, err)
+		assert.EqualValues(t, "foo-meta", string(data))
+
+		// foo evicted with its metadata
+		_ = getPath(t, c, "baz", 200)
+		_, err = c.ReadMetadata(IdentifiableString("foo"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		data, err = c.ReadMetadata(IdentifiableString("bar"))
+		assert.NoError(t, err)
+		assert.EqualValues(t, "bar-meta", string(data))
+		assert.Len(t, listFiles(t, filepath.Join(dir, localMetadataDir)), 1)
+
+		assert.NoError(t, c.RemoveMetadata(IdentifiableString("bar")))
+		assert.NoError(t, c.RemoveMetadata(IdentifiableString("bar")))
+		assert.Empty(t, listFiles(t, filepath.Join(dir, localMetadataDir)))
+	})
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+
+	"arhat.dev/pkg/log"
+)
+
+// localMetadataDir is the dir in cache dir storing metadata of local cache
+const localMetadataDir = "metadata"
+
+// formatLocalMetadataFilename returns path of the metadata file for local
+// cache files with prefix
+func formatLocalMetadataFilename(prefix string) string {
+	return path.Join(localMetadataDir, prefix)
+}
+
+// ReadMetadata reads metadata of the local cache of obj saved by WriteMetadata
+func (c *TwoTierCache) ReadMetadata(obj IdentifiableObject) ([]byte, error) {
+	return c.cacheFS.ReadFile(formatLocalMetadataFilename(
+		formatCacheFilenamePrefix(obj.ScopeUniqueID()),
+	))
+}
+
+// WriteMetadata saves data as metadata of the local cache of obj (e.g. http
+// validators), metadata is removed once all local cache files of obj are
+// evicted
+func (c *TwoTierCache) WriteMetadata(obj IdentifiableObject, data []byte) error {
+	err := c.cacheFS.MkdirAll(localMetadataDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	return c.cacheFS.WriteFile(formatLocalMetadataFilename(
+		formatCacheFilenamePrefix(obj.ScopeUniqueID()),
+	), data, 0600)
+}
+
+// RemoveMetadata removes metadata of the local cache of obj if any
+func (c *TwoTierCache) RemoveMetadata(obj IdentifiableObject) error {
+	err := c.cacheFS.Remove(formatLocalMetadataFilename(
+		formatCacheFilenamePrefix(obj.ScopeUniqueID()),
+	))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// removeLocalMetadata removes metadata of local cache files with prefix
+// when none of them is left
+func (c *TwoTierCache) removeLocalMetadata(prefix string) {
+	matches, err := fs.Glob(c.cacheFS, prefix+"-*")
+	if err != nil || len(matches) != 0 {
+		return
+	}
+
+	file := formatLocalMetadataFilename(prefix)
+	err = c.cacheFS.Remove(file)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Log.I("evicting local cache metadata", log.String("file", file), log.Error(err))
+	}
+}
@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"arhat.dev/pkg/log"
+)
+
+// RemoteStore is the optional remote tier of TwoTierCache shared across
+// machines, objects are addressed by the sha256 sum of their scope unique id
+type RemoteStore interface {
+	// Get returns content of the object stored with key and the time
+	// when it was stored (zero value if unknown),
+	// error wrapping fs.ErrNotExist is returned when not found
+	Get(key string) (io.ReadCloser, time.Time, error)
+
+	// Put stores content read from r with key
+	Put(key string, r io.Reader, size int64) error
+}
+
+// SetRemote enables remote tier of the cache, remote content is checked
+// before refreshing, and refreshed content is uploaded unless readOnly
+//
+// namespace separates objects from different caches in the same store
+func (c *TwoTierCache) SetRemote(store RemoteStore, namespace string, readOnly bool) {
+	c.remote = store
+	c.remoteNamespace = namespace
+	c.remoteReadOnly = readOnly
+}
+
+// PrivateContent marks content returned by RemoteCacheRefreshFunc as fetched
+// with credentials, it is cached locally but never uploaded to remote tier,
+// so it is not shared with those without access to the original source
+func PrivateContent(r io.ReadCloser) io.ReadCloser {
+	return &privateContent{ReadCloser: r}
+}
+
+type privateContent struct{ io.ReadCloser }
+
+func formatRemoteCacheKey(namespace string, obj IdentifiableObject) string {
+	sum := sha256.Sum256([]byte(obj.ScopeUniqueID()))
+	return path.Join(namespace, hex.EncodeToString(sum[:])+obj.Ext())
+}
+
+// getRemote looks up obj in remote tier, returns nil reader
+// when not found or stored before notBefore (unix timestamp)
+func (c *TwoTierCache) getRemote(obj IdentifiableObject, notBefore int64) io.ReadCloser {
+	if c.remote == nil {
+		return nil
+	}
+
+	key := formatRemoteCacheKey(c.remoteNamespace, obj)
+	r, storedAt, err := c.remote.Get(key)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Log.I("checking remote cache", log.String("key", key), log.Error(err))
+		}
+
+		return nil
+	}
+
+	if !storedAt.IsZero() && storedAt.Unix() < notBefore {
+		_ = r.Close()
+		return nil
+	}
+
+	return r
+}
+
+// putRemote uploads local cache file to remote tier (best effort)
+func (c *TwoTierCache) putRemote(obj IdentifiableObject, file string, size int64) {
+	if c.remote == nil || c.remoteReadOnly {
+		return
+	}
+
+	key := formatRemoteCacheKey(c.remoteNamespace, obj)
+	f, err := c.cacheFS.Open(file)
+	if err == nil {
+		err = c.remote.Put(key, f, size)
+		_ = f.Close()
+	}
+
+	if err != nil {
+		log.Log.I("uploading remote cache", log.String("key", key), log.Error(err))
+	}
+}
+
+// NewHTTPRemoteStore creates a RemoteStore storing objects at baseURL/key
+// with http GET and PUT requests
+//
+// header is added to all requests (e.g. Authorization)
+func NewHTTPRemoteStore(baseURL string, client *http.Client, header http.Header) (RemoteStore, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote cache url: %w", err)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &httpRemoteStore{
+		baseURL: u,
+		client:  client,
+		header:  header,
+	}, nil
+}
+
+type httpRemoteStore struct {
+	baseURL *url.URL
+	client  *http.Client
+	header  http.Header
+}
+
+func (s *httpRemoteStore) newRequest(method, key string, body io.Reader) (*http.Request, error) {
+	u := *s.baseURL
+	u.Path = path.Join("/", u.Path, key)
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range s.header {
+		req.Header[k] = v
+	}
+
+	return req, nil
+}
+
+func (s *httpRemoteStore) Get(key string) (io.ReadCloser, time.Time, error) {
+	req, err := s.newRequest(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		_ = resp.Body.Close()
+		return nil, time.Time{}, fmt.Errorf("remote cache %q: %w", key, fs.ErrNotExist)
+	case resp.StatusCode != http.StatusOK:
+		_ = resp.Body.Close()
+		return nil, time.Time{}, fmt.Errorf("remote cache %q: unexpected response status %q", key, resp.Status)
+	}
+
+	// best effort, zero value when not set
+	storedAt, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return resp.Body, storedAt, nil
+}
+
+func (s *httpRemoteStore) Put(key string, r io.Reader, size int64) error {
+	req, err := s.newRequest(http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = size
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("remote cache %q: unexpected response status %q", key, resp.Status)
+	}
+
+	return nil
+}
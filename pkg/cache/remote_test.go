@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"arhat.dev/pkg/fshelper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTwoTierCache_remote(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		objects = make(map[string]string)
+	)
+
+	storedAt := time.Unix(1111111110, 0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Last-Modified", storedAt.UTC().Format(http.TimeFormat))
+			_, _ = w.Write([]byte(data))
+		case http.MethodPut:
+			data, _ := io.ReadAll(r.Body)
+			objects[r.URL.Path] = string(data)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	store, err := NewHTTPRemoteStore(srv.URL+"/cache", srv.Client(), http.Header{
+		"Authorization": []string{"Bearer test"},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	called := 0
+	fetch := RemoteCacheRefreshFunc(func(_ IdentifiableObject) (io.ReadCloser, error) {
+		called++
+		return ioutil.NopCloser(strings.NewReader("test-data")), nil
+	})
+
+	fetchFail := RemoteCacheRefreshFunc(func(_ IdentifiableObject) (io.ReadCloser, error) {
+		called++
+		return nil, fmt.Errorf("test error")
+	})
+
+	newCache := func(t *testing.T, readOnly bool) *TwoTierCache {
+		cacheDir := t.TempDir()
+		c := NewTwoTierCache(fshelper.NewOSFS(false, func() (string, error) {
+			return cacheDir, nil
+		}), 0, 0, 100)
+		c.SetRemote(store, "renderer/http", readOnly)
+
+		return c
+	}
+
+	obj := IdentifiableString("https://example.com/foo.yaml")
+
+	t.Run("Read Only", func(t *testing.T) {
+		called = 0
+		data, _, err := newCache(t, true).Get(obj, 1111111110, true, fetch)
+		assert.NoError(t, err)
+		assert.Equal(t, "test-data", string(data))
+		assert.Equal(t, 1, called)
+		assert.Len(t, objects, 0)
+	})
+
+	t.Run("Upload Refreshed", func(t *testing.T) {
+		called = 0
+		data, _, err := newCache(t, false).Get(obj, 1111111110, true, fetch)
+		assert.NoError(t, err)
+		assert.Equal(t, "test-data", string(data))
+		assert.Equal(t, 1, called)
+		assert.Len(t, objects, 1)
+		for k, v := range objects {
+			assert.True(t, strings.HasPrefix(k, "/cache/renderer/http/"), k)
+			assert.True(t, strings.HasSuffix(k, ".yaml"), k)
+			assert.Equal(t, "test-data", v)
+		}
+	})
+
+	t.Run("Use Remote", func(t *testing.T) {
+		called = 0
+		c := newCache(t, false)
+		path, expired, err := c.GetPath(obj, 1111111150, true, fetchFail)
+		assert.NoError(t, err)
+		assert.False(t, expired)
+		assert.Equal(t, 0, called)
+
+		data, err := ioutil.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, "test-data", string(data))
+	})
+
+	t.Run("Ignore Stale Remote", func(t *testing.T) {
+		called = 0
+		_, _, err := newCache(t, false).Get(obj, 1111111311, true, fetchFail)
+		assert.Error(t, err)
+		assert.Equal(t, 1, called)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		called = 0
+		data, _, err := newCache(t, false).Get(IdentifiableString("bar"), 1111111110, true, fetch)
+		assert.NoError(t, err)
+		assert.Equal(t, "test-data", string(data))
+		assert.Equal(t, 1, called)
+	})
+
+	t.Run("Private Content", func(t *testing.T) {
+		mu.Lock()
+		count := len(objects)
+		mu.Unlock()
+
+		data, _, err := newCache(t, false).Get(IdentifiableString("private"), 1111111110, true,
+			func(_ IdentifiableObject) (io.ReadCloser, error) {
+				return PrivateContent(ioutil.NopCloser(strings.NewReader("private-data"))), nil
+			},
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, "private-data", string(data))
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Len(t, objects, count)
+	})
+}
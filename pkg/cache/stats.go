@@ -0,0 +1,21 @@
+package cache
+
+import "sync/atomic"
+
+// counters of all caches in this process
+var stats struct {
+	hits   uint64
+	misses uint64
+}
+
+// Stats returns count of cache hits and misses of all caches since
+// process start, a miss is counted when cached content is refreshed
+//
+// it is used to check whether some operation was served from cache by
+// comparing stats before and after the operation
+func Stats() (hits, misses uint64) {
+	return atomic.LoadUint64(&stats.hits), atomic.LoadUint64(&stats.misses)
+}
+
+func countHit()  { atomic.AddUint64(&stats.hits, 1) }
+func countMiss() { atomic.AddUint64(&stats.misses, 1) }
@@ -12,6 +12,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"arhat.dev/pkg/fshelper"
 	"arhat.dev/pkg/log"
@@ -55,8 +56,17 @@ func NewTwoTierCache(
 type TwoTierCache struct {
 	itemMaxBytes int64
 
+	// size limits of local cache files, see LimitLocalCache
+	localItemMaxBytes int64
+	localMaxBytes     int64
+
 	cacheFS  *fshelper.OSFS
 	memcache *lru.LruCache
+
+	// optional remote tier, see SetRemote
+	remote          RemoteStore
+	remoteNamespace string
+	remoteReadOnly  bool
 }
 
 // Get cached content
@@ -95,6 +105,7 @@ func (c *TwoTierCache) get(
 		var ok bool
 		content, ok = c.memcache.Get(obj.ScopeUniqueID())
 		if ok {
+			countHit()
 			return "", content, false, nil
 		}
 	}
@@ -124,8 +135,10 @@ func (c *TwoTierCache) get(
 
 	if len(active) != 0 {
 		// use latest active cache
+		countHit()
 		file = active[len(active)-1]
 		isExpired = false
+		c.touchLocalCache(file)
 		if retConent {
 			content, err = c.cacheFS.ReadFile(file)
 		}
@@ -149,7 +162,24 @@ func (c *TwoTierCache) get(
 		}
 	}
 
-	r, err := refresh(obj)
+	// check remote tier before refreshing
+	if r := c.getRemote(obj, now-c.memcache.MaxAge); r != nil {
+		countHit()
+		defer func() { _ = r.Close() }()
+		return c.store(obj, r, cacheFilenamePrefix, suffix, now, retConent, false)
+	}
+
+	refreshObj := obj
+	if len(expired) != 0 {
+		timestamp, _ := parseLocalCacheTimestamp(cacheFilenamePrefix, suffix, expired[len(expired)-1])
+		refreshObj = &expiredObject{
+			IdentifiableObject: obj,
+			cachedAt:           time.Unix(timestamp, 0),
+		}
+	}
+
+	countMiss()
+	r, err := refresh(refreshObj)
 	if err != nil {
 		// failed fetching from remote, fallback to last expired
 		if len(expired) == 0 {
@@ -157,6 +187,11 @@ func (c *TwoTierCache) get(
 			return
 		}
 
+		if errors.Is(err, ErrNotModified) {
+			// remote content unchanged, renew last expired cache
+			return c.renew(obj, expired[len(expired)-1], cacheFilenamePrefix, suffix, now, retConent)
+		}
+
 		file = expired[len(expired)-1]
 		isExpired = true
 
@@ -177,13 +212,32 @@ func (c *TwoTierCache) get(
 	}
 	defer func() { _ = r.Close() }()
 
-	isExpired = false
-	_file := formatLocalCacheFilename(cacheFilenamePrefix, suffix, now)
+	_, private := r.(*privateContent)
+	return c.store(obj, r, cacheFilenamePrefix, suffix, now, retConent, !private)
+}
+
+// store saves content read from r as local cache, and uploads it to
+// remote tier when upload is true
+func (c *TwoTierCache) store(
+	obj IdentifiableObject,
+	r io.Reader,
+	prefix, suffix string,
+	now int64,
+	retConent bool,
+	upload bool,
+) (file string, content []byte, isExpired bool, err error) {
+	_file := formatLocalCacheFilename(prefix, suffix, now)
 	size, content, err := storeLocalCache(c.cacheFS, _file, r, retConent)
 	if err != nil {
 		return
 	}
 
+	if upload {
+		c.putRemote(obj, _file, size)
+	}
+
+	c.evictLocalCache(_file)
+
 	file, err = c.cacheFS.Abs(_file)
 	if err != nil {
 		return
@@ -204,6 +258,39 @@ func (c *TwoTierCache) get(
 	return
 }
 
+// renew marks expired cache file as active by renaming it with current timestamp
+func (c *TwoTierCache) renew(
+	obj IdentifiableObject,
+	expiredFile, prefix, suffix string,
+	now int64,
+	retConent bool,
+) (file string, content []byte, isExpired bool, err error) {
+	_file := formatLocalCacheFilename(prefix, suffix, now)
+	err = c.cacheFS.Rename(expiredFile, _file)
+	if err != nil {
+		return
+	}
+
+	c.touchLocalCache(_file)
+	c.evictLocalCache(_file)
+
+	file, err = c.cacheFS.Abs(_file)
+	if err != nil || !retConent {
+		return
+	}
+
+	content, err = c.cacheFS.ReadFile(_file)
+	if err != nil {
+		return
+	}
+
+	if int64(len(content)) <= c.itemMaxBytes && int64(len(content)) <= c.memcache.MaxSize {
+		c.memcache.Set(obj.ScopeUniqueID(), content)
+	}
+
+	return
+}
+
 func formatCacheFilenamePrefix(id string) string {
 	return hex.EncodeToString(md5helper.Sum([]byte(id)))
 }
@@ -304,23 +391,13 @@ func lookupLocalCache(
 	for _, info := range entries[start:end] {
 		filename := info.Name()
 
-		parts := strings.SplitN(strings.TrimSuffix(filename, suffix), "-", 2)
-		if len(parts) != 2 || parts[0] != prefix {
+		timestamp, ok := parseLocalCacheTimestamp(prefix, suffix, filename)
+		if !ok {
 			// invalid cache file
 			invalid = append(invalid, filename)
 			continue
 		}
 
-		timestamp, err2 := strconv.ParseInt(
-			// trim padding
-			strings.TrimLeft(parts[1], "0"),
-			10, 64,
-		)
-		if err2 != nil {
-			invalid = append(invalid, filename)
-			continue
-		}
-
 		if timestamp < notBefore {
 			expired = append(expired, filename)
 			continue
@@ -331,3 +408,23 @@ func lookupLocalCache(
 
 	return
 }
+
+// parseLocalCacheTimestamp parses unix timestamp in cache filename
+// formatted by formatLocalCacheFilename
+func parseLocalCacheTimestamp(prefix, suffix, filename string) (int64, bool) {
+	parts := strings.SplitN(strings.TrimSuffix(filename, suffix), "-", 2)
+	if len(parts) != 2 || parts[0] != prefix {
+		return 0, false
+	}
+
+	timestamp, err := strconv.ParseInt(
+		// trim padding
+		strings.TrimLeft(parts[1], "0"),
+		10, 64,
+	)
+	if err != nil {
+		return 0, false
+	}
+
+	return timestamp, true
+}
@@ -201,6 +201,53 @@ func TestTwoTierCache(t *testing.T) {
 		assert.NoError(t, err)
 		assert.EqualValues(t, cachedData, string(data))
 	})
+
+	t.Run("Renew Expired When Not Modified", func(t *testing.T) {
+		defer t.Cleanup(func() {
+			calledFail = 0
+			calledOk = 0
+		})
+
+		cacheDir := t.TempDir()
+		cache := NewTwoTierCache(fshelper.NewOSFS(false, func() (string, error) {
+			return cacheDir, nil
+		}), -1, -1, 100)
+
+		_, _, err := cache.Get(obj, 1111111111, true, func(obj IdentifiableObject) (io.ReadCloser, error) {
+			_, ok := obj.(ExpiredObject)
+			assert.False(t, ok)
+			return fetchRemoteAlwaysOk(obj)
+		})
+		assert.NoError(t, err)
+
+		var cachedAt int64
+		path, expired, err := cache.GetPath(obj, 1111111311, true, func(obj IdentifiableObject) (io.ReadCloser, error) {
+			eo, ok := obj.(ExpiredObject)
+			if assert.True(t, ok) {
+				cachedAt = eo.CachedAt().Unix()
+			}
+
+			return nil, fmt.Errorf("wrapped: %w", ErrNotModified)
+		})
+		assert.NoError(t, err)
+		assert.False(t, expired)
+		assert.EqualValues(t, 1111111111, cachedAt)
+		assert.EqualValues(t, cacheFilenamePrefix+"-00000000001111111311", filepath.Base(path))
+
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.EqualValues(t, cachedData, string(data))
+
+		// renewed cache is active
+		data, _, err = cache.Get(obj, 1111111312, true, fetchRemoteAlwaysFail)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 0, calledFail)
+		assert.EqualValues(t, cachedData, string(data))
+
+		entries, err := os.ReadDir(cacheDir)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
 }
 
 func TestFormatCacheFilenamePrefix(t *testing.T) {
@@ -1,15 +1,31 @@
 package cache
 
 import (
+	"errors"
 	"io"
+	"time"
 )
 
+// ErrNotModified can be returned by RemoteCacheRefreshFunc when it is called
+// with an ExpiredObject and remote content is the same as the expired cache,
+// the expired cache is renewed and used
+var ErrNotModified = errors.New("not modified")
+
 type (
 	IdentifiableObject interface {
 		ScopeUniqueID() string
 		Ext() string
 	}
 
+	// ExpiredObject is passed to RemoteCacheRefreshFunc when there is
+	// expired local cache of the object, to make conditional requests
+	ExpiredObject interface {
+		IdentifiableObject
+
+		// CachedAt returns the time when the expired cache was created
+		CachedAt() time.Time
+	}
+
 	RemoteCacheRefreshFunc func(obj IdentifiableObject) (io.ReadCloser, error)
 	LocalCacheRefreshFunc  func(obj IdentifiableObject) ([]byte, error)
 )
@@ -34,3 +50,11 @@ func (s IdentifiableString) Ext() string {
 
 	return ""
 }
+
+type expiredObject struct {
+	IdentifiableObject
+
+	cachedAt time.Time
+}
+
+func (o *expiredObject) CachedAt() time.Time { return o.cachedAt }
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+// Options cli options available to all cache commands
+type Options struct {
+	renderers []string
+	tools     []string
+	tasks     []string
+}
+
+func (opts *Options) filtered() bool {
+	return len(opts.renderers) != 0 || len(opts.tools) != 0 || len(opts.tasks) != 0
+}
+
+// selected checks whether cache files of the component are selected
+// by name patterns, all components are selected when there is no pattern
+func (opts *Options) selected(c *component) bool {
+	if !opts.filtered() {
+		return true
+	}
+
+	match := func(patterns []string, name string) bool {
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, name); ok {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	switch c.kind {
+	case kindRenderer:
+		return match(opts.renderers, c.name)
+	case kindTool:
+		return match(opts.tools, c.name)
+	case kindTask:
+		return match(opts.tasks, c.name) || match(opts.tools, c.tool)
+	default:
+		return false
+	}
+}
+
+// selectedFiles scans cache dir for files of selected components
+func (opts *Options) selectedFiles(ctx dukkha.Context) (string, []*cacheFile, error) {
+	crc, ok := ctx.(dukkha.ConfigResolvingContext)
+	if !ok {
+		return "", nil, fmt.Errorf("unexpected context without cache layout")
+	}
+
+	cacheDir, err := filepath.Abs(ctx.CacheDir())
+	if err != nil {
+		return "", nil, fmt.Errorf("resolving cache dir: %w", err)
+	}
+
+	components, err := collectComponents(crc, cacheDir)
+	if err != nil {
+		return "", nil, fmt.Errorf("collecting cache dirs: %w", err)
+	}
+
+	files, err := scanCacheDir(cacheDir, components)
+	if err != nil {
+		return "", nil, fmt.Errorf("scanning cache dir: %w", err)
+	}
+
+	var ret []*cacheFile
+	for _, f := range files {
+		if opts.selected(f.owner) {
+			ret = append(ret, f)
+		}
+	}
+
+	return cacheDir, ret, nil
+}
+
+func NewCacheCmd(ctx *dukkha.Context) *cobra.Command {
+	opts := &Options{}
+
+	cacheCmd := &cobra.Command{
+		Use:           "cache",
+		Short:         "Manage cache of renderers, tools and tasks",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		CompletionOptions: cobra.CompletionOptions{
+			DisableDefaultCmd:   false,
+			DisableNoDescFlag:   false,
+			DisableDescriptions: true,
+		},
+	}
+
+	flags := cacheCmd.PersistentFlags()
+	flags.StringSliceVar(&opts.renderers, "renderer", nil,
+		"select cache of renderers by name pattern (e.g. http, git-*)",
+	)
+	flags.StringSliceVar(&opts.tools, "tool", nil,
+		"select cache of tools and their tasks by name pattern "+
+			"(e.g. golang, golang:my-tool)",
+	)
+	flags.StringSliceVar(&opts.tasks, "task", nil,
+		"select cache of tasks by name pattern <tool>:<task-kind>:<task-name> "+
+			"(e.g. golang:test:*)",
+	)
+
+	cacheCmd.AddCommand(
+		newListCmd(ctx, opts),
+		newStatCmd(ctx, opts),
+		newPruneCmd(ctx, opts),
+		newCleanCmd(ctx, opts),
+		newWarmCmd(ctx, opts),
+	)
+
+	return cacheCmd
+}
@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	di "arhat.dev/dukkha/internal"
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/renderer/echo"
+)
+
+func TestOptions_selectedFiles(t *testing.T) {
+	cacheDir := t.TempDir()
+
+	ctx := dt.NewTestContext(context.TODO())
+	ctx.(di.CacheDirSetter).SetCacheDir(cacheDir)
+	ctx.AddRenderer("my-echo", echo.NewDefault("my-echo"))
+
+	old := time.Now().Add(-48 * time.Hour)
+	for file, modTime := range map[string]time.Time{
+		"renderer/my-echo/a":      old,
+		"renderer/http/b":         time.Now(),
+		"renderer/http/c":         old,
+		"golang/_/test/unit/d":    time.Now(),
+		"buildah/image-id/digest": old,
+	} {
+		p := filepath.Join(cacheDir, filepath.FromSlash(file))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		assert.NoError(t, os.WriteFile(p, []byte(file), 0400))
+		assert.NoError(t, os.Chtimes(p, modTime, modTime))
+	}
+
+	owners := func(t *testing.T, opts *Options) map[string]string {
+		_, files, err := opts.selectedFiles(ctx)
+		assert.NoError(t, err)
+
+		ret := make(map[string]string)
+		for _, f := range files {
+			ret[f.path] = f.owner.String()
+		}
+
+		return ret
+	}
+
+	assert.Equal(t, map[string]string{
+		"renderer/my-echo/a":      "renderer:my-echo",
+		"renderer/http/b":         "renderer:http",
+		"renderer/http/c":         "renderer:http",
+		"golang/_/test/unit/d":    "other:golang",
+		"buildah/image-id/digest": "other:buildah",
+	}, owners(t, &Options{}))
+
+	assert.Equal(t, map[string]string{
+		"renderer/http/b": "renderer:http",
+		"renderer/http/c": "renderer:http",
+	}, owners(t, &Options{renderers: []string{"ht*"}}))
+
+	assert.Empty(t, owners(t, &Options{tools: []string{"golang"}}))
+
+	t.Run("Stat", func(t *testing.T) {
+		_, files, err := (&Options{renderers: []string{"http"}}).selectedFiles(ctx)
+		assert.NoError(t, err)
+
+		buf := &bytes.Buffer{}
+		assert.NoError(t, statFiles(buf, files))
+		assert.Contains(t, buf.String(), "renderer:http")
+		assert.Contains(t, buf.String(), "TOTAL")
+	})
+
+	t.Run("Clean", func(t *testing.T) {
+		dir, files, err := (&Options{renderers: []string{"http"}}).selectedFiles(ctx)
+		assert.NoError(t, err)
+		assert.NoError(t, removeFiles(&bytes.Buffer{}, dir, files))
+
+		_, err = os.Stat(filepath.Join(cacheDir, "renderer", "http"))
+		assert.True(t, os.IsNotExist(err))
+		assert.Len(t, owners(t, &Options{}), 3)
+	})
+}
+
+func TestOptions_selected(t *testing.T) {
+	opts := &Options{tools: []string{"docker"}, tasks: []string{"golang:test:*"}}
+
+	for _, test := range []struct {
+		c        *component
+		expected bool
+	}{
+		{&component{kind: kindTool, name: "docker"}, true},
+		{&component{kind: kindTool, name: "golang"}, false},
+		{&component{kind: kindTask, name: "docker:build:app", tool: "docker"}, true},
+		{&component{kind: kindTask, name: "golang:test:unit", tool: "golang"}, true},
+		{&component{kind: kindTask, name: "golang:build:app", tool: "golang"}, false},
+		{&component{kind: kindRenderer, name: "http"}, false},
+		{&component{kind: kindOther, name: "buildah"}, false},
+	} {
+		assert.Equal(t, test.expected, opts.selected(test.c), test.c.String())
+	}
+
+	assert.True(t, (&Options{}).selected(&component{kind: kindOther, name: "buildah"}))
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "512B", formatSize(512))
+	assert.Equal(t, "1.5KB", formatSize(1536))
+	assert.Equal(t, "2.0MB", formatSize(2<<20))
+}
@@ -0,0 +1,217 @@
+package cache
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"arhat.dev/pkg/fshelper"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+// component kinds
+const (
+	kindRenderer = "renderer"
+	kindTool     = "tool"
+	kindTask     = "task"
+	kindOther    = "other"
+)
+
+// component is a sub dir of cache dir owned by a renderer, tool or task
+type component struct {
+	kind string
+	name string
+
+	// tool is the name of the tool managing the task (task only)
+	tool string
+
+	// dir is the slash separated path relative to cache dir
+	dir string
+}
+
+func (c *component) String() string { return c.kind + ":" + c.name }
+
+// cacheFile is a regular file in cache dir
+type cacheFile struct {
+	// path is the slash separated path relative to cache dir
+	path    string
+	size    int64
+	modTime time.Time
+
+	owner *component
+}
+
+func toolName(k dukkha.ToolKey) string {
+	if len(k.Name) == 0 {
+		return string(k.Kind)
+	}
+
+	return k.String()
+}
+
+// collectComponents finds cache dirs of renderers, tools and tasks in config,
+// and renderer cache dirs in cache dir
+func collectComponents(ctx dukkha.ConfigResolvingContext, cacheDir string) ([]*component, error) {
+	var ret []*component
+
+	relDir := func(ofs *fshelper.OSFS) (string, error) {
+		abs, err := ofs.Abs(".")
+		if err != nil {
+			return "", err
+		}
+
+		rel, err := filepath.Rel(cacheDir, abs)
+		if err != nil {
+			return "", err
+		}
+
+		return filepath.ToSlash(rel), nil
+	}
+
+	seenRenderers := make(map[string]struct{})
+	for name := range ctx.AllRenderers() {
+		dir, err := relDir(ctx.RendererCacheFS(name))
+		if err != nil {
+			return nil, err
+		}
+
+		seenRenderers[dir] = struct{}{}
+		ret = append(ret, &component{kind: kindRenderer, name: name, dir: dir})
+	}
+
+	entries, err := os.ReadDir(filepath.Join(cacheDir, kindRenderer))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		dir := path.Join(kindRenderer, entry.Name())
+		if _, ok := seenRenderers[dir]; ok || !entry.IsDir() {
+			continue
+		}
+
+		ret = append(ret, &component{kind: kindRenderer, name: entry.Name(), dir: dir})
+	}
+
+	for _, tool := range ctx.AllTools() {
+		dir, err := relDir(ctx.ToolCacheFS(tool))
+		if err != nil {
+			return nil, err
+		}
+
+		tName := toolName(tool.Key())
+		ret = append(ret, &component{kind: kindTool, name: tName, dir: dir})
+
+		for _, task := range tool.AllTasks() {
+			dir, err = relDir(ctx.TaskCacheFS(task))
+			if err != nil {
+				return nil, err
+			}
+
+			ret = append(ret, &component{
+				kind: kindTask,
+				name: tName + ":" + string(task.Kind()) + ":" + string(task.Name()),
+				tool: tName,
+				dir:  dir,
+			})
+		}
+	}
+
+	// longest dir first for owner lookup
+	sort.SliceStable(ret, func(i, j int) bool {
+		return len(ret[i].dir) > len(ret[j].dir)
+	})
+
+	return ret, nil
+}
+
+// scanCacheDir lists all regular files in cache dir with their owners,
+// files not owned by any known component are owned by `other` component
+// named after the top level dir
+func scanCacheDir(cacheDir string, components []*component) ([]*cacheFile, error) {
+	var ret []*cacheFile
+
+	others := make(map[string]*component)
+	err := filepath.WalkDir(cacheDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(cacheDir, p)
+		if err != nil {
+			return err
+		}
+
+		f := &cacheFile{
+			path:    filepath.ToSlash(rel),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		}
+
+		for _, c := range components {
+			if strings.HasPrefix(f.path, c.dir+"/") {
+				f.owner = c
+				break
+			}
+		}
+
+		if f.owner == nil {
+			top := strings.SplitN(f.path, "/", 2)[0]
+			f.owner = others[top]
+			if f.owner == nil {
+				f.owner = &component{kind: kindOther, name: top, dir: top}
+				others[top] = f.owner
+			}
+		}
+
+		ret = append(ret, f)
+		return nil
+	})
+
+	return ret, err
+}
+
+// removeFile removes cache file, which can be read-only
+func removeFile(cacheDir string, f *cacheFile) error {
+	p := filepath.Join(cacheDir, filepath.FromSlash(f.path))
+
+	// best effort
+	_ = os.Chmod(p, 0600)
+	return os.Remove(p)
+}
+
+// removeEmptyDirs removes empty dirs in dir (including dir itself)
+func removeEmptyDirs(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			removeEmptyDirs(filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	// fails when not empty
+	_ = os.Remove(dir)
+}
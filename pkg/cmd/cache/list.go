@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+func newListCmd(ctx *dukkha.Context, opts *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:           "list",
+		Short:         "List cache files",
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, files, err := opts.selectedFiles(*ctx)
+			if err != nil {
+				return err
+			}
+
+			return listFiles(os.Stdout, files)
+		},
+	}
+}
+
+func listFiles(w io.Writer, files []*cacheFile) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "OWNER\tSIZE\tMODIFIED\tPATH")
+	for _, f := range files {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+			f.owner, formatSize(f.size), f.modTime.Format(time.RFC3339), f.path,
+		)
+	}
+
+	return tw.Flush()
+}
+
+func newStatCmd(ctx *dukkha.Context, opts *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:           "stat",
+		Short:         "Show cache usage of renderers, tools and tasks",
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cacheDir, files, err := opts.selectedFiles(*ctx)
+			if err != nil {
+				return err
+			}
+
+			_, _ = fmt.Fprintln(os.Stdout, "cache dir:", cacheDir)
+			return statFiles(os.Stdout, files)
+		},
+	}
+}
+
+func statFiles(w io.Writer, files []*cacheFile) error {
+	type usage struct {
+		owner    *component
+		count    int
+		size     int64
+		modified time.Time
+	}
+
+	var (
+		owners []*usage
+		total  usage
+	)
+
+	index := make(map[*component]*usage)
+	for _, f := range files {
+		u, ok := index[f.owner]
+		if !ok {
+			u = &usage{owner: f.owner}
+			index[f.owner] = u
+			owners = append(owners, u)
+		}
+
+		for _, u := range []*usage{u, &total} {
+			u.count++
+			u.size += f.size
+			if f.modTime.After(u.modified) {
+				u.modified = f.modTime
+			}
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "OWNER\tFILES\tSIZE\tLAST MODIFIED")
+	for _, u := range append(owners, &total) {
+		name, modified := "TOTAL", "-"
+		if u.owner != nil {
+			name = u.owner.String()
+		}
+
+		if !u.modified.IsZero() {
+			modified = u.modified.Format(time.RFC3339)
+		}
+
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", name, u.count, formatSize(u.size), modified)
+	}
+
+	return tw.Flush()
+}
+
+// formatSize formats size in bytes with binary units
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f%cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+func newPruneCmd(ctx *dukkha.Context, opts *Options) *cobra.Command {
+	var olderThan time.Duration
+
+	pruneCmd := &cobra.Command{
+		Use:           "prune",
+		Short:         "Remove cache files not modified recently",
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cacheDir, files, err := opts.selectedFiles(*ctx)
+			if err != nil {
+				return err
+			}
+
+			notBefore := time.Now().Add(-olderThan)
+
+			var outdated []*cacheFile
+			for _, f := range files {
+				if f.modTime.Before(notBefore) {
+					outdated = append(outdated, f)
+				}
+			}
+
+			return removeFiles(os.Stdout, cacheDir, outdated)
+		},
+	}
+
+	pruneCmd.Flags().DurationVar(&olderThan, "older-than", 7*24*time.Hour,
+		"remove cache files last modified before this duration",
+	)
+
+	return pruneCmd
+}
+
+func newCleanCmd(ctx *dukkha.Context, opts *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:           "clean",
+		Short:         "Remove all cache files, or cache files of selected renderers, tools and tasks",
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cacheDir, files, err := opts.selectedFiles(*ctx)
+			if err != nil {
+				return err
+			}
+
+			return removeFiles(os.Stdout, cacheDir, files)
+		},
+	}
+}
+
+// removeFiles removes cache files and then dirs left empty
+func removeFiles(w io.Writer, cacheDir string, files []*cacheFile) error {
+	var (
+		count int
+		size  int64
+		dirs  = make(map[string]struct{})
+	)
+
+	for _, f := range files {
+		err := removeFile(cacheDir, f)
+		if err != nil {
+			return fmt.Errorf("removing cache file %q: %w", f.path, err)
+		}
+
+		count++
+		size += f.size
+		dirs[f.owner.dir] = struct{}{}
+	}
+
+	for dir := range dirs {
+		removeEmptyDirs(filepath.Join(cacheDir, filepath.FromSlash(dir)))
+	}
+
+	_, _ = fmt.Fprintf(w, "removed %d files (%s)\n", count, formatSize(size))
+	return nil
+}
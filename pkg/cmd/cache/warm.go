@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/renderer"
+	"arhat.dev/dukkha/pkg/tools"
+)
+
+func newWarmCmd(ctx *dukkha.Context, opts *Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "warm",
+		Short: "Fetch remote content referenced in config and tasks into cache",
+		Long: "Remote content referenced in config is fetched when resolving the config, " +
+			"then all tasks (or tasks selected by --tool and --task) are resolved " +
+			"for all matrix entries to fetch remote content referenced in tasks",
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if renderer.IsOffline() {
+				return fmt.Errorf("cannot warm cache in offline mode")
+			}
+
+			return warmTasks((*ctx).DeriveNew(), opts, os.Stdout)
+		},
+	}
+}
+
+// warmTasks resolves selected tasks for all matrix entries, remote content
+// fetched by renderers is cached as a side effect
+func warmTasks(appCtx dukkha.Context, opts *Options, w io.Writer) error {
+	var toolKeys []dukkha.ToolKey
+	for k := range appCtx.AllTools() {
+		toolKeys = append(toolKeys, k)
+	}
+
+	sort.Slice(toolKeys, func(i, j int) bool {
+		return toolKeys[i].String() < toolKeys[j].String()
+	})
+
+	failed := 0
+	for _, k := range toolKeys {
+		tool, _ := appCtx.GetTool(k)
+		tName := toolName(k)
+
+		var tasks []dukkha.Task
+		for _, task := range tool.AllTasks() {
+			tasks = append(tasks, task)
+		}
+
+		sort.Slice(tasks, func(i, j int) bool {
+			return tasks[i].Key().String() < tasks[j].Key().String()
+		})
+
+		for _, task := range tasks {
+			c := &component{
+				kind: kindTask,
+				name: tName + ":" + task.Key().String(),
+				tool: tName,
+			}
+
+			if !opts.selected(c) {
+				continue
+			}
+
+			count, err := warmTask(appCtx, tool, task)
+			if err != nil {
+				failed++
+				_, _ = fmt.Fprintf(w, "failed %s: %v\n", c.name, err)
+				continue
+			}
+
+			_, _ = fmt.Fprintf(w, "warmed %s (%d matrix entries)\n", c.name, count)
+		}
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("failed to warm cache for %d tasks", failed)
+	}
+
+	return nil
+}
+
+func warmTask(appCtx dukkha.Context, tool dukkha.Tool, task dukkha.Task) (int, error) {
+	matrixSpecs, err := task.GetMatrixSpecs(appCtx)
+	if err != nil {
+		return 0, fmt.Errorf("create task matrix specs: %w", err)
+	}
+
+	execOpts := dukkha.CreateTaskExecOptions(0, len(matrixSpecs))
+	tskCtx := appCtx.DeriveNew()
+	tskCtx.SetTask(tool.Key(), task.Key())
+
+	for _, ms := range matrixSpecs {
+		mCtx, _, err := tools.CreateTaskMatrixContext(
+			&tools.TaskExecRequest{
+				Context: tskCtx,
+				Tool:    tool,
+				Task:    task,
+			},
+			ms, execOpts,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("creating task matrix context for %s: %w", ms.BriefString(), err)
+		}
+
+		err = task.DoAfterFieldsResolved(mCtx, -1, true, func() error { return nil })
+		if err != nil {
+			return 0, fmt.Errorf("resolving task for %s: %w", ms.BriefString(), err)
+		}
+	}
+
+	return len(matrixSpecs), nil
+}
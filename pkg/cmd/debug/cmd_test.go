@@ -105,6 +105,7 @@ func prepareDebugCmd(flags []string) (checkFlags func() error, runCmd func() err
 		ctx,
 		os.DirFS("./testdata"),
 		[]string{"."},
+		nil,
 		false,
 		&map[string]struct{}{},
 		config,
@@ -0,0 +1,37 @@
+package debug
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"arhat.dev/dukkha/docs"
+	"arhat.dev/dukkha/pkg/tools/custom"
+)
+
+func NewDebugSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Show json schema of config, including custom tools declared in config",
+
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+
+		CompletionOptions: cobra.CompletionOptions{
+			DisableDefaultCmd:   false,
+			DisableNoDescFlag:   false,
+			DisableDescriptions: true,
+		},
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := custom.ExtendJSONSchema(docs.Schema)
+			if err != nil {
+				return err
+			}
+
+			_, err = os.Stdout.Write(data)
+			return err
+		},
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"arhat.dev/pkg/textquery"
 	"github.com/spf13/cobra"
+	"go.uber.org/multierr"
 	"gopkg.in/yaml.v3"
 
 	"arhat.dev/dukkha/pkg/cmd/utils"
@@ -47,7 +48,7 @@ func NewDebugTaskSpecCmd(ctx *dukkha.Context, opts *Options) *cobra.Command {
 				return err
 			}
 
-			return forEachTask(appCtx, args,
+			err = forEachTask(appCtx, args,
 				func(appCtx dukkha.Context, tool dukkha.Tool, task dukkha.Task, _, _ int) error {
 					matrixSpecs, err := task.GetMatrixSpecs(appCtx)
 					if err != nil {
@@ -137,11 +138,14 @@ func NewDebugTaskSpecCmd(ctx *dukkha.Context, opts *Options) *cobra.Command {
 					return nil
 				},
 			)
+
+			return multierr.Append(err, utils.WriteRenderingTrace(appCtx, os.Stderr))
 		},
 	}
 
 	flags := debugTaskSpecCmd.Flags()
 	utils.RegisterMatrixFilterFlag(flags, &matrixFilter)
+	utils.RegisterTraceRenderingFlag(flags)
 	err := utils.SetupTaskAndTaskMatrixCompletion(ctx, debugTaskSpecCmd)
 	if err != nil {
 		panic(err)
@@ -8,6 +8,7 @@ import (
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
 
+	"arhat.dev/dukkha/pkg/cmd/utils"
 	"arhat.dev/dukkha/pkg/diff"
 	"arhat.dev/dukkha/pkg/dukkha"
 )
@@ -69,7 +70,7 @@ func diffFile(rc dukkha.RenderingContext, srcDocSrc, baseDocSrc, newDocSrc strin
 			return yaml.NewDecoder(os.Stdin), func() {}, nil
 		}
 
-		rd, err := rc.FS().Open(src)
+		rd, err := utils.CwdFS().Open(src)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -21,25 +21,35 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"arhat.dev/pkg/fshelper"
 	"arhat.dev/pkg/log"
 	"github.com/spf13/cobra"
 
+	di "arhat.dev/dukkha/internal"
+	"arhat.dev/dukkha/pkg/cmd/cache"
 	"arhat.dev/dukkha/pkg/cmd/completion"
 	"arhat.dev/dukkha/pkg/cmd/debug"
 	"arhat.dev/dukkha/pkg/cmd/diff"
 	"arhat.dev/dukkha/pkg/cmd/render"
 	"arhat.dev/dukkha/pkg/cmd/run"
+	"arhat.dev/dukkha/pkg/cmd/secret"
+	"arhat.dev/dukkha/pkg/cmd/utils"
 	"arhat.dev/dukkha/pkg/conf"
+	"arhat.dev/dukkha/pkg/constant"
 	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/plugin"
+	"arhat.dev/dukkha/pkg/renderer"
 	"arhat.dev/dukkha/pkg/renderer/echo"
 	"arhat.dev/dukkha/pkg/renderer/env"
 	"arhat.dev/dukkha/pkg/renderer/file"
 	"arhat.dev/dukkha/pkg/renderer/shell"
 	"arhat.dev/dukkha/pkg/renderer/tpl"
 	"arhat.dev/dukkha/pkg/renderer/transform"
+	"arhat.dev/dukkha/pkg/tools"
 )
 
 // NewRootCmd creates the dukkha command with all sub commands added
@@ -48,6 +58,15 @@ func NewRootCmd() *cobra.Command {
 		logConfig = new(log.Config)
 
 		configPaths []string
+		configExts  []string
+		profiles    []string
+		inputs      []string
+		offline     bool
+
+		remoteCache         string
+		remoteCacheReadOnly bool
+
+		renderConcurrency int
 		// merged config
 		config = conf.NewConfig()
 
@@ -94,17 +113,92 @@ func NewRootCmd() *cobra.Command {
 
 			logger := log.Log.WithName("pre-run")
 
+			if !cmd.Flags().Changed("offline") {
+				offline, _ = strconv.ParseBool(os.Getenv(constant.ENV_DUKKHA_OFFLINE))
+			}
+			renderer.SetOffline(offline)
+
+			if !cmd.Flags().Changed("remote-cache") {
+				remoteCache = os.Getenv(constant.ENV_DUKKHA_REMOTE_CACHE)
+			}
+
+			if !cmd.Flags().Changed("remote-cache-read-only") {
+				remoteCacheReadOnly, _ = strconv.ParseBool(os.Getenv(constant.ENV_DUKKHA_REMOTE_CACHE_READ_ONLY))
+			}
+
+			// remote cache is never accessed in offline mode
+			if len(remoteCache) != 0 && !offline {
+				store, err2 := newRemoteCacheStore(remoteCache)
+				if err2 != nil {
+					return err2
+				}
+
+				renderer.SetRemoteCache(store, remoteCacheReadOnly)
+				tools.SetRemoteCache(store, remoteCacheReadOnly)
+			}
+
 			cwd, err := os.Getwd()
 			if err != nil {
 				return fmt.Errorf("check working dir: %w", err)
 			}
 
+			// flags of the root command are inherited by sub commands, only
+			// FlagSet of cmd knows whether they are set
+			configSet := cmd.Flags().Changed("config")
+			if !configSet {
+				// discover config from cwd up to the project root
+				var discovered []string
+				cwd, discovered, err = conf.Discover(cwd, configExts)
+				if err != nil {
+					return fmt.Errorf("discover config: %w", err)
+				}
+
+				if len(discovered) != 0 {
+					configPaths = discovered
+				}
+
+				logger.V("discovered config",
+					log.String("work_dir", cwd),
+					log.Strings("config_paths", configPaths),
+				)
+			}
+
 			_appCtx := dukkha.NewConfigResolvingContext(
 				appBaseCtx, dukkha.GlobalInterfaceTypeHandler,
 				createGlobalEnv(appBaseCtx, cwd),
 			)
 			_appCtx.AddListEnv(os.Environ()...)
 
+			// trace rendering from config loading
+			utils.SetupRenderingTracer(cmd.Flags(), _appCtx)
+
+			if !cmd.Flags().Changed("render-concurrency") {
+				renderConcurrency, _ = strconv.Atoi(os.Getenv(constant.ENV_DUKKHA_RENDER_CONCURRENCY))
+			}
+			_appCtx.(di.RenderConcurrencySetter).SetRenderConcurrency(renderConcurrency)
+
+			// answers to input renderer override env
+			for _, in := range inputs {
+				parts := strings.SplitN(in, "=", 2)
+				if len(parts) != 2 || len(parts[0]) == 0 {
+					return fmt.Errorf("invalid input %q, expecting key=value", in)
+				}
+
+				_appCtx.AddListEnv(constant.GetInputEnvName(parts[0]) + "=" + parts[1])
+			}
+
+			// plugins are started on demand when config references renderers
+			// or tool kinds not registered, and stopped by plugin.CloseAll
+			{
+				plugins, err2 := plugin.Discover(filepath.SplitList(os.Getenv("PATH")))
+				if err2 != nil {
+					return fmt.Errorf("discover plugins: %w", err2)
+				}
+
+				logger.V("found plugins", log.Int("count", len(plugins)))
+				dukkha.SetTypeResolver(plugin.NewResolver(plugins))
+			}
+
 			// add essential renderers for bootstraping
 			{
 				logger.V("creating essential renderers")
@@ -130,9 +224,12 @@ func NewRootCmd() *cobra.Command {
 			visitedPaths := make(map[string]struct{})
 			err = conf.Read(
 				_appCtx,
-				fshelper.NewOSFS(false, os.Getwd),
+				fshelper.NewOSFS(false, func() (string, error) {
+					return cwd, nil
+				}),
 				configPaths,
-				!cmd.PersistentFlags().Changed("config"),
+				configExts,
+				!configSet,
 				&visitedPaths,
 				config,
 			)
@@ -140,12 +237,28 @@ func NewRootCmd() *cobra.Command {
 				return fmt.Errorf("loading config: %w", err)
 			}
 
+			if !cmd.Flags().Changed("profile") {
+				profiles = nil
+				for _, p := range strings.Split(os.Getenv(constant.ENV_DUKKHA_PROFILE), ",") {
+					if p = strings.TrimSpace(p); len(p) != 0 {
+						profiles = append(profiles, p)
+					}
+				}
+			}
+
+			logger.V("applying profiles", log.Strings("profiles", profiles))
+			err = config.ApplyProfiles(profiles)
+			if err != nil {
+				return fmt.Errorf("loading config: %w", err)
+			}
+
 			logger.V("initializing dukkha", log.Any("raw_config", config))
 
 			var needTasks bool
 			switch {
 			case strings.HasPrefix(cmd.Use, "render"),
-				strings.HasPrefix(cmd.Use, "diff"):
+				strings.HasPrefix(cmd.Use, "diff"),
+				cmd.HasParent() && strings.HasPrefix(cmd.Parent().Use, "secret"):
 				needTasks = false
 			case strings.HasPrefix(cmd.Use, "debug"):
 				needTasks = len(args) != 0
@@ -170,8 +283,53 @@ func NewRootCmd() *cobra.Command {
 	globalFlags := rootCmd.PersistentFlags()
 	globalFlags.StringSliceVarP(
 		&configPaths, "config", "c", []string{".dukkha.yaml"},
-		"path to your config files and directories, if a directory is provided"+
-			"only files with .yaml extension in that directory are parsed",
+		"path to your config files and directories, if a directory is provided "+
+			"only files with extensions set by --config-ext in that directory are parsed, "+
+			"when not set, config is discovered from the current dir up to the project root",
+	)
+
+	globalFlags.StringSliceVar(
+		&configExts, "config-ext", conf.DefaultConfigExtensions,
+		"extensions of config files parsed in config directories and looked up during "+
+			"config discovery, e.g. --config-ext .yaml,.yml,.json",
+	)
+
+	globalFlags.StringSliceVar(
+		&profiles, "profile", nil,
+		"config profiles to apply in order, "+
+			"defaults to comma separated names in env DUKKHA_PROFILE",
+	)
+
+	globalFlags.StringArrayVar(
+		&inputs, "input", nil,
+		"answer input renderer non-interactively in key=value format, "+
+			"the key is set in the input spec",
+	)
+
+	globalFlags.BoolVar(
+		&offline, "offline", false,
+		"serve remote content from local cache only and never access network, "+
+			"defaults to env DUKKHA_OFFLINE",
+	)
+
+	globalFlags.StringVar(
+		&remoteCache, "remote-cache", "",
+		"url of remote cache shared by renderers with caching enabled, "+
+			"one of [http(s)://<host>/<path>, s3+http(s)://<endpoint>/<bucket>/<base-path>?region=<region>], "+
+			"defaults to env DUKKHA_REMOTE_CACHE",
+	)
+
+	globalFlags.BoolVar(
+		&remoteCacheReadOnly, "remote-cache-read-only", false,
+		"do not upload fetched content to remote cache, "+
+			"defaults to env DUKKHA_REMOTE_CACHE_READ_ONLY",
+	)
+
+	globalFlags.IntVar(
+		&renderConcurrency, "render-concurrency", 0,
+		"max count of independent fields (e.g. global values) rendered at the same time "+
+			"by concurrency safe renderers, values less than 2 disable parallel rendering, "+
+			"ignored when --trace-rendering is set, defaults to env DUKKHA_RENDER_CONCURRENCY",
 	)
 
 	// logging for debugging purpose
@@ -200,6 +358,7 @@ func NewRootCmd() *cobra.Command {
 
 	debugCmd.AddCommand(
 		debugTaskCmd,
+		debug.NewDebugSchemaCmd(),
 	)
 
 	rootCmd.AddCommand(
@@ -212,6 +371,10 @@ func NewRootCmd() *cobra.Command {
 		// dukkha run
 		run.NewRunCmd(&appCtx),
 		diff.NewDiffCmd(&appCtx),
+		// dukkha secret
+		secret.NewSecretCmd(&appCtx),
+		// dukkha cache
+		cache.NewCacheCmd(&appCtx),
 	)
 
 	return rootCmd
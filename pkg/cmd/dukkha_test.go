@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"arhat.dev/dukkha/pkg/constant"
+)
+
+// newTestProject creates a project with files in a temporary dir and changes
+// working dir to subDir of it, the returned func restores working dir
+func newTestProject(t *testing.T, files map[string]string, subDir string) (projectDir string, restore func()) {
+	projectDir = filepath.Join(t.TempDir(), "project")
+	for name, data := range files {
+		file := filepath.Join(projectDir, name)
+		if !assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0755)) {
+			t.FailNow()
+		}
+
+		if !assert.NoError(t, os.WriteFile(file, []byte(data), 0644)) {
+			t.FailNow()
+		}
+	}
+
+	cwd, err := os.Getwd()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if !assert.NoError(t, os.Chdir(filepath.Join(projectDir, subDir))) {
+		t.FailNow()
+	}
+
+	return projectDir, func() { _ = os.Chdir(cwd) }
+}
+
+func runRootCmd(t *testing.T, args ...string) error {
+	rootCmd := NewRootCmd()
+	rootCmd.SetArgs(append([]string{"--log.level", "silent"}, args...))
+
+	return rootCmd.Execute()
+}
+
+func TestRootCmd_Config(t *testing.T) {
+	projectDir, restore := newTestProject(t, map[string]string{
+		".git/HEAD":      "",
+		".dukkha.yaml":   "global:\n  values:\n    foo: root\n",
+		"pkg/a/alt.yaml": "global:\n  values:\n    foo: alt\n",
+		"pkg/a/in.yaml":  "foo@tpl: \"{{ values.foo }}\"\n",
+	}, "pkg/a")
+	defer restore()
+
+	for _, test := range []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "Discovered",
+			args:     []string{"render", "in.yaml", "-o", "out.yaml"},
+			expected: "foo: root\n",
+		},
+		{
+			name:     "Flag",
+			args:     []string{"render", "-c", "alt.yaml", "in.yaml", "-o", "out.yaml"},
+			expected: "foo: alt\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if !assert.NoError(t, runRootCmd(t, test.args...)) {
+				return
+			}
+
+			// relative paths in args are relative to the current working dir
+			// regardless of where config was found
+			data, err := os.ReadFile(filepath.Join(projectDir, "pkg", "a", "out.yaml"))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, test.expected, string(data))
+		})
+	}
+}
+
+func TestRootCmd_Profile(t *testing.T) {
+	projectDir, restore := newTestProject(t, map[string]string{
+		".git/HEAD": "",
+		".dukkha.yaml": `
+global:
+  values:
+    foo: root
+
+profiles:
+  env:
+    global:
+      values:
+        foo: env
+  flag:
+    global:
+      values:
+        foo: flag
+`,
+		"in.yaml": "foo@tpl: \"{{ values.foo }}\"\n",
+	}, "")
+	defer restore()
+
+	for _, test := range []struct {
+		name     string
+		env      string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "None",
+			args:     []string{"render", "in.yaml", "-o", "out.yaml"},
+			expected: "foo: root\n",
+		},
+		{
+			name:     "Env",
+			env:      "env",
+			args:     []string{"render", "in.yaml", "-o", "out.yaml"},
+			expected: "foo: env\n",
+		},
+		{
+			name:     "Flag",
+			env:      "env",
+			args:     []string{"render", "--profile", "flag", "in.yaml", "-o", "out.yaml"},
+			expected: "foo: flag\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			oldEnv, hasEnv := os.LookupEnv(constant.ENV_DUKKHA_PROFILE)
+			defer func() {
+				if hasEnv {
+					_ = os.Setenv(constant.ENV_DUKKHA_PROFILE, oldEnv)
+				} else {
+					_ = os.Unsetenv(constant.ENV_DUKKHA_PROFILE)
+				}
+			}()
+			assert.NoError(t, os.Setenv(constant.ENV_DUKKHA_PROFILE, test.env))
+
+			if !assert.NoError(t, runRootCmd(t, test.args...)) {
+				return
+			}
+
+			data, err := os.ReadFile(filepath.Join(projectDir, "out.yaml"))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, test.expected, string(data))
+		})
+	}
+}
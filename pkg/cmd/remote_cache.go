@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"arhat.dev/dukkha/pkg/cache"
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/renderer/s3"
+)
+
+// newRemoteCacheStore creates remote cache store by url scheme
+//
+//   - `http://` and `https://`: objects are stored at `<url>/<key>`
+//     with GET and PUT requests, bearer token is read from
+//     env DUKKHA_REMOTE_CACHE_TOKEN
+//   - `s3+http://` and `s3+https://`: url in format
+//     `s3+https://<endpoint>/<bucket>/<base-path>?region=<region>`,
+//     credentials are read from env DUKKHA_REMOTE_CACHE_ACCESS_KEY_ID
+//     and DUKKHA_REMOTE_CACHE_ACCESS_KEY_SECRET
+func newRemoteCacheStore(rawURL string) (cache.RemoteStore, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote cache url: %w", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		header := make(http.Header)
+		if token := os.Getenv(constant.ENV_DUKKHA_REMOTE_CACHE_TOKEN); len(token) != 0 {
+			header.Set("Authorization", "Bearer "+token)
+		}
+
+		return cache.NewHTTPRemoteStore(rawURL, nil, header)
+	case "s3+http", "s3+https":
+		parts := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 2)
+		if len(parts[0]) == 0 {
+			return nil, fmt.Errorf("invalid remote cache url %q: missing bucket name", rawURL)
+		}
+
+		config := &s3.Config{
+			EndpointURL: strings.TrimPrefix(u.Scheme, "s3+") + "://" + u.Host,
+			Region:      u.Query().Get("region"),
+			Bucket:      parts[0],
+
+			AccessKeyID:     os.Getenv(constant.ENV_DUKKHA_REMOTE_CACHE_ACCESS_KEY_ID),
+			AccessKeySecret: os.Getenv(constant.ENV_DUKKHA_REMOTE_CACHE_ACCESS_KEY_SECRET),
+		}
+
+		if len(parts) == 2 {
+			config.BasePath = parts[1]
+		}
+
+		client, err := config.CreateClient()
+		if err != nil {
+			return nil, err
+		}
+
+		return s3.NewRemoteCacheStore(client), nil
+	default:
+		return nil, fmt.Errorf("unsupported remote cache url scheme %q", u.Scheme)
+	}
+}
@@ -7,8 +7,10 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"go.uber.org/multierr"
 
 	di "arhat.dev/dukkha/internal"
+	"arhat.dev/dukkha/pkg/cmd/utils"
 	"arhat.dev/dukkha/pkg/dukkha"
 )
 
@@ -30,7 +32,8 @@ func NewRenderCmd(ctx *dukkha.Context) *cobra.Command {
 		},
 
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return run(*ctx, opts, args, os.Stdout)
+			err := run(*ctx, opts, args, os.Stdout)
+			return multierr.Append(err, utils.WriteRenderingTrace(*ctx, os.Stderr))
 		},
 	}
 
@@ -62,6 +65,7 @@ func createOptionsFlags(cmd *cobra.Command, opts *Options) {
 		"set root of the soure for specified inputs (args) for relative path resovling, "+
 			"useful when you are rendering single file inside some child directory of the source directory",
 	)
+	utils.RegisterTraceRenderingFlag(flags)
 }
 
 func run(appCtx dukkha.Context, opts *Options, args []string, stdout io.Writer) error {
@@ -70,7 +74,7 @@ func run(appCtx dukkha.Context, opts *Options, args []string, stdout io.Writer)
 		args = append(args, "-")
 	}
 
-	resolvedOpts, err := opts.Resolve(appCtx.FS(), args, stdout)
+	resolvedOpts, err := opts.Resolve(utils.CwdFS(), args, stdout)
 	if err != nil {
 		return fmt.Errorf("invalid options: %w", err)
 	}
@@ -80,6 +84,7 @@ func run(appCtx dukkha.Context, opts *Options, args []string, stdout io.Writer)
 		if src == "-" {
 			err = renderYamlReader(
 				appCtx,
+				"-",
 				os.Stdin,
 				resolvedOpts.OutputPathFor("-"),
 				0664,
@@ -99,7 +99,7 @@ func renderYamlFile(
 		destPath = &dest
 	}
 
-	return renderYamlReader(rc, srcFile, destPath, srcPerm[srcPath], opts)
+	return renderYamlReader(rc, srcPath, srcFile, destPath, srcPerm[srcPath], opts)
 }
 
 func ensureDestDir(ofs *fshelper.OSFS, srcPath, destPath string, srcPerm map[string]fs.FileMode) error {
@@ -12,17 +12,21 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/output"
 )
 
 func renderYamlReader(
 	rc dukkha.Context,
+	srcName string,
 	src io.Reader,
 	destPath *string,
 	destPerm fs.FileMode,
 	opts *ResolvedOptions,
 ) error {
-	dec := yaml.NewDecoder(src)
-	ret, err := parseYaml(dec)
+	docs, ret, err := parseYamlDocs(yaml.NewDecoder(src))
+	for _, doc := range docs {
+		output.TraceDocument(rc, srcName, doc)
+	}
 
 	// always write parsed yaml docs, regardless of errors
 
@@ -55,8 +59,11 @@ func renderYamlReader(
 		}
 	}
 
-	for _, doc := range ret {
+	for i, doc := range ret {
+		// top level fields in the same doc are independent
+		done := dukkha.PreRender(rc, docs[i])
 		err2 := doc.ResolveFields(rc, -1)
+		done()
 		if err2 != nil {
 			return multierr.Append(err, err2)
 		}
@@ -90,3 +97,27 @@ func parseYaml(dec *yaml.Decoder) ([]*rs.AnyObject, error) {
 		ret = append(ret, obj)
 	}
 }
+
+// parseYamlDocs is parseYaml with raw yaml docs returned, docs[i] is the
+// raw doc of objs[i]
+func parseYamlDocs(dec *yaml.Decoder) (docs []*yaml.Node, objs []*rs.AnyObject, err error) {
+	for {
+		doc := new(yaml.Node)
+		err = dec.Decode(doc)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, objs, nil
+			}
+
+			return docs, objs, err
+		}
+
+		obj := &rs.AnyObject{}
+		err = doc.Decode(obj)
+		if err != nil {
+			return docs, objs, err
+		}
+
+		docs, objs = append(docs, doc), append(objs, obj)
+	}
+}
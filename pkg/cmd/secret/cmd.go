@@ -0,0 +1,135 @@
+package secret
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"arhat.dev/dukkha/pkg/cmd/utils"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/secret"
+)
+
+// Options cli options available to all secret commands
+type Options struct {
+	ageIdentityFile string
+	ageRecipients   []string
+	aesKeyFile      string
+	aes             bool
+}
+
+// method returns encryption method for new secrets
+func (opts *Options) method() secret.Method {
+	if opts.aes {
+		return secret.MethodAESGCM
+	}
+
+	return secret.MethodAge
+}
+
+// keyring loads keys in cli flags, then keys in env DUKKHA_SECRET_*
+func (opts *Options) keyring(ctx dukkha.Context) (*secret.Keyring, error) {
+	kr := &secret.Keyring{}
+
+	if len(opts.ageIdentityFile) != 0 {
+		data, err := utils.CwdFS().ReadFile(opts.ageIdentityFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading age identity file: %w", err)
+		}
+
+		err = kr.AddAgeIdentities(string(data))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(opts.aesKeyFile) != 0 {
+		data, err := utils.CwdFS().ReadFile(opts.aesKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading aes key file: %w", err)
+		}
+
+		err = kr.SetAESKey(string(data))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := kr.LoadEnv(os.Getenv, ctx.FS().ReadFile)
+	if err != nil {
+		return nil, err
+	}
+
+	err = addAgeRecipients(kr, opts.ageRecipients)
+	if err != nil {
+		return nil, err
+	}
+
+	return kr, nil
+}
+
+// addAgeRecipients adds recipients not in the keyring
+func addAgeRecipients(kr *secret.Keyring, recipients []string) error {
+	existing := make(map[string]struct{})
+	for _, r := range kr.AgeRecipients {
+		existing[fmt.Sprint(r)] = struct{}{}
+	}
+
+	for _, r := range recipients {
+		r = strings.TrimSpace(r)
+		if _, ok := existing[r]; ok || len(r) == 0 {
+			continue
+		}
+
+		existing[r] = struct{}{}
+		err := kr.AddAgeRecipients(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func NewSecretCmd(ctx *dukkha.Context) *cobra.Command {
+	opts := &Options{}
+
+	secretCmd := &cobra.Command{
+		Use:           "secret",
+		Short:         "Manage encrypted secret files",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		CompletionOptions: cobra.CompletionOptions{
+			DisableDefaultCmd:   false,
+			DisableNoDescFlag:   false,
+			DisableDescriptions: true,
+		},
+	}
+
+	flags := secretCmd.PersistentFlags()
+	flags.StringVar(&opts.ageIdentityFile, "age-identity-file", "",
+		"set path to age identity file, "+
+			"defaults to env DUKKHA_SECRET_AGE_IDENTITY_FILE",
+	)
+	flags.StringSliceVarP(&opts.ageRecipients, "recipient", "r", nil,
+		"add age recipients (age1...) to encrypt for, "+
+			"recipients of loaded age identities are always included",
+	)
+	flags.StringVar(&opts.aesKeyFile, "aes-key-file", "",
+		"set path to aes key file, defaults to env DUKKHA_SECRET_AES_KEY_FILE",
+	)
+	flags.BoolVar(&opts.aes, "aes", false,
+		"encrypt new secrets with aes key instead of age",
+	)
+
+	secretCmd.AddCommand(
+		newEncryptCmd(ctx, opts),
+		newDecryptCmd(ctx, opts),
+		newEditCmd(ctx, opts),
+		newKeygenCmd(ctx),
+	)
+
+	return secretCmd
+}
@@ -0,0 +1,67 @@
+package secret
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	dukkha_test "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/secret"
+)
+
+func TestEditFile(t *testing.T) {
+	ctx := dukkha_test.NewTestContext(context.TODO())
+	dir := t.TempDir()
+
+	identity, recipient, err := secret.GenerateAgeIdentity()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	keyFile := filepath.Join(dir, "keys.txt")
+	assert.NoError(t, os.WriteFile(keyFile, []byte(identity+"\n"), 0600))
+
+	opts := &Options{ageIdentityFile: keyFile}
+	file := filepath.Join(dir, "secret.yaml")
+
+	// create new file
+	assert.NoError(t, editFile(ctx, opts, file, func(tmpFile string) error {
+		return os.WriteFile(tmpFile, []byte("password: foo-bar\n"), 0600)
+	}))
+
+	encrypted, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(encrypted), "password: ENC[age,str,")
+	assert.Contains(t, string(encrypted), recipient)
+
+	// unchanged
+	assert.NoError(t, editFile(ctx, opts, file, func(tmpFile string) error {
+		data, err2 := os.ReadFile(tmpFile)
+		assert.Equal(t, "password: foo-bar\n", string(data))
+		return err2
+	}))
+
+	unchanged, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, string(encrypted), string(unchanged))
+
+	// method is kept when editing
+	opts.aes = true
+	assert.NoError(t, editFile(ctx, opts, file, func(tmpFile string) error {
+		return os.WriteFile(tmpFile, []byte("password: foo-bar\nuser: foo\n"), 0600)
+	}))
+
+	kr, err := opts.keyring(ctx)
+	assert.NoError(t, err)
+
+	updated, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(updated), "user: ENC[age,str,")
+
+	plaintext, _, err := kr.Decrypt(updated)
+	assert.NoError(t, err)
+	assert.Equal(t, "password: foo-bar\nuser: foo\n", string(plaintext))
+}
@@ -0,0 +1,168 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age/armor"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/cmd/utils"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/secret"
+)
+
+func newEditCmd(ctx *dukkha.Context, opts *Options) *cobra.Command {
+	editCmd := &cobra.Command{
+		Use:   "edit <file>",
+		Short: "Edit secret file in $EDITOR",
+		Long: "Decrypt secret file to a temporary file, open it in $EDITOR and " +
+			"encrypt it back with the same method and recipients when changed, " +
+			"a new file is created when the file does not exist",
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return editFile(*ctx, opts, args[0], runEditor)
+		},
+	}
+
+	return editCmd
+}
+
+// runEditor opens file in $EDITOR, defaults to vi
+func runEditor(file string) error {
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+
+	cmd := exec.Command(editor[0], append(editor[1:], file)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	return cmd.Run()
+}
+
+func editFile(ctx dukkha.Context, opts *Options, file string, edit func(file string) error) error {
+	kr, err := opts.keyring(ctx)
+	if err != nil {
+		return err
+	}
+
+	exists := true
+	data, err := utils.CwdFS().ReadFile(file)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("reading secret file: %w", err)
+		}
+
+		exists = false
+	}
+
+	var (
+		method    = opts.method()
+		wholeFile bool
+		armored   bool
+		plaintext []byte
+	)
+
+	switch {
+	case len(bytes.TrimSpace(data)) == 0:
+		// new secret yaml
+	case secret.IsAgeFile(data):
+		method, wholeFile = secret.MethodAge, true
+		armored = bytes.HasPrefix(bytes.TrimSpace(data), []byte(armor.Header))
+	case secret.IsEncryptedValue(string(data)):
+		method, wholeFile = secret.MethodAESGCM, true
+	default:
+		var md *secret.Metadata
+		md, err = readMetadata(data)
+		if err != nil {
+			return err
+		}
+
+		if md != nil {
+			method = md.Method
+
+			// keep existing recipients
+			err = addAgeRecipients(kr, md.AgeRecipients)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(data) != 0 {
+		plaintext, _, err = kr.Decrypt(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "dukkha-secret-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	tmpFile := filepath.Join(tmpDir, filepath.Base(file))
+	err = os.WriteFile(tmpFile, plaintext, 0600)
+	if err != nil {
+		return err
+	}
+
+	err = edit(tmpFile)
+	if err != nil {
+		return fmt.Errorf("running editor: %w", err)
+	}
+
+	edited, err := os.ReadFile(tmpFile)
+	if err != nil {
+		return err
+	}
+
+	if exists && bytes.Equal(edited, plaintext) {
+		return nil
+	}
+
+	var result []byte
+	if wholeFile {
+		result, err = kr.EncryptFile(method, edited, armored)
+	} else {
+		result, err = kr.EncryptYaml(method, edited)
+	}
+	if err != nil {
+		return fmt.Errorf("encrypting secret file: %w", err)
+	}
+
+	return utils.CwdFS().WriteFile(file, result, 0600)
+}
+
+// readMetadata returns the first secret metadata found in yaml docs
+func readMetadata(data []byte) (*secret.Metadata, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		doc := &yaml.Node{}
+		err := dec.Decode(doc)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, nil
+			}
+
+			return nil, fmt.Errorf("decoding secret yaml: %w", err)
+		}
+
+		md, err := secret.ReadMetadata(doc)
+		if err != nil || md != nil {
+			return md, err
+		}
+	}
+}
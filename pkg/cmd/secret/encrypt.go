@@ -0,0 +1,173 @@
+package secret
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"arhat.dev/dukkha/pkg/cmd/utils"
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+// ioOptions are options for commands transforming files
+type ioOptions struct {
+	inPlace bool
+	output  string
+}
+
+func (o *ioOptions) addFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.BoolVarP(&o.inPlace, "in-place", "i", false,
+		"write result back to the input file",
+	)
+	flags.StringVarP(&o.output, "output", "o", "",
+		"write result to file instead of stdout",
+	)
+}
+
+// run reads src (`-` for stdin), transforms its content and writes the result
+func (o *ioOptions) run(
+	ctx dukkha.Context, src string, stdout io.Writer,
+	transform func(data []byte) ([]byte, error),
+) error {
+	if o.inPlace && src == "-" {
+		return fmt.Errorf("stdin cannot be edited in place")
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if src == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = utils.CwdFS().ReadFile(src)
+	}
+	if err != nil {
+		return fmt.Errorf("reading %q: %w", src, err)
+	}
+
+	result, err := transform(data)
+	if err != nil {
+		return fmt.Errorf("%q: %w", src, err)
+	}
+
+	var dest string
+	switch {
+	case o.inPlace:
+		dest = src
+	case len(o.output) != 0:
+		dest = o.output
+	default:
+		_, err = stdout.Write(result)
+		return err
+	}
+
+	return utils.CwdFS().WriteFile(dest, result, 0600)
+}
+
+func checkArgs(o *ioOptions) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(o.output) != 0 && (o.inPlace || len(args) > 1) {
+			return fmt.Errorf("--output requires single input and no --in-place")
+		}
+
+		return nil
+	}
+}
+
+func newEncryptCmd(ctx *dukkha.Context, opts *Options) *cobra.Command {
+	var (
+		ioOpts    ioOptions
+		wholeFile bool
+		armor     bool
+	)
+
+	encryptCmd := &cobra.Command{
+		Use:   "encrypt [file...]",
+		Short: "Encrypt yaml values or whole files",
+		Long: "Encrypt yaml values or whole files, yaml keys and comments are kept " +
+			"in plaintext unless --whole-file is set, read stdin when no file provided",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				args = append(args, "-")
+			}
+
+			kr, err := opts.keyring(*ctx)
+			if err != nil {
+				return err
+			}
+
+			method := opts.method()
+			for _, src := range args {
+				err = ioOpts.run(*ctx, src, cmd.OutOrStdout(), func(data []byte) ([]byte, error) {
+					if wholeFile {
+						return kr.EncryptFile(method, data, armor)
+					}
+
+					return kr.EncryptYaml(method, data)
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+
+	encryptCmd.Args = checkArgs(&ioOpts)
+	ioOpts.addFlags(encryptCmd)
+
+	flags := encryptCmd.Flags()
+	flags.BoolVar(&wholeFile, "whole-file", false,
+		"encrypt the whole file instead of yaml values (e.g. for certificates and keys)",
+	)
+	flags.BoolVar(&armor, "armor", false,
+		"use PEM encoded age format for --whole-file",
+	)
+
+	return encryptCmd
+}
+
+func newDecryptCmd(ctx *dukkha.Context, opts *Options) *cobra.Command {
+	var ioOpts ioOptions
+
+	decryptCmd := &cobra.Command{
+		Use:           "decrypt [file...]",
+		Short:         "Decrypt secret files",
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				args = append(args, "-")
+			}
+
+			kr, err := opts.keyring(*ctx)
+			if err != nil {
+				return err
+			}
+
+			for _, src := range args {
+				err = ioOpts.run(*ctx, src, cmd.OutOrStdout(), func(data []byte) ([]byte, error) {
+					plaintext, _, err2 := kr.Decrypt(data)
+					return plaintext, err2
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+
+	decryptCmd.Args = checkArgs(&ioOpts)
+	ioOpts.addFlags(decryptCmd)
+
+	return decryptCmd
+}
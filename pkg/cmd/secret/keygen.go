@@ -0,0 +1,83 @@
+package secret
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"arhat.dev/dukkha/pkg/cmd/utils"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/secret"
+)
+
+func newKeygenCmd(ctx *dukkha.Context) *cobra.Command {
+	var (
+		aes    bool
+		output string
+	)
+
+	keygenCmd := &cobra.Command{
+		Use:           "keygen",
+		Short:         "Generate age identity or aes key",
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var (
+				key []byte
+				err error
+			)
+			if aes {
+				key, err = generateAESKey()
+			} else {
+				key, err = generateAgeIdentity(cmd.ErrOrStderr())
+			}
+			if err != nil {
+				return err
+			}
+
+			if len(output) == 0 {
+				_, err = cmd.OutOrStdout().Write(key)
+				return err
+			}
+
+			return utils.CwdFS().WriteFile(output, key, 0600)
+		},
+	}
+
+	flags := keygenCmd.Flags()
+	flags.BoolVar(&aes, "aes", false,
+		"generate aes key instead of age identity",
+	)
+	flags.StringVarP(&output, "output", "o", "",
+		"write key to file instead of stdout",
+	)
+
+	return keygenCmd
+}
+
+func generateAESKey() ([]byte, error) {
+	key, err := secret.GenerateAESKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(key + "\n"), nil
+}
+
+// generateAgeIdentity generates age identity in the same format as
+// `age-keygen`, the public key is also written to stderr
+func generateAgeIdentity(stderr io.Writer) ([]byte, error) {
+	identity, recipient, err := secret.GenerateAgeIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = fmt.Fprintf(stderr, "Public key: %s\n", recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte("# public key: " + recipient + "\n" + identity + "\n"), nil
+}
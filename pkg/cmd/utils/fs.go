@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"os"
+
+	"arhat.dev/pkg/fshelper"
+)
+
+// CwdFS returns a filesystem rooted at the working dir of the dukkha process
+//
+// relative paths in cli args and flags are resolved against it rather than
+// DUKKHA_WORKDIR, which is the project root when config was discovered from
+// a sub directory
+func CwdFS() *fshelper.OSFS {
+	return fshelper.NewOSFS(false, os.Getwd)
+}
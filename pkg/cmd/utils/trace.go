@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"io"
+
+	"github.com/spf13/pflag"
+
+	di "arhat.dev/dukkha/internal"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/output"
+)
+
+const TraceRenderingFlagName = "trace-rendering"
+
+func RegisterTraceRenderingFlag(flags *pflag.FlagSet) {
+	flags.Bool(TraceRenderingFlagName, false,
+		"write yaml path, renderers, input and output (truncated, with secrets masked), "+
+			"cache hits and misses, and duration of every rendered field to stderr",
+	)
+}
+
+// SetupRenderingTracer sets a rendering tracer to rc when flag `--trace-rendering`
+// is registered in flags and set
+//
+// MUST be called before loading config to trace config fields by yaml path
+func SetupRenderingTracer(flags *pflag.FlagSet, rc dukkha.RenderingContext) {
+	enabled, err := flags.GetBool(TraceRenderingFlagName)
+	if err != nil || !enabled {
+		return
+	}
+
+	rc.(di.RenderingTracerSetter).SetRenderingTracer(output.NewRenderingTracer())
+}
+
+// WriteRenderingTrace writes rendering trace recorded in rc to w, does nothing
+// if rendering is not traced
+func WriteRenderingTrace(rc dukkha.RenderingContext, w io.Writer) error {
+	tracer := output.GetRenderingTracer(rc)
+	if tracer == nil {
+		return nil
+	}
+
+	return tracer.Write(w)
+}
@@ -1,4 +1,5 @@
-package archive
+// Package compression provides compression codecs by method name
+package compression
 
 import (
 	"compress/flate"
@@ -15,24 +16,26 @@ import (
 	"arhat.dev/dukkha/pkg/constant"
 )
 
-func createCompressionStream(w io.Writer, method, level string) (io.WriteCloser, error) {
+// NewWriter creates a compression stream writing to w, level is the
+// method specific compression level, empty level means the default level
+func NewWriter(w io.Writer, method, level string) (io.WriteCloser, error) {
 	switch method {
 	case constant.CompressionMethod_DEFLATE:
-		l, err := parseFlateCompressionLevel(level)
+		l, err := ParseFlateLevel(level)
 		if err != nil {
 			return nil, err
 		}
 
 		return flate.NewWriter(w, l)
 	case constant.CompressionMethod_Gzip:
-		l, err := parseGzipCompressionLevel(level)
+		l, err := ParseGzipLevel(level)
 		if err != nil {
 			return nil, err
 		}
 
 		return gzip.NewWriterLevel(w, l)
 	case constant.CompressionMethod_Bzip2:
-		l, err := parseBzip2CompresssionLevel(level)
+		l, err := ParseBzip2Level(level)
 		if err != nil {
 			return nil, err
 		}
@@ -45,7 +48,7 @@ func createCompressionStream(w io.Writer, method, level string) (io.WriteCloser,
 	case constant.CompressionMethod_LZMA:
 		return lzma.WriterConfig{}.NewWriter(w)
 	case constant.CompressionMethod_ZSTD:
-		l, err := parseZstdCompressionLevel(level)
+		l, err := ParseZstdLevel(level)
 		if err != nil {
 			return nil, err
 		}
@@ -56,7 +59,43 @@ func createCompressionStream(w io.Writer, method, level string) (io.WriteCloser,
 	}
 }
 
-func parseFlateCompressionLevel(level string) (int, error) {
+// NewReader creates a decompression stream reading from r
+func NewReader(r io.Reader, method string) (io.ReadCloser, error) {
+	switch method {
+	case constant.CompressionMethod_DEFLATE:
+		return flate.NewReader(r), nil
+	case constant.CompressionMethod_Gzip:
+		return gzip.NewReader(r)
+	case constant.CompressionMethod_Bzip2:
+		return bzip2.NewReader(r, nil)
+	case constant.CompressionMethod_XZ:
+		xr, err := xz.ReaderConfig{}.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+
+		return io.NopCloser(xr), nil
+	case constant.CompressionMethod_LZMA:
+		lr, err := lzma.ReaderConfig{}.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+
+		return io.NopCloser(lr), nil
+	case constant.CompressionMethod_ZSTD:
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+
+		return zr.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression method: %q", method)
+	}
+}
+
+// ParseFlateLevel parses compression level for deflate
+func ParseFlateLevel(level string) (int, error) {
 	if len(level) == 0 {
 		return flate.DefaultCompression, nil
 	}
@@ -69,7 +108,8 @@ func parseFlateCompressionLevel(level string) (int, error) {
 	return int(l), nil
 }
 
-func parseGzipCompressionLevel(level string) (int, error) {
+// ParseGzipLevel parses compression level for gzip
+func ParseGzipLevel(level string) (int, error) {
 	if len(level) == 0 {
 		return gzip.DefaultCompression, nil
 	}
@@ -82,7 +122,8 @@ func parseGzipCompressionLevel(level string) (int, error) {
 	return int(l), nil
 }
 
-func parseZstdCompressionLevel(level string) (zstd.EncoderLevel, error) {
+// ParseZstdLevel maps numeric compression level to zstd encoder level
+func ParseZstdLevel(level string) (zstd.EncoderLevel, error) {
 	if len(level) == 0 {
 		return zstd.SpeedDefault, nil
 	}
@@ -106,7 +147,8 @@ func parseZstdCompressionLevel(level string) (zstd.EncoderLevel, error) {
 	}
 }
 
-func parseBzip2CompresssionLevel(level string) (int, error) {
+// ParseBzip2Level parses compression level for bzip2
+func ParseBzip2Level(level string) (int, error) {
 	if len(level) == 0 {
 		return bzip2.DefaultCompression, nil
 	}
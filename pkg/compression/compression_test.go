@@ -0,0 +1,53 @@
+package compression
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"arhat.dev/dukkha/pkg/constant"
+)
+
+func TestNewWriter_NewReader(t *testing.T) {
+	data := bytes.Repeat([]byte("dukkha compression test data\n"), 100)
+
+	for _, method := range []string{
+		constant.CompressionMethod_DEFLATE,
+		constant.CompressionMethod_Gzip,
+		constant.CompressionMethod_Bzip2,
+		constant.CompressionMethod_XZ,
+		constant.CompressionMethod_LZMA,
+		constant.CompressionMethod_ZSTD,
+	} {
+		t.Run(method, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := NewWriter(buf, method, "")
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			_, err = w.Write(data)
+			assert.NoError(t, err)
+			assert.NoError(t, w.Close())
+			assert.Less(t, buf.Len(), len(data))
+
+			r, err := NewReader(buf, method)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer func() { _ = r.Close() }()
+
+			actual, err := io.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, data, actual)
+		})
+	}
+
+	_, err := NewWriter(io.Discard, "unknown", "")
+	assert.Error(t, err)
+
+	_, err = NewReader(&bytes.Buffer{}, "unknown")
+	assert.Error(t, err)
+}
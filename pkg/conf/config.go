@@ -22,11 +22,13 @@ import (
 	"arhat.dev/pkg/log"
 	"arhat.dev/pkg/rshelper"
 	"arhat.dev/rs"
+	"gopkg.in/yaml.v3"
 
 	di "arhat.dev/dukkha/internal"
 	"arhat.dev/dukkha/pkg/constant"
 	"arhat.dev/dukkha/pkg/dukkha"
 	"arhat.dev/dukkha/pkg/tools"
+	"arhat.dev/dukkha/pkg/tools/custom"
 )
 
 func NewConfig() *Config {
@@ -38,6 +40,13 @@ func NewConfig() *Config {
 type Config struct {
 	rs.BaseField `yaml:"-"`
 
+	// Root marks the directory containing this config file as the project root
+	// config discovery stops walking up parent directories once found
+	//
+	// only effective in config files discovered automatically
+	// (e.g. `.dukkha.yaml`), and rendering suffix is not supported
+	Root bool `yaml:"root"`
+
 	// Global options only have limited rendering suffix support
 	Global GlobalConfig `yaml:"global"`
 
@@ -53,10 +62,39 @@ type Config struct {
 	// Renderers config options
 	Renderers []*RendererGroup `yaml:"renderers"`
 
+	// Templates are libraries of named templates shared by all templates
+	//
+	// libraries are resolved right after renderers and shells, so templates
+	// defined in them are available to config loaded later
+	Templates []*TemplateLibrary `yaml:"templates"`
+
+	// CustomTools declares custom tool kinds and their task kinds
+	//
+	// custom tools are resolved and registered before other sections,
+	// and MUST be declared before being used (in the same file or
+	// in config loaded earlier)
+	CustomTools []*custom.ToolSpec `yaml:"custom_tools"`
+
 	// Tools config options for registered tools
 	Tools Tools `yaml:"tools"`
 
 	Tasks map[string][]dukkha.Task `yaml:",inline"`
+
+	// rawTasks for tasks inheritance
+	rawTasks taskDefs
+
+	// rawValues are raw global values, pre-rendered before resolving
+	rawValues []*yaml.Node
+
+	// rawTools are raw yaml of tools, used to override tools in profiles
+	//
+	// tool kind -> tool name -> raw tool
+	rawTools map[string]map[string]*yaml.Node
+
+	// profiles are raw overlays in `profiles` section, applied by ApplyProfiles
+	//
+	// profile name -> overlays in the order they are defined
+	profiles map[string][]*yaml.Node
 }
 
 func (c *Config) Merge(a *Config) error {
@@ -70,11 +108,26 @@ func (c *Config) Merge(a *Config) error {
 		return err
 	}
 
+	c.CustomTools = append(c.CustomTools, a.CustomTools...)
+
 	err = c.Tools.Merge(&a.Tools)
 	if err != nil {
 		return err
 	}
 
+	c.rawTasks.merge(&a.rawTasks)
+	c.rawValues = append(c.rawValues, a.rawValues...)
+
+	c.addRawTools(a.rawTools)
+
+	for name, overlays := range a.profiles {
+		if c.profiles == nil {
+			c.profiles = make(map[string][]*yaml.Node)
+		}
+
+		c.profiles[name] = append(c.profiles[name], overlays...)
+	}
+
 	if len(a.Tasks) != 0 {
 		if c.Tasks == nil {
 			c.Tasks = make(map[string][]dukkha.Task)
@@ -209,7 +262,10 @@ func (c *Config) Resolve(appCtx dukkha.ConfigResolvingContext, needTasks bool) e
 	{
 		logger.D("resolving global values")
 
+		// global values do not depend on each other
+		done := dukkha.PreRender(appCtx, c.rawValues...)
 		err := c.Global.ResolveFields(appCtx, -1, "values")
+		done()
 		if err != nil {
 			return fmt.Errorf("resolving global values: %w", err)
 		}
@@ -234,8 +290,21 @@ func (c *Config) Resolve(appCtx dukkha.ConfigResolvingContext, needTasks bool) e
 	}
 
 	// step 3: resolve tools and tasks
+	logger.D("resolving extending tasks", log.Int("count", len(c.rawTasks.extending)))
+	extended, err := c.rawTasks.resolve()
+	if err != nil {
+		return fmt.Errorf("resolve extending tasks: %w", err)
+	}
+
+	// extending tasks are resolved only once
+	c.rawTasks.extending = nil
+	err = c.Merge(extended)
+	if err != nil {
+		return fmt.Errorf("merge extending tasks: %w", err)
+	}
+
 	logger.D("resolving tools overview")
-	err := c.ResolveFields(appCtx, 2, "tools")
+	err = c.ResolveFields(appCtx, 2, "tools")
 	if err != nil {
 		return fmt.Errorf("gain overview of tools: %w", err)
 	}
@@ -0,0 +1,57 @@
+package conf
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/tools/custom"
+)
+
+const customToolsKey = "custom_tools"
+
+// resolveCustomTools resolves and registers custom tools declared in doc,
+// the `custom_tools` section is removed from doc, so tools and tasks of custom
+// kinds in the same doc can be unmarshaled
+func resolveCustomTools(
+	rc dukkha.ConfigResolvingContext,
+	doc *yaml.Node,
+) ([]*custom.ToolSpec, error) {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	top := doc.Content[0]
+	if top.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	idx := findKey(top, customToolsKey)
+	if idx < 0 {
+		return nil, nil
+	}
+
+	section := NewConfig()
+	err := (&yaml.Node{
+		Kind:    yaml.MappingNode,
+		Content: []*yaml.Node{top.Content[idx], top.Content[idx+1]},
+	}).Decode(section)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal custom tools: %w", err)
+	}
+
+	top.Content = append(top.Content[:idx], top.Content[idx+2:]...)
+
+	err = section.ResolveFields(rc, -1, customToolsKey)
+	if err != nil {
+		return nil, fmt.Errorf("resolving custom tools: %w", err)
+	}
+
+	err = custom.Register(section.CustomTools)
+	if err != nil {
+		return nil, fmt.Errorf("registering custom tools: %w", err)
+	}
+
+	return section.CustomTools, nil
+}
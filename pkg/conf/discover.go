@@ -0,0 +1,180 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigExtensions are extensions of config files parsed by default,
+// other extensions (e.g. `.yml`, `.json`) are opt-in
+var DefaultConfigExtensions = []string{".yaml"}
+
+// DefaultConfigDir is the directory name looked up after config files during
+// config discovery
+const DefaultConfigDir = "dukkha.d"
+
+// configPathsToLookup returns `.dukkha<ext>` for each of exts followed by
+// DefaultConfigDir, all of them present in the project root are loaded in
+// this order
+func configPathsToLookup(exts []string) []string {
+	if len(exts) == 0 {
+		exts = DefaultConfigExtensions
+	}
+
+	ret := make([]string, 0, len(exts)+1)
+	for _, ext := range exts {
+		ret = append(ret, ".dukkha"+ext)
+	}
+
+	return append(ret, DefaultConfigDir)
+}
+
+// vcsRootMarkers are directory entries marking the root of a vcs repo
+var vcsRootMarkers = []string{
+	".git",
+	".hg",
+	".svn",
+}
+
+// Discover finds dukkha config by walking from startDir up to the project root
+//
+// the project root is the first directory containing a vcs root marker (e.g. `.git`)
+// or a config file with top level `root: true`, config in the project root is preferred,
+// if there is none, config in the nearest directory below the project root is used
+//
+// exts are extensions of config files to look up, defaults to DefaultConfigExtensions
+//
+// the returned configPaths are relative to workDir, when no config was found,
+// workDir is startDir and configPaths is empty
+func Discover(startDir string, exts []string) (workDir string, configPaths []string, err error) {
+	startDir, err = filepath.Abs(startDir)
+	if err != nil {
+		return "", nil, fmt.Errorf("get absolute path of start dir: %w", err)
+	}
+
+	var (
+		lookupPaths = configPathsToLookup(exts)
+
+		nearestDir   string
+		nearestPaths []string
+	)
+
+	for dir := startDir; ; {
+		found, isRoot, err := lookupConfigPaths(dir, lookupPaths)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if len(found) != 0 && len(nearestPaths) == 0 {
+			nearestDir, nearestPaths = dir, found
+		}
+
+		if !isRoot {
+			isRoot, err = hasVCSRootMarker(dir)
+			if err != nil {
+				return "", nil, err
+			}
+		}
+
+		if isRoot {
+			if len(found) != 0 {
+				return dir, found, nil
+			}
+
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+
+		dir = parent
+	}
+
+	if len(nearestPaths) != 0 {
+		return nearestDir, nearestPaths, nil
+	}
+
+	return startDir, nil, nil
+}
+
+// lookupConfigPaths checks existence of names in dir and reports whether any
+// of the config files found declares `root: true`
+func lookupConfigPaths(dir string, names []string) (found []string, isRoot bool, err error) {
+	for _, name := range names {
+		file := filepath.Join(dir, name)
+		info, err := os.Stat(file)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+
+			return nil, false, err
+		}
+
+		found = append(found, name)
+		if info.IsDir() || isRoot {
+			continue
+		}
+
+		isRoot, err = isRootConfig(file)
+		if err != nil {
+			return nil, false, fmt.Errorf("check root marker in %q: %w", file, err)
+		}
+	}
+
+	return
+}
+
+func hasVCSRootMarker(dir string) (bool, error) {
+	for _, name := range vcsRootMarkers {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if err == nil {
+			return true, nil
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return false, err
+		}
+	}
+
+	return false, nil
+}
+
+// isRootConfig checks whether any yaml doc in file has `root: true`
+//
+// only the plain `root` key is checked, rendering suffix is not supported
+func isRootConfig(file string) (bool, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = f.Close() }()
+
+	dec := yaml.NewDecoder(f)
+	for {
+		var marker struct {
+			Root bool `yaml:"root"`
+		}
+
+		err = dec.Decode(&marker)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return false, nil
+			}
+
+			return false, err
+		}
+
+		if marker.Root {
+			return true, nil
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscover(t *testing.T) {
+	tests := []struct {
+		name string
+
+		files    map[string]string
+		startDir string
+		exts     []string
+
+		expectedWorkDir     string
+		expectedConfigPaths []string
+	}{
+		{
+			name:            "None",
+			files:           map[string]string{"a/b/.keep": ""},
+			startDir:        "a/b",
+			expectedWorkDir: "a/b",
+		},
+		{
+			name: "VCS Root",
+			files: map[string]string{
+				".git/HEAD":         "",
+				".dukkha.yaml":      "",
+				"pkg/a/.dukkha.yml": "",
+			},
+			startDir:            "pkg/a",
+			expectedWorkDir:     ".",
+			expectedConfigPaths: []string{".dukkha.yaml"},
+		},
+		{
+			name: "VCS Root Without Config",
+			files: map[string]string{
+				".git/HEAD":          "",
+				"pkg/a/.dukkha.json": "{}",
+			},
+			startDir:            "pkg/a/b",
+			exts:                []string{".json"},
+			expectedWorkDir:     "pkg/a",
+			expectedConfigPaths: []string{".dukkha.json"},
+		},
+		{
+			name: "Root Marker",
+			files: map[string]string{
+				".git/HEAD":           "",
+				".dukkha.yaml":        "",
+				"pkg/.dukkha.yaml":    "---\nroot: true\n",
+				"pkg/dukkha.d/a.yaml": "",
+			},
+			startDir:            "pkg/a",
+			expectedWorkDir:     "pkg",
+			expectedConfigPaths: []string{".dukkha.yaml", "dukkha.d"},
+		},
+		{
+			name: "Default Extensions",
+			files: map[string]string{
+				".hg/store":       "",
+				".dukkha.yml":     "",
+				".dukkha.json":    "{}",
+				"dukkha.d/a.yaml": "",
+			},
+			startDir:            ".",
+			expectedWorkDir:     ".",
+			expectedConfigPaths: []string{"dukkha.d"},
+		},
+		{
+			name: "All Kinds",
+			files: map[string]string{
+				".hg/store":       "",
+				".dukkha.yaml":    "",
+				".dukkha.yml":     "",
+				".dukkha.json":    "{}",
+				"dukkha.d/a.json": "{}",
+			},
+			startDir:            ".",
+			exts:                []string{".yaml", ".yml", ".json"},
+			expectedWorkDir:     ".",
+			expectedConfigPaths: []string{".dukkha.yaml", ".dukkha.yml", ".dukkha.json", "dukkha.d"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmpdir := filepath.Join(t.TempDir(), "project")
+			for name, data := range test.files {
+				file := filepath.Join(tmpdir, name)
+				if !assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0755)) {
+					return
+				}
+
+				if !assert.NoError(t, os.WriteFile(file, []byte(data), 0644)) {
+					return
+				}
+			}
+
+			startDir := filepath.Join(tmpdir, test.startDir)
+			if !assert.NoError(t, os.MkdirAll(startDir, 0755)) {
+				return
+			}
+
+			workDir, configPaths, err := Discover(startDir, test.exts)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, filepath.Join(tmpdir, test.expectedWorkDir), workDir)
+			assert.EqualValues(t, test.expectedConfigPaths, configPaths)
+		})
+	}
+}
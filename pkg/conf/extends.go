@@ -0,0 +1,375 @@
+package conf
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+const (
+	// taskFieldExtends is the task field referencing the base task to inherit from
+	taskFieldExtends = "extends"
+
+	// appendKeySuffix is the key suffix to append list items to the base task's list
+	// instead of replacing it (e.g. `env+: []`)
+	//
+	// it is dukkha syntax only recognized when merging raw tasks, not part of rs
+	appendKeySuffix = "+"
+)
+
+// rawTask is a task definition kept as raw yaml for task inheritance
+type rawTask struct {
+	// key is the top level key in config, e.g. `golang:build`
+	key  string
+	name string
+
+	node *yaml.Node
+
+	// base is the task overridden by this task (e.g. by profile),
+	// takes precedence over `extends`
+	base *rawTask
+}
+
+// taskDefs collects raw yaml of tasks for task inheritance
+type taskDefs struct {
+	// defs of all tasks defined with top level key and task name
+	// not using rendering suffix
+	//
+	// key -> task name -> raw task
+	defs map[string]map[string]*rawTask
+
+	// extending are tasks with `extends` set, in the order they are defined,
+	// they are unmarshaled after all config loaded, so they can inherit
+	// tasks defined in included config
+	extending []*rawTask
+}
+
+func (d *taskDefs) merge(a *taskDefs) {
+	for k, tasks := range a.defs {
+		for name, t := range tasks {
+			d.add(k, name, t)
+		}
+	}
+
+	d.extending = append(d.extending, a.extending...)
+}
+
+// add task definition, when there are tasks with the same key and name,
+// last appeared will be effective
+func (d *taskDefs) add(key, name string, t *rawTask) {
+	if d.defs == nil {
+		d.defs = make(map[string]map[string]*rawTask)
+	}
+
+	m, ok := d.defs[key]
+	if !ok {
+		m = make(map[string]*rawTask)
+		d.defs[key] = m
+	}
+
+	m[name] = t
+}
+
+// collect task definitions in doc, tasks with `extends` set are removed from doc
+func (d *taskDefs) collect(doc *yaml.Node) error {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil
+	}
+
+	top := doc.Content[0]
+	if top.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var content []*yaml.Node
+	for i := 0; i+1 < len(top.Content); i += 2 {
+		k, v := top.Content[i], top.Content[i+1]
+
+		// only tasks without rendering suffix in key are supported
+		if !strings.Contains(k.Value, ":") ||
+			strings.Contains(k.Value, "@") ||
+			v.Kind != yaml.SequenceNode {
+			content = append(content, k, v)
+			continue
+		}
+
+		var items []*yaml.Node
+		for _, item := range v.Content {
+			t := &rawTask{
+				key:  k.Value,
+				name: lookupScalar(item, "name"),
+				node: item,
+			}
+
+			if len(t.name) != 0 {
+				d.add(t.key, t.name, t)
+			}
+
+			// base task is resolved before rendering, so it can only be
+			// referenced with plain value
+			if item.Kind == yaml.MappingNode {
+				if idx := findKey(item, taskFieldExtends); idx >= 0 &&
+					item.Content[idx].Value != taskFieldExtends {
+					return fmt.Errorf(
+						"task %s(%s): rendering suffix is not supported by %q, got %q",
+						t.key, t.name, taskFieldExtends, item.Content[idx].Value,
+					)
+				}
+			}
+
+			if len(lookupScalar(item, taskFieldExtends)) == 0 {
+				items = append(items, item)
+				continue
+			}
+
+			d.extending = append(d.extending, t)
+		}
+
+		if len(items) == 0 {
+			continue
+		}
+
+		v.Content = items
+		content = append(content, k, v)
+	}
+
+	top.Content = content
+	return nil
+}
+
+// resolve all extending tasks as a config containing only tasks
+func (d *taskDefs) resolve() (*Config, error) {
+	ret := NewConfig()
+	if len(d.extending) == 0 {
+		return ret, nil
+	}
+
+	top := &yaml.Node{Kind: yaml.MappingNode}
+	tasksByKey := make(map[string]*yaml.Node)
+	for _, t := range d.extending {
+		merged, err := d.mergeBase(t, make(map[*rawTask]struct{}))
+		if err != nil {
+			return nil, fmt.Errorf("extending task %s(%s): %w", t.key, t.name, err)
+		}
+
+		seq, ok := tasksByKey[t.key]
+		if !ok {
+			seq = &yaml.Node{Kind: yaml.SequenceNode}
+			tasksByKey[t.key] = seq
+			top.Content = append(top.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: t.key}, seq,
+			)
+		}
+
+		seq.Content = append(seq.Content, merged)
+	}
+
+	err := top.Decode(ret)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal extended tasks: %w", err)
+	}
+
+	return ret, nil
+}
+
+// mergeBase returns raw yaml of t with all its base tasks merged
+func (d *taskDefs) mergeBase(t *rawTask, visited map[*rawTask]struct{}) (*yaml.Node, error) {
+	if _, ok := visited[t]; ok {
+		return nil, fmt.Errorf("circular inheritance")
+	}
+	visited[t] = struct{}{}
+
+	if t.base != nil {
+		baseNode, err := d.mergeBase(t.base, visited)
+		if err != nil {
+			return nil, err
+		}
+
+		return mergeTaskNode(baseNode, t.node)
+	}
+
+	baseRef := lookupScalar(t.node, taskFieldExtends)
+	if len(baseRef) == 0 {
+		return t.node, nil
+	}
+
+	ref, err := dukkha.ParseTaskReference(baseRef, "")
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s reference %q: %w", taskFieldExtends, baseRef, err)
+	}
+
+	key := string(ref.ToolKind)
+	if len(ref.ToolName) != 0 {
+		key += ":" + string(ref.ToolName)
+	}
+	key += ":" + string(ref.TaskKind)
+
+	base, ok := d.defs[key][string(ref.TaskName)]
+	if !ok {
+		return nil, fmt.Errorf("base task %s(%s) not found", key, ref.TaskName)
+	}
+
+	baseNode, err := d.mergeBase(base, visited)
+	if err != nil {
+		return nil, fmt.Errorf("extending base task %s(%s): %w", key, ref.TaskName, err)
+	}
+
+	return mergeTaskNode(baseNode, t.node)
+}
+
+// mergeTaskNode deep merges override into base and returns a new node,
+// neither base nor override is modified
+//
+// rs.BaseField.Inherit is not used since it only replaces fields with rendering
+// suffix, plain fields are already unmarshaled and can not be merged by key
+//
+// rules:
+// 	- maps are merged recursively
+// 	- lists are replaced, unless key in override has suffix `+`, then items are appended
+// 	- scalars are replaced
+// 	- values with rendering suffix are replaced as a whole, since they are not resolved yet
+func mergeTaskNode(base, override *yaml.Node) (*yaml.Node, error) {
+	ret, err := mergeMap(base, override)
+	if err != nil {
+		return nil, err
+	}
+
+	// extends is not a task field
+	deleteKey(ret, taskFieldExtends)
+	return ret, nil
+}
+
+func mergeMap(base, override *yaml.Node) (*yaml.Node, error) {
+	base, override = resolveAlias(base), resolveAlias(override)
+	if base.Kind != yaml.MappingNode || override.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("unexpected non map value")
+	}
+
+	ret := copyNode(base)
+	for i := 0; i+1 < len(override.Content); i += 2 {
+		k, v := override.Content[i], override.Content[i+1]
+
+		key := k.Value
+		doAppend := strings.HasSuffix(key, appendKeySuffix)
+		if doAppend {
+			key = strings.TrimSuffix(key, appendKeySuffix)
+		}
+
+		idx := findKey(ret, fieldName(key))
+		if idx < 0 {
+			ret.Content = append(ret.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: k.Tag, Value: key, Style: k.Style},
+				copyNode(v),
+			)
+
+			continue
+		}
+
+		baseKey, baseValue := ret.Content[idx], resolveAlias(ret.Content[idx+1])
+		v = resolveAlias(v)
+
+		switch {
+		case baseKey.Value != key:
+			// rendering suffix is different, replace
+			ret.Content[idx] = &yaml.Node{Kind: yaml.ScalarNode, Tag: k.Tag, Value: key, Style: k.Style}
+			ret.Content[idx+1] = copyNode(v)
+		case doAppend:
+			if baseValue.Kind != yaml.SequenceNode || v.Kind != yaml.SequenceNode {
+				return nil, fmt.Errorf("invalid non list value for %q", k.Value)
+			}
+
+			seq := copyNode(baseValue)
+			for _, item := range v.Content {
+				seq.Content = append(seq.Content, copyNode(item))
+			}
+
+			ret.Content[idx+1] = seq
+		case strings.Contains(key, "@"),
+			baseValue.Kind != yaml.MappingNode || v.Kind != yaml.MappingNode:
+			ret.Content[idx+1] = copyNode(v)
+		default:
+			merged, err := mergeMap(baseValue, v)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%w", key, err)
+			}
+
+			ret.Content[idx+1] = merged
+		}
+	}
+
+	return ret, nil
+}
+
+// fieldName returns yaml key without rendering suffix
+func fieldName(key string) string {
+	if idx := strings.LastIndexByte(key, '@'); idx >= 0 {
+		return key[:idx]
+	}
+
+	return key
+}
+
+// findKey finds index of the key in map node by field name, returns -1 if not found
+func findKey(m *yaml.Node, name string) int {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		k := m.Content[i].Value
+		if k == name || fieldName(k) == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func deleteKey(m *yaml.Node, key string) {
+	idx := findKey(m, key)
+	if idx < 0 {
+		return
+	}
+
+	m.Content = append(m.Content[:idx], m.Content[idx+2:]...)
+}
+
+// lookupScalar returns value of a plain scalar field in map node
+func lookupScalar(m *yaml.Node, key string) string {
+	if m.Kind != yaml.MappingNode {
+		return ""
+	}
+
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value != key {
+			continue
+		}
+
+		if v := resolveAlias(m.Content[i+1]); v.Kind == yaml.ScalarNode {
+			return v.Value
+		}
+
+		return ""
+	}
+
+	return ""
+}
+
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+
+	return n
+}
+
+func copyNode(n *yaml.Node) *yaml.Node {
+	n = resolveAlias(n)
+
+	ret := *n
+	ret.Content = make([]*yaml.Node, len(n.Content))
+	for i, c := range n.Content {
+		ret.Content[i] = copyNode(c)
+	}
+
+	return &ret
+}
@@ -0,0 +1,171 @@
+package conf
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	di "arhat.dev/dukkha/internal"
+	dukkha_test "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/tools/golang"
+)
+
+func TestMergeTaskNode(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		override string
+		expected string
+		err      bool
+	}{
+		{
+			name:     "Scalar Replace",
+			base:     `{name: base, path: ./cmd/a, chdir: foo}`,
+			override: `{name: child, extends: "golang:build(base)", path: ./cmd/b}`,
+			expected: `{name: child, path: ./cmd/b, chdir: foo}`,
+		},
+		{
+			name:     "Map Merge",
+			base:     `{cgo: {enabled: true, cflags: [-O2]}}`,
+			override: `{cgo: {cc: clang}}`,
+			expected: `{cgo: {enabled: true, cflags: [-O2], cc: clang}}`,
+		},
+		{
+			name:     "List Replace",
+			base:     `{extra_args: [a, b]}`,
+			override: `{extra_args: [c]}`,
+			expected: `{extra_args: [c]}`,
+		},
+		{
+			name:     "List Append",
+			base:     `{hooks: {before: [{shell: a}]}}`,
+			override: `{hooks: {before+: [{shell: b}]}}`,
+			expected: `{hooks: {before: [{shell: a}, {shell: b}]}}`,
+		},
+		{
+			name:     "Rendering Suffix Replace",
+			base:     `{matrix: {arch: [amd64]}}`,
+			override: `{matrix@file: matrix.yaml}`,
+			expected: `{matrix@file: matrix.yaml}`,
+		},
+		{
+			name:     "Append Non List",
+			base:     `{path: foo}`,
+			override: `{path+: [bar]}`,
+			err:      true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var base, override, expected yaml.Node
+			assert.NoError(t, yaml.Unmarshal([]byte(test.base), &base))
+			assert.NoError(t, yaml.Unmarshal([]byte(test.override), &override))
+
+			actual, err := mergeTaskNode(base.Content[0], override.Content[0])
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.NoError(t, yaml.Unmarshal([]byte(test.expected), &expected))
+
+			actualBytes, err := yaml.Marshal(actual)
+			assert.NoError(t, err)
+			expectedBytes, err := yaml.Marshal(expected.Content[0])
+			assert.NoError(t, err)
+
+			assert.Equal(t, string(expectedBytes), string(actualBytes))
+		})
+	}
+}
+
+func TestReadExtends(t *testing.T) {
+	testFS := fstest.MapFS{
+		".dukkha.yaml": &fstest.MapFile{
+			Data: []byte(`
+include:
+- path: base.yaml
+
+golang:build:
+- name: foo
+  extends: golang:build(bar)
+  path: ./cmd/foo
+`),
+		},
+		"base.yaml": &fstest.MapFile{
+			Data: []byte(`
+golang:build:
+- name: base
+  chdir: src
+  path: ./cmd/base
+  extra_args: [-v]
+- name: bar
+  extends: golang:build(base)
+  extra_args+: [-x]
+`),
+		},
+	}
+
+	rc := dukkha_test.NewTestContext(context.TODO())
+	rc.(di.CacheDirSetter).SetCacheDir(t.TempDir())
+
+	config := NewConfig()
+	visitedPaths := make(map[string]struct{})
+	if !assert.NoError(t, Read(rc, testFS, []string{".dukkha.yaml"}, nil, false, &visitedPaths, config)) {
+		return
+	}
+
+	if !assert.NoError(t, config.Resolve(rc, true)) {
+		return
+	}
+
+	tasks := make(map[string]*golang.TaskBuild)
+	for _, tsk := range config.Tasks["golang:build"] {
+		assert.NoError(t, tsk.ResolveFields(rc, -1))
+		tasks[string(tsk.Name())] = tsk.(*golang.TaskBuild)
+	}
+
+	if !assert.Len(t, tasks, 3) {
+		return
+	}
+
+	assert.Equal(t, "src", tasks["foo"].Chdir)
+	assert.Equal(t, "./cmd/foo", tasks["foo"].Path)
+	assert.EqualValues(t, []string{"-v", "-x"}, tasks["foo"].ExtraArgs)
+
+	assert.Equal(t, "./cmd/base", tasks["bar"].Path)
+	assert.EqualValues(t, []string{"-v", "-x"}, tasks["bar"].ExtraArgs)
+}
+
+func TestReadExtends_RenderingSuffix(t *testing.T) {
+	testFS := fstest.MapFS{
+		".dukkha.yaml": &fstest.MapFile{
+			Data: []byte(`
+golang:build:
+- name: base
+  path: ./cmd/base
+- name: foo
+  extends@tpl: golang:build({{ "base" }})
+`),
+		},
+	}
+
+	rc := dukkha_test.NewTestContext(context.TODO())
+	rc.(di.CacheDirSetter).SetCacheDir(t.TempDir())
+
+	config := NewConfig()
+	visitedPaths := make(map[string]struct{})
+	err := Read(rc, testFS, []string{".dukkha.yaml"}, nil, false, &visitedPaths, config)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "golang:build(foo)")
+		assert.Contains(t, err.Error(), `"extends@tpl"`)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"arhat.dev/rs"
+	"gopkg.in/yaml.v3"
 
 	"arhat.dev/dukkha/pkg/dukkha"
 )
@@ -85,3 +86,41 @@ func (g *GlobalConfig) ResolveAllButValues(rc dukkha.ConfigResolvingContext) err
 
 	return nil
 }
+
+// collectRawValues returns raw global values in doc for pre-rendering,
+// a values field using rendering suffix is returned as a single field map
+func collectRawValues(doc *yaml.Node) []*yaml.Node {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil
+	}
+
+	top := resolveAlias(doc.Content[0])
+	if top.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	idx := findKey(top, "global")
+	if idx < 0 || top.Content[idx].Value != "global" {
+		// global config rendered as a whole
+		return nil
+	}
+
+	global := resolveAlias(top.Content[idx+1])
+	if global.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	idx = findKey(global, "values")
+	if idx < 0 {
+		return nil
+	}
+
+	if global.Content[idx].Value != "values" {
+		return []*yaml.Node{{
+			Kind:    yaml.MappingNode,
+			Content: global.Content[idx : idx+2],
+		}}
+	}
+
+	return []*yaml.Node{resolveAlias(global.Content[idx+1])}
+}
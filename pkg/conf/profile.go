@@ -0,0 +1,317 @@
+package conf
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const profilesKey = "profiles"
+
+// collectProfiles removes the `profiles` section from doc and returns raw
+// profile overlays in it
+func collectProfiles(doc *yaml.Node) (map[string][]*yaml.Node, error) {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	top := doc.Content[0]
+	if top.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	idx := findKey(top, profilesKey)
+	if idx < 0 {
+		return nil, nil
+	}
+
+	if top.Content[idx].Value != profilesKey {
+		return nil, fmt.Errorf("rendering suffix is not supported by %q", profilesKey)
+	}
+
+	section := resolveAlias(top.Content[idx+1])
+	top.Content = append(top.Content[:idx], top.Content[idx+2:]...)
+
+	switch section.Kind {
+	case yaml.MappingNode:
+	case yaml.ScalarNode:
+		if section.ShortTag() == "!!null" {
+			return nil, nil
+		}
+
+		fallthrough
+	default:
+		return nil, fmt.Errorf("invalid non map %q", profilesKey)
+	}
+
+	ret := make(map[string][]*yaml.Node)
+	for i := 0; i+1 < len(section.Content); i += 2 {
+		name, overlay := section.Content[i].Value, resolveAlias(section.Content[i+1])
+		if overlay.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("invalid non map profile %q", name)
+		}
+
+		ret[name] = append(ret[name], overlay)
+	}
+
+	return ret, nil
+}
+
+// ApplyProfiles merges overlays of profiles into config in order
+//
+// MUST be called after all config loaded and before Resolve
+//
+// in each profile overlay:
+// 	- `global` is merged as an included config
+// 	- tools with the same kind and name are deep merged with the overlay
+// 	  (same as tasks), other tools are added
+// 	- tasks with the same key and name are deep merged with the overlay
+// 	  (same as `extends`), other tasks are added
+func (c *Config) ApplyProfiles(names []string) error {
+	for _, name := range names {
+		overlays, ok := c.profiles[name]
+		if !ok {
+			return fmt.Errorf("profile %q not found", name)
+		}
+
+		for _, overlay := range overlays {
+			err := c.applyProfile(overlay)
+			if err != nil {
+				return fmt.Errorf("applying profile %q: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) applyProfile(overlay *yaml.Node) error {
+	global := &yaml.Node{Kind: yaml.MappingNode}
+	for i := 0; i+1 < len(overlay.Content); i += 2 {
+		k, v := overlay.Content[i], resolveAlias(overlay.Content[i+1])
+		switch {
+		case fieldName(k.Value) == "global":
+			global.Content = append(global.Content, k, copyNode(v))
+		case k.Value == "tools":
+			err := c.overrideTools(v)
+			if err != nil {
+				return err
+			}
+		case strings.Contains(k.Value, ":") && !strings.Contains(k.Value, "@"):
+			if v.Kind != yaml.SequenceNode {
+				return fmt.Errorf("invalid non list tasks %q", k.Value)
+			}
+
+			for _, item := range v.Content {
+				t := &rawTask{
+					key:  k.Value,
+					name: lookupScalar(item, "name"),
+					node: copyNode(item),
+				}
+
+				c.overrideTask(t)
+			}
+		default:
+			return fmt.Errorf("unsupported field %q in profile", k.Value)
+		}
+	}
+
+	if len(global.Content) == 0 {
+		return nil
+	}
+
+	current := NewConfig()
+	err := global.Decode(current)
+	if err != nil {
+		return fmt.Errorf("unmarshal profile: %w", err)
+	}
+
+	return c.Merge(current)
+}
+
+// overrideTask adds t as an extending task, when there is already a task
+// with the same key and name, that task is replaced by t and used as its base
+func (c *Config) overrideTask(t *rawTask) {
+	if base, ok := c.rawTasks.defs[t.key][t.name]; ok && len(t.name) != 0 {
+		t.base = base
+
+		tasks := c.Tasks[t.key][:0]
+		for _, tsk := range c.Tasks[t.key] {
+			if string(tsk.Name()) != t.name {
+				tasks = append(tasks, tsk)
+			}
+		}
+		c.Tasks[t.key] = tasks
+
+		extending := c.rawTasks.extending[:0]
+		for _, e := range c.rawTasks.extending {
+			if e != base {
+				extending = append(extending, e)
+			}
+		}
+		c.rawTasks.extending = extending
+	}
+
+	if len(t.name) != 0 {
+		c.rawTasks.add(t.key, t.name, t)
+	}
+
+	c.rawTasks.extending = append(c.rawTasks.extending, t)
+}
+
+// overrideTools updates tools with same kind and name by deep merging
+// overlay into their raw yaml (same as tasks), tools not found are added
+func (c *Config) overrideTools(overlay *yaml.Node) error {
+	if overlay.Kind != yaml.MappingNode {
+		return fmt.Errorf("invalid non map tools")
+	}
+
+	toAdd := &yaml.Node{Kind: yaml.MappingNode}
+	for i := 0; i+1 < len(overlay.Content); i += 2 {
+		k, v := overlay.Content[i], resolveAlias(overlay.Content[i+1])
+		if v.Kind != yaml.SequenceNode {
+			return fmt.Errorf("invalid non list tools %q", k.Value)
+		}
+
+		seq := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range v.Content {
+			name := lookupScalar(item, "name")
+			idx := c.Tools.index(k.Value, name)
+			if idx < 0 {
+				seq.Content = append(seq.Content, copyNode(item))
+				continue
+			}
+
+			base, ok := c.rawTools[k.Value][name]
+			if !ok {
+				return fmt.Errorf("override tool %s(%s): tool not defined as plain yaml", k.Value, name)
+			}
+
+			merged, err := mergeMap(base, item)
+			if err != nil {
+				return fmt.Errorf("override tool %s(%s): %w", k.Value, name, err)
+			}
+
+			replaced := NewConfig()
+			err = (&yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+				k, {Kind: yaml.SequenceNode, Content: []*yaml.Node{merged}},
+			}}).Decode(&replaced.Tools)
+			if err != nil {
+				return fmt.Errorf("override tool %s(%s): %w", k.Value, name, err)
+			}
+
+			c.Tools.Tools[k.Value][idx] = replaced.Tools.Tools[k.Value][0]
+			c.rawTools[k.Value][name] = merged
+		}
+
+		if len(seq.Content) != 0 {
+			toAdd.Content = append(toAdd.Content, k, seq)
+		}
+	}
+
+	if len(toAdd.Content) == 0 {
+		return nil
+	}
+
+	added := NewConfig()
+	err := toAdd.Decode(&added.Tools)
+	if err != nil {
+		return fmt.Errorf("unmarshal tools in profile: %w", err)
+	}
+
+	c.addRawTools(rawToolsOf(toAdd))
+	return c.Tools.Merge(&added.Tools)
+}
+
+// addRawTools adds raw tools not defined yet
+func (c *Config) addRawTools(rawTools map[string]map[string]*yaml.Node) {
+	for kind, tools := range rawTools {
+		if c.rawTools == nil {
+			c.rawTools = make(map[string]map[string]*yaml.Node)
+		}
+
+		if c.rawTools[kind] == nil {
+			c.rawTools[kind] = make(map[string]*yaml.Node)
+		}
+
+		for name, raw := range tools {
+			// first defined is effective, same as tools found by name
+			if _, ok := c.rawTools[kind][name]; !ok {
+				c.rawTools[kind][name] = raw
+			}
+		}
+	}
+}
+
+// collectRawTools returns raw yaml of tools in the `tools` section of doc
+func collectRawTools(doc *yaml.Node) map[string]map[string]*yaml.Node {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil
+	}
+
+	top := resolveAlias(doc.Content[0])
+	if top.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	idx := findKey(top, "tools")
+	if idx < 0 || top.Content[idx].Value != "tools" {
+		// tools rendered as a whole
+		return nil
+	}
+
+	return rawToolsOf(top.Content[idx+1])
+}
+
+// rawToolsOf returns raw yaml of tools in tools map, tools defined with
+// rendering suffix in kind or without name are not included
+//
+// tool kind -> tool name -> raw tool
+func rawToolsOf(tools *yaml.Node) map[string]map[string]*yaml.Node {
+	tools = resolveAlias(tools)
+	if tools.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	ret := make(map[string]map[string]*yaml.Node)
+	for i := 0; i+1 < len(tools.Content); i += 2 {
+		kind, v := tools.Content[i].Value, resolveAlias(tools.Content[i+1])
+		if strings.Contains(kind, "@") || v.Kind != yaml.SequenceNode {
+			continue
+		}
+
+		for _, item := range v.Content {
+			name := lookupScalar(item, "name")
+			if len(name) == 0 {
+				continue
+			}
+
+			if _, ok := ret[kind]; !ok {
+				ret[kind] = make(map[string]*yaml.Node)
+			}
+
+			// first defined is effective, same as tools found by name
+			if _, ok := ret[kind][name]; !ok {
+				ret[kind][name] = resolveAlias(item)
+			}
+		}
+	}
+
+	return ret
+}
+
+// index returns index of the tool with kind and name, returns -1 if not found
+func (m *Tools) index(kind, name string) int {
+	if len(name) == 0 {
+		return -1
+	}
+
+	for i, t := range m.Tools[kind] {
+		if string(t.Name()) == name {
+			return i
+		}
+	}
+
+	return -1
+}
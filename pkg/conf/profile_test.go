@@ -0,0 +1,256 @@
+package conf
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+
+	di "arhat.dev/dukkha/internal"
+	dukkha_test "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/renderer/tpl"
+	"arhat.dev/dukkha/pkg/tools/golang"
+)
+
+func TestApplyProfiles(t *testing.T) {
+	testFS := fstest.MapFS{
+		".dukkha.yaml": &fstest.MapFile{
+			Data: []byte(`
+include:
+- path: profiles.yaml
+
+global:
+  env:
+  - name: STAGE
+    value: dev
+
+tools:
+  golang:
+  - name: local
+    cmd: [go]
+
+golang:build:
+- name: foo
+  chdir: src
+  path: ./cmd/foo
+  extra_args: [-v]
+`),
+		},
+		"profiles.yaml": &fstest.MapFile{
+			Data: []byte(`
+profiles:
+  ci:
+    global:
+      env:
+      - name: STAGE
+        value: ci
+    tools:
+      golang:
+      - name: local
+        cmd: [go1.17]
+    golang:build:
+    - name: foo
+      extra_args+: [-race]
+  release:
+    golang:build:
+    - name: foo
+      path: ./cmd/foo-release
+    - name: bar
+      path: ./cmd/bar
+`),
+		},
+	}
+
+	for _, test := range []struct {
+		name     string
+		profiles []string
+
+		stage     string
+		cmd       []string
+		path      string
+		extraArgs []string
+		taskCount int
+		err       bool
+	}{
+		{
+			name:      "None",
+			stage:     "dev",
+			cmd:       []string{"go"},
+			path:      "./cmd/foo",
+			extraArgs: []string{"-v"},
+			taskCount: 1,
+		},
+		{
+			name:      "Single",
+			profiles:  []string{"ci"},
+			stage:     "ci",
+			cmd:       []string{"go1.17"},
+			path:      "./cmd/foo",
+			extraArgs: []string{"-v", "-race"},
+			taskCount: 1,
+		},
+		{
+			name:      "Multiple",
+			profiles:  []string{"ci", "release"},
+			stage:     "ci",
+			cmd:       []string{"go1.17"},
+			path:      "./cmd/foo-release",
+			extraArgs: []string{"-v", "-race"},
+			taskCount: 2,
+		},
+		{
+			name:     "Not Found",
+			profiles: []string{"unknown"},
+			err:      true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			rc := dukkha_test.NewTestContext(context.TODO())
+			rc.(di.CacheDirSetter).SetCacheDir(t.TempDir())
+
+			config := NewConfig()
+			visitedPaths := make(map[string]struct{})
+			if !assert.NoError(t, Read(rc, testFS, []string{".dukkha.yaml"}, nil, false, &visitedPaths, config)) {
+				return
+			}
+
+			err := config.ApplyProfiles(test.profiles)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			if !assert.NoError(t, config.Resolve(rc, true)) {
+				return
+			}
+
+			assert.Equal(t, test.stage, rc.Env()["STAGE"].Get())
+
+			if assert.Len(t, config.Tools.Tools["golang"], 1) {
+				assert.EqualValues(t, test.cmd, config.Tools.Tools["golang"][0].(*golang.Tool).Cmd)
+			}
+
+			tasks := make(map[string]*golang.TaskBuild)
+			for _, tsk := range config.Tasks["golang:build"] {
+				assert.NoError(t, tsk.ResolveFields(rc, -1))
+				tasks[string(tsk.Name())] = tsk.(*golang.TaskBuild)
+			}
+
+			if !assert.Len(t, tasks, test.taskCount) {
+				return
+			}
+
+			assert.Equal(t, "src", tasks["foo"].Chdir)
+			assert.Equal(t, test.path, tasks["foo"].Path)
+			assert.EqualValues(t, test.extraArgs, tasks["foo"].ExtraArgs)
+		})
+	}
+}
+
+func TestApplyProfiles_Tools(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		tool    string
+		overlay string
+
+		cmd []string
+		env []string
+	}{
+		{
+			name:    "Plain Overrides Rendering Suffix",
+			tool:    "cmd@tpl: [\"{{ `go` }}\"]",
+			overlay: "cmd: [go1.17]",
+			cmd:     []string{"go1.17"},
+		},
+		{
+			name:    "Rendering Suffix Overrides Plain",
+			tool:    "cmd: [go]",
+			overlay: "cmd@tpl: [\"{{ `go1.17` }}\"]",
+			cmd:     []string{"go1.17"},
+		},
+		{
+			name:    "Rendering Suffix Overrides Rendering Suffix",
+			tool:    "cmd@tpl: [\"{{ `go` }}\"]",
+			overlay: "cmd@tpl: [\"{{ `go1.17` }}\"]",
+			cmd:     []string{"go1.17"},
+		},
+		{
+			name:    "Nested List Replaced",
+			tool:    "cmd: [go]\n    env:\n    - name: A\n      value@tpl: \"{{ `a` }}\"",
+			overlay: "env:\n        - name: B\n          value@tpl: \"{{ `b` }}\"",
+			cmd:     []string{"go"},
+			env:     []string{"B=b"},
+		},
+		{
+			name:    "Nested List Appended",
+			tool:    "cmd: [go]\n    env:\n    - name: A\n      value@tpl: \"{{ `a` }}\"",
+			overlay: "env+:\n        - name: B\n          value: b",
+			cmd:     []string{"go"},
+			env:     []string{"A=a", "B=b"},
+		},
+		{
+			name:    "Nested List Rendering Suffix Replaced",
+			tool:    "cmd: [go]\n    env@tpl: |-\n      - name: A\n        value: a",
+			overlay: "env+:\n        - name: B\n          value: b",
+			cmd:     []string{"go"},
+			env:     []string{"B=b"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			testFS := fstest.MapFS{
+				".dukkha.yaml": &fstest.MapFile{
+					Data: []byte(`
+tools:
+  golang:
+  - name: local
+    ` + test.tool + `
+
+profiles:
+  ci:
+    tools:
+      golang:
+      - name: local
+        ` + test.overlay + `
+`),
+				},
+			}
+
+			rc := dukkha_test.NewTestContext(context.TODO())
+			rc.(di.CacheDirSetter).SetCacheDir(t.TempDir())
+			rc.AddRenderer("tpl", tpl.NewDefault("tpl"))
+
+			config := NewConfig()
+			visitedPaths := make(map[string]struct{})
+			if !assert.NoError(t, Read(rc, testFS, []string{".dukkha.yaml"}, nil, false, &visitedPaths, config)) {
+				return
+			}
+
+			if !assert.NoError(t, config.ApplyProfiles([]string{"ci"})) {
+				return
+			}
+
+			if !assert.NoError(t, config.Resolve(rc, true)) {
+				return
+			}
+
+			if !assert.Len(t, config.Tools.Tools["golang"], 1) {
+				return
+			}
+
+			tool := config.Tools.Tools["golang"][0].(*golang.Tool)
+			assert.NoError(t, tool.ResolveFields(rc, -1))
+			assert.EqualValues(t, test.cmd, tool.Cmd)
+
+			var env []string
+			for _, e := range tool.Env {
+				env = append(env, e.Name+"="+e.Value)
+			}
+			assert.EqualValues(t, test.env, env)
+		})
+	}
+}
@@ -12,13 +12,18 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/output"
 )
 
 // Read config recursively
+//
+// exts are extensions of files parsed in config directories, defaults to
+// DefaultConfigExtensions
 func Read(
 	rc dukkha.ConfigResolvingContext,
 	rootfs fs.FS,
 	configPaths []string,
+	exts []string,
 	ignoreFileNotExist bool,
 	visitedPaths *map[string]struct{},
 	mergedConfig *Config,
@@ -37,7 +42,7 @@ func Read(
 
 		if !info.IsDir() {
 			err = readAndMergeConfigFile(rc,
-				rootfs, visitedPaths, mergedConfig, target,
+				rootfs, exts, visitedPaths, mergedConfig, target,
 			)
 			if err != nil {
 				return err
@@ -60,15 +65,12 @@ func Read(
 				return nil
 			}
 
-			switch path.Ext(pathInDir) {
-			case ".yaml":
-				// leave .yml for customization
-			default:
+			if !hasExtension(pathInDir, exts) {
 				return nil
 			}
 
 			return readAndMergeConfigFile(rc,
-				rootfs, visitedPaths, mergedConfig, path.Join(target, pathInDir),
+				rootfs, exts, visitedPaths, mergedConfig, path.Join(target, pathInDir),
 			)
 		})
 
@@ -80,9 +82,25 @@ func Read(
 	return nil
 }
 
+func hasExtension(file string, exts []string) bool {
+	if len(exts) == 0 {
+		exts = DefaultConfigExtensions
+	}
+
+	ext := path.Ext(file)
+	for _, e := range exts {
+		if ext == e {
+			return true
+		}
+	}
+
+	return false
+}
+
 func readAndMergeConfigFile(
 	rc dukkha.ConfigResolvingContext,
 	rootfs fs.FS,
+	exts []string,
 	visitedPaths *map[string]struct{},
 	mergedConfig *Config,
 	file string,
@@ -98,19 +116,22 @@ func readAndMergeConfigFile(
 		return fmt.Errorf("read config file %q: %w", file, err)
 	}
 
-	include, err := loadConfig(rc, r, mergedConfig)
+	include, err := loadConfig(rc, file, r, mergedConfig)
 	_ = r.Close()
 	if err != nil {
 		return err
 	}
 
-	return handleInclude(rc, rootfs, visitedPaths, mergedConfig, file, include)
+	return handleInclude(rc, rootfs, exts, visitedPaths, mergedConfig, file, include)
 }
 
 // loadConfig unmarshal all yaml docs in r as Config, add configured renderers into rc
 // then merge freshly unmarshaled Config into mergedConfig
+//
+// source is the name of r, used in rendering trace
 func loadConfig(
 	rc dukkha.ConfigResolvingContext,
+	source string,
 	r io.Reader,
 	mergedConfig *Config,
 ) ([]*IncludeEntry, error) {
@@ -118,9 +139,8 @@ func loadConfig(
 
 	dec := yaml.NewDecoder(r)
 	for {
-		current := NewConfig()
-
-		err := dec.Decode(current)
+		var doc yaml.Node
+		err := dec.Decode(&doc)
 		if err != nil {
 			if err == io.EOF {
 				return ret, nil
@@ -129,6 +149,38 @@ func loadConfig(
 			return nil, fmt.Errorf("unmarshal config: %w", err)
 		}
 
+		output.TraceDocument(rc, source, &doc)
+
+		customTools, err := resolveCustomTools(rc, &doc)
+		if err != nil {
+			return nil, err
+		}
+
+		profiles, err := collectProfiles(&doc)
+		if err != nil {
+			return nil, err
+		}
+
+		current := NewConfig()
+		current.profiles = profiles
+
+		// tasks using `extends` are removed from doc and unmarshaled
+		// after all config loaded
+		err = current.rawTasks.collect(&doc)
+		if err != nil {
+			return nil, err
+		}
+
+		current.rawValues = collectRawValues(&doc)
+		current.rawTools = collectRawTools(&doc)
+
+		err = doc.Decode(current)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal config: %w", err)
+		}
+
+		current.CustomTools = customTools
+
 		err = current.resolveRenderers(rc)
 		if err != nil {
 			return nil, fmt.Errorf("resolve renderers: %w", err)
@@ -139,6 +191,11 @@ func loadConfig(
 			return nil, fmt.Errorf("resolve shells: %w", err)
 		}
 
+		err = current.resolveTemplates(rc)
+		if err != nil {
+			return nil, fmt.Errorf("resolve templates: %w", err)
+		}
+
 		err = current.ResolveFields(rc, -1, "include")
 		if err != nil {
 			return nil, fmt.Errorf("resolve include entries: %w", err)
@@ -156,6 +213,7 @@ func loadConfig(
 func handleInclude(
 	rc dukkha.ConfigResolvingContext,
 	rootfs fs.FS,
+	exts []string,
 	visitedPaths *map[string]struct{},
 	mergedConfig *Config,
 	currentFile string,
@@ -176,19 +234,21 @@ func handleInclude(
 			}
 
 			err2 = Read(rc,
-				rootfs, matches, false, visitedPaths, mergedConfig,
+				rootfs, matches, exts, false, visitedPaths, mergedConfig,
 			)
 
 			if err2 != nil {
 				return fmt.Errorf("loading included config files: %w", err2)
 			}
 		case len(inc.Text) != 0:
-			embedInclude, err := loadConfig(rc, strings.NewReader(inc.Text), mergedConfig)
+			embedInclude, err := loadConfig(rc,
+				"text included by "+currentFile, strings.NewReader(inc.Text), mergedConfig,
+			)
 			if err != nil {
 				return err
 			}
 
-			err = handleInclude(rc, rootfs, visitedPaths, mergedConfig, currentFile, embedInclude)
+			err = handleInclude(rc, rootfs, exts, visitedPaths, mergedConfig, currentFile, embedInclude)
 			if err != nil {
 				return err
 			}
@@ -131,6 +131,7 @@ func TestRead(t *testing.T) {
 				rc,
 				testFS,
 				test.configPaths,
+				nil,
 				test.ignoreFileNotExist,
 				&visitedPaths,
 				mergedConfig,
@@ -155,6 +156,41 @@ func TestRead(t *testing.T) {
 	}
 }
 
+func TestRead_Extensions(t *testing.T) {
+	testFS := fstest.MapFS{
+		"dukkha.d/a.yaml": &fstest.MapFile{
+			Data: []byte("global:\n  default_git_branch: a\n"),
+		},
+		"dukkha.d/b.yml": &fstest.MapFile{
+			Data: []byte("global:\n  default_git_branch: b\n"),
+		},
+		"dukkha.d/c.json": &fstest.MapFile{
+			Data: []byte(`{"global": {"default_git_branch": "c"}}`),
+		},
+	}
+
+	for _, test := range []struct {
+		name     string
+		exts     []string
+		expected string
+	}{
+		{name: "Default", exts: nil, expected: "a"},
+		{name: "YML", exts: []string{".yaml", ".yml"}, expected: "b"},
+		{name: "All", exts: []string{".yaml", ".yml", ".json"}, expected: "c"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			mergedConfig := NewConfig()
+			rc := dukkha_test.NewTestContext(context.Background())
+			err := Read(rc, testFS, []string{"dukkha.d"}, test.exts, false, &map[string]struct{}{}, mergedConfig)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, test.expected, mergedConfig.Global.DefaultGitBranch)
+		})
+	}
+}
+
 func newConfig(update func(c *Config)) *Config {
 	ret := NewConfig()
 	if update != nil {
@@ -0,0 +1,88 @@
+package conf
+
+import (
+	"fmt"
+
+	"arhat.dev/pkg/log"
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/templateutils"
+)
+
+// TemplateLibrary is a set of named templates (`{{ define "name" }}` blocks)
+// available to all templates (e.g. `tpl` renderer, template operation in `T`
+// renderer, `eval.Template`)
+type TemplateLibrary struct {
+	rs.BaseField `yaml:"-"`
+
+	// Namespace prefixes names of templates defined in this library
+	//
+	// e.g. `{{ define "labels" }}` in namespace `k8s` is available as `k8s.labels`,
+	// templates in the same library can still reference it as `labels`
+	Namespace string `yaml:"namespace"`
+
+	// Include template files and texts
+	Include []*TemplateInclude `yaml:"include"`
+}
+
+// TemplateInclude is a source of library templates
+type TemplateInclude struct {
+	rs.BaseField `yaml:"-"`
+
+	// Path to local template files, relative to DUKKHA_WORKDIR
+	//
+	// With path glob pattern '*' and '**' support
+	//
+	// Path and Text are mutually exclusive
+	Path string `yaml:"path"`
+
+	// Text is the template text to include, usually used with rendering suffix
+	// to include remote templates
+	//
+	// Path and Text are mutually exclusive
+	Text string `yaml:"text"`
+}
+
+// resolveTemplates resolves `templates` section and adds all libraries to
+// the template library of appCtx
+func (c *Config) resolveTemplates(appCtx dukkha.ConfigResolvingContext) error {
+	logger := log.Log.WithName("config")
+
+	err := c.ResolveFields(appCtx, -1, "templates")
+	if err != nil {
+		return fmt.Errorf("resolving template libraries: %w", err)
+	}
+
+	logger.D("adding template libraries", log.Int("count", len(c.Templates)))
+	for i, lib := range c.Templates {
+		var texts []string
+		for _, inc := range lib.Include {
+			switch {
+			case len(inc.Path) != 0:
+				matches, err2 := appCtx.FS().Glob(inc.Path)
+				if err2 != nil || len(matches) == 0 {
+					matches = []string{inc.Path}
+				}
+
+				for _, file := range matches {
+					data, err2 := appCtx.FS().ReadFile(file)
+					if err2 != nil {
+						return fmt.Errorf("loading template file %q: %w", file, err2)
+					}
+
+					texts = append(texts, string(data))
+				}
+			case len(inc.Text) != 0:
+				texts = append(texts, inc.Text)
+			}
+		}
+
+		err = templateutils.AddTemplateLibrary(appCtx, lib.Namespace, texts...)
+		if err != nil {
+			return fmt.Errorf("adding template library #%d (namespace %q): %w", i, lib.Namespace, err)
+		}
+	}
+
+	return nil
+}
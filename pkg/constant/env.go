@@ -1,5 +1,7 @@
 package constant
 
+import "strings"
+
 // TODO(all): Update docs/environment-variables.md when updating this file
 
 // Environment variables for all tasks
@@ -43,3 +45,59 @@ const (
 	ENV_MATRIX_ARCH   = "MATRIX_ARCH"
 	ENV_MATRIX_LIBC   = "MATRIX_LIBC"
 )
+
+// Environment variables read by dukkha
+// nolint:revive
+const (
+	// comma separated profile names, used when --profile is not set
+	ENV_DUKKHA_PROFILE = "DUKKHA_PROFILE"
+
+	// serve remote content from cache only (true/false), used when
+	// --offline is not set
+	ENV_DUKKHA_OFFLINE = "DUKKHA_OFFLINE"
+
+	// url of remote cache shared by renderers, used when --remote-cache
+	// is not set, see `dukkha --help` for supported schemes
+	ENV_DUKKHA_REMOTE_CACHE = "DUKKHA_REMOTE_CACHE"
+
+	// do not upload to remote cache (true/false), used when
+	// --remote-cache-read-only is not set
+	ENV_DUKKHA_REMOTE_CACHE_READ_ONLY = "DUKKHA_REMOTE_CACHE_READ_ONLY"
+
+	// max count of fields rendered in parallel, used when
+	// --render-concurrency is not set
+	ENV_DUKKHA_RENDER_CONCURRENCY = "DUKKHA_RENDER_CONCURRENCY"
+
+	// bearer token for http remote cache
+	ENV_DUKKHA_REMOTE_CACHE_TOKEN = "DUKKHA_REMOTE_CACHE_TOKEN"
+
+	// credentials for s3 remote cache
+	ENV_DUKKHA_REMOTE_CACHE_ACCESS_KEY_ID     = "DUKKHA_REMOTE_CACHE_ACCESS_KEY_ID"
+	ENV_DUKKHA_REMOTE_CACHE_ACCESS_KEY_SECRET = "DUKKHA_REMOTE_CACHE_ACCESS_KEY_SECRET"
+
+	// keys for secret renderer and `dukkha secret` commands
+	ENV_DUKKHA_SECRET_AGE_IDENTITY      = "DUKKHA_SECRET_AGE_IDENTITY"
+	ENV_DUKKHA_SECRET_AGE_IDENTITY_FILE = "DUKKHA_SECRET_AGE_IDENTITY_FILE"
+	ENV_DUKKHA_SECRET_AES_KEY           = "DUKKHA_SECRET_AES_KEY"
+	ENV_DUKKHA_SECRET_AES_KEY_FILE      = "DUKKHA_SECRET_AES_KEY_FILE"
+
+	// prefix of env answering input renderer non-interactively
+	// (DUKKHA_INPUT_<KEY>), also set by `--input key=value`
+	ENV_PREFIX_DUKKHA_INPUT = "DUKKHA_INPUT_"
+)
+
+// GetInputEnvName returns name of the env answering input with key,
+// the key is upper cased and characters other than letters and digits
+// are replaced with `_` (e.g. `bump-type` to `DUKKHA_INPUT_BUMP_TYPE`)
+func GetInputEnvName(key string) string {
+	return ENV_PREFIX_DUKKHA_INPUT + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+}
@@ -0,0 +1,244 @@
+// Package dotenv parses `.env` files
+//
+// supported syntax (compatible with docker compose and python-dotenv)
+//   - `NAME=value`, optionally prefixed with `export `
+//   - full line comments (`# ...`) and inline comments after unquoted
+//     values (` # ...`)
+//   - single quoted values are used literally
+//   - double quoted values support escape sequences (`\n`, `\t`, `\"`, `\\`)
+//     and can span multiple lines
+package dotenv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Entry is a single variable defined in dotenv file
+type Entry struct {
+	Name  string
+	Value string
+
+	// SingleQuoted is true when the value is single quoted, such value
+	// should not be expanded
+	SingleQuoted bool
+}
+
+// Error is the error of invalid dotenv file
+type Error struct {
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("dotenv: line %d: %s", e.Line, e.Msg)
+}
+
+// Parse parses dotenv file content, entries are returned in the order
+// defined, later entries with the same name are kept
+func Parse(data []byte) ([]*Entry, error) {
+	p := &parser{
+		src:  strings.ReplaceAll(string(data), "\r\n", "\n"),
+		line: 1,
+	}
+
+	var ret []*Entry
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return ret, nil
+		}
+
+		entry, err := p.parseEntry()
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, entry)
+	}
+}
+
+// ToMap converts entries to map, later entries override former ones
+func ToMap(entries []*Entry) map[string]string {
+	ret := make(map[string]string, len(entries))
+	for _, e := range entries {
+		ret[e.Name] = e.Value
+	}
+
+	return ret
+}
+
+type parser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (p *parser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &Error{Line: p.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// skipBlank skips whitespaces, empty lines and comment lines
+func (p *parser) skipBlank() {
+	for !p.eof() {
+		switch c := p.src[p.pos]; c {
+		case '\n':
+			p.line++
+			p.pos++
+		case ' ', '\t':
+			p.pos++
+		case '#':
+			p.skipLine()
+		default:
+			return
+		}
+	}
+}
+
+// skipLine skips to the beginning of next line
+func (p *parser) skipLine() {
+	for !p.eof() && p.src[p.pos] != '\n' {
+		p.pos++
+	}
+}
+
+func (p *parser) skipSpaces() {
+	for !p.eof() && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *parser) parseEntry() (*Entry, error) {
+	if strings.HasPrefix(p.src[p.pos:], "export ") ||
+		strings.HasPrefix(p.src[p.pos:], "export\t") {
+		p.pos += len("export")
+		p.skipSpaces()
+	}
+
+	start := p.pos
+	for !p.eof() && isNameChar(p.src[p.pos]) {
+		p.pos++
+	}
+
+	name := p.src[start:p.pos]
+	if len(name) == 0 {
+		return nil, p.errorf("invalid variable name")
+	}
+
+	p.skipSpaces()
+	if p.eof() || p.src[p.pos] != '=' {
+		return nil, p.errorf("expecting `=` after %q", name)
+	}
+	p.pos++
+	p.skipSpaces()
+
+	entry := &Entry{Name: name}
+
+	var err error
+	switch {
+	case p.eof():
+	case p.src[p.pos] == '\'':
+		entry.SingleQuoted = true
+		entry.Value, err = p.parseSingleQuoted()
+	case p.src[p.pos] == '"':
+		entry.Value, err = p.parseDoubleQuoted()
+	default:
+		entry.Value = p.parseUnquoted()
+		return entry, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// only comments allowed after quoted value
+	p.skipSpaces()
+	if !p.eof() && p.src[p.pos] != '\n' && p.src[p.pos] != '#' {
+		return nil, p.errorf("unexpected character %q after quoted value of %q", p.src[p.pos], name)
+	}
+	p.skipLine()
+
+	return entry, nil
+}
+
+func (p *parser) parseSingleQuoted() (string, error) {
+	line := p.line
+	p.pos++
+
+	end := strings.IndexByte(p.src[p.pos:], '\'')
+	if end < 0 {
+		p.line = line
+		return "", p.errorf("unterminated single quoted value")
+	}
+
+	ret := p.src[p.pos : p.pos+end]
+	p.line += strings.Count(ret, "\n")
+	p.pos += end + 1
+
+	return ret, nil
+}
+
+func (p *parser) parseDoubleQuoted() (string, error) {
+	line := p.line
+	p.pos++
+
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.src[p.pos]
+		p.pos++
+
+		switch c {
+		case '"':
+			return sb.String(), nil
+		case '\n':
+			p.line++
+			sb.WriteByte(c)
+		case '\\':
+			if p.eof() {
+				break
+			}
+
+			e := p.src[p.pos]
+			p.pos++
+			switch e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case '"', '\\':
+				sb.WriteByte(e)
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(e)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	p.line = line
+	return "", p.errorf("unterminated double quoted value")
+}
+
+func (p *parser) parseUnquoted() string {
+	start := p.pos
+	p.skipLine()
+
+	value := p.src[start:p.pos]
+	for i := 0; i < len(value); i++ {
+		if value[i] == '#' && i > 0 && (value[i-1] == ' ' || value[i-1] == '\t') {
+			value = value[:i]
+			break
+		}
+	}
+
+	return strings.TrimSpace(value)
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
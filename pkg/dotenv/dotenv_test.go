@@ -0,0 +1,59 @@
+package dotenv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	entries, err := Parse([]byte(`# comment
+FOO=bar
+export EXPORTED = value # inline comment
+HASH=a#b
+EMPTY=
+
+SINGLE='${FOO} # not comment'
+DOUBLE="line1\nline2 \"quoted\"" # comment
+MULTI="a
+b"
+URL=http://example.com/?a=b
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []*Entry{
+		{Name: "FOO", Value: "bar"},
+		{Name: "EXPORTED", Value: "value"},
+		{Name: "HASH", Value: "a#b"},
+		{Name: "EMPTY", Value: ""},
+		{Name: "SINGLE", Value: "${FOO} # not comment", SingleQuoted: true},
+		{Name: "DOUBLE", Value: "line1\nline2 \"quoted\""},
+		{Name: "MULTI", Value: "a\nb"},
+		{Name: "URL", Value: "http://example.com/?a=b"},
+	}, entries)
+
+	assert.Equal(t, map[string]string{"A": "2"}, ToMap([]*Entry{
+		{Name: "A", Value: "1"},
+		{Name: "A", Value: "2"},
+	}))
+}
+
+func TestParse_Error(t *testing.T) {
+	for _, test := range []struct {
+		data string
+		line int
+	}{
+		{"FOO", 1},
+		{"A=1\n=2", 2},
+		{"A='foo", 1},
+		{"A=1\nB=\"foo\nbar", 2},
+		{"A='foo' bar", 1},
+	} {
+		_, err := Parse([]byte(test.data))
+		if assert.Error(t, err, test.data) {
+			assert.Equal(t, test.line, err.(*Error).Line, test.data)
+		}
+	}
+}
@@ -57,6 +57,7 @@ func newContextRendering(
 		ifaceTypeHandler: ifaceTypeHandler,
 		renderers:        make(map[string]Renderer),
 		values:           make(map[string]interface{}),
+		preRendered:      newPreRenderedResults(),
 
 		fs: lazyEnsuredSubFS(fshelper.NewOSFS(false, func() (string, error) {
 			return envValues.WorkDir(), nil
@@ -84,6 +85,20 @@ type contextRendering struct {
 	// nolint:revive
 	_VALUE interface{}
 
+	// templateLibrary is the library of named templates defined in config,
+	// shared by all derived contexts
+	templateLibrary interface{}
+
+	// tracer records rendering steps when set, shared by all derived contexts
+	tracer RenderingTracer
+
+	// renderConcurrency is the max count of fields pre-rendered in parallel,
+	// see PreRender
+	renderConcurrency int
+
+	// preRendered results shared by all derived contexts
+	preRendered *preRenderedResults
+
 	fs      *fshelper.OSFS
 	cacheFS *fshelper.OSFS
 }
@@ -103,6 +118,12 @@ func (c *contextRendering) clone(newCtx *contextStd, deepCopy bool) *contextRend
 		// values are global scoped, DO NOT deep copy in any case
 		values: c.values,
 
+		templateLibrary: c.templateLibrary,
+		tracer:          c.tracer,
+
+		renderConcurrency: c.renderConcurrency,
+		preRendered:       c.preRendered,
+
 		fs: lazyEnsuredSubFS(fshelper.NewOSFS(false, func() (string, error) {
 			return envValues.WorkDir(), nil
 		}), "."),
@@ -179,23 +200,42 @@ func (c *contextRendering) Values() map[string]interface{} {
 	return c.values
 }
 
-func (c *contextRendering) RenderYaml(renderer string, rawData interface{}) ([]byte, error) {
+func (c *contextRendering) RenderYaml(renderer string, rawData interface{}) (result []byte, err error) {
+	if c.tracer != nil {
+		done := c.tracer.TraceRendering(renderer, rawData)
+		defer func() { done(result, err) }()
+	}
+
+	if data, ok := c.preRendered.get(renderer, rawData); ok {
+		return data, nil
+	}
+
+	v, attributes, err := c.getRenderer(renderer)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.RenderYaml(c, rawData, attributes)
+}
+
+// getRenderer finds renderer by name with optional attributes (e.g. `http#cached-file`)
+func (c *contextRendering) getRenderer(name string) (Renderer, []RendererAttribute, error) {
 	var attributes []RendererAttribute
-	attrStart := strings.LastIndexByte(renderer, '#')
+	attrStart := strings.LastIndexByte(name, '#')
 	if attrStart != -1 {
-		for _, attr := range strings.Split(renderer[attrStart+1:], ",") {
+		for _, attr := range strings.Split(name[attrStart+1:], ",") {
 			attributes = append(attributes, RendererAttribute(strings.TrimSpace(attr)))
 		}
 
-		renderer = renderer[:attrStart]
+		name = name[:attrStart]
 	}
 
-	v, ok := c.renderers[renderer]
+	v, ok := c.renderers[name]
 	if !ok {
-		return nil, fmt.Errorf("renderer %q not found", renderer)
+		return nil, nil, fmt.Errorf("renderer %q not found", name)
 	}
 
-	return v.RenderYaml(c, rawData, attributes)
+	return v, attributes, nil
 }
 
 func (c *contextRendering) Create(typ reflect.Type, yamlKey string) (interface{}, error) {
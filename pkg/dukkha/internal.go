@@ -13,6 +13,29 @@ func (c *contextRendering) SetVALUE(value interface{}) { c._VALUE = value }
 // VALUE for transform renderer
 func (c *contextRendering) VALUE() interface{} { return c._VALUE }
 
+// SetTemplateLibrary for templates shared by all template rendering
+func (c *contextRendering) SetTemplateLibrary(lib interface{}) { c.templateLibrary = lib }
+
+// TemplateLibrary for templates shared by all template rendering
+func (c *contextRendering) TemplateLibrary() interface{} { return c.templateLibrary }
+
+// SetRenderingTracer for `--trace-rendering`, tracer MUST implement RenderingTracer
+func (c *contextRendering) SetRenderingTracer(tracer interface{}) {
+	c.tracer, _ = tracer.(RenderingTracer)
+}
+
+// RenderingTracer for `--trace-rendering`
+func (c *contextRendering) RenderingTracer() interface{} {
+	if c.tracer == nil {
+		return nil
+	}
+
+	return c.tracer
+}
+
+// SetRenderConcurrency for pre-rendering fields in parallel, see PreRender
+func (c *contextRendering) SetRenderConcurrency(n int) { c.renderConcurrency = n }
+
 // SetCacheDir set env DUKKHA_CACHE_DIR
 //
 // should not be exposed by any interface type in this package
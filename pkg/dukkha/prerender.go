@@ -0,0 +1,194 @@
+package dukkha
+
+import (
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PreRender renders independent fields using rendering suffix in nodes in
+// parallel (at most render concurrency of rc at the same time), results are
+// used when these fields are rendered with rc or contexts derived from it,
+// until the returned func is called
+//
+// it does nothing unless render concurrency of rc is greater than 1, and
+// only fields whose first renderer is concurrency safe (see ConcurrentRenderer)
+// are pre-rendered, fields inside values of fields using rendering suffix are
+// left to the renderer
+//
+// fields in nodes MUST NOT depend on each other (e.g. items in global values)
+func PreRender(rc RenderingContext, nodes ...*yaml.Node) (done func()) {
+	pr, ok := rc.(interface {
+		preRender(nodes []*yaml.Node) func()
+	})
+	if !ok {
+		return func() {}
+	}
+
+	return pr.preRender(nodes)
+}
+
+func (c *contextRendering) preRender(nodes []*yaml.Node) func() {
+	// rendering trace is kept sequential to be accurate
+	if c.renderConcurrency <= 1 || c.tracer != nil {
+		return func() {}
+	}
+
+	var fields []preRenderedKey
+	for _, n := range nodes {
+		fields = c.collectPreRenderFields(fields, n)
+	}
+
+	if len(fields) < 2 {
+		return func() {}
+	}
+
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, c.renderConcurrency)
+		results = make([][]byte, len(fields))
+		okList  = make([]bool, len(fields))
+	)
+
+	for i, f := range fields {
+		// renderers are looked up when collecting fields
+		r, attributes, _ := c.getRenderer(f.renderer)
+
+		// each renderer gets its own context since env is not safe for
+		// concurrent use
+		rc := c.clone(c.contextStd, true)
+		rc.tracer = nil
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, node *yaml.Node) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			data, err := r.RenderYaml(rc, node, attributes)
+			if err != nil {
+				// rendered again when resolving to report the error
+				return
+			}
+
+			results[i], okList[i] = data, true
+		}(i, f.node)
+	}
+
+	wg.Wait()
+
+	var added []preRenderedKey
+	for i, f := range fields {
+		if okList[i] {
+			c.preRendered.set(f, results[i])
+			added = append(added, f)
+		}
+	}
+
+	return func() {
+		for _, f := range added {
+			c.preRendered.delete(f)
+		}
+	}
+}
+
+// collectPreRenderFields appends fields with concurrency safe first renderer
+// in n to fields
+func (c *contextRendering) collectPreRenderFields(fields []preRenderedKey, n *yaml.Node) []preRenderedKey {
+	if n == nil {
+		return fields
+	}
+
+	switch n.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, v := range n.Content {
+			fields = c.collectPreRenderFields(fields, v)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+
+			suffix := ""
+			if idx := strings.LastIndexByte(k.Value, '@'); idx != -1 {
+				suffix = k.Value[idx+1:]
+			} else if tag := strings.TrimPrefix(
+				strings.TrimPrefix(v.Tag, "!rs:"), "!tag:arhat.dev/rs:",
+			); tag != v.Tag {
+				suffix = tag
+			}
+
+			if len(suffix) == 0 {
+				fields = c.collectPreRenderFields(fields, v)
+				continue
+			}
+
+			name := strings.SplitN(suffix, "|", 2)[0]
+			if strings.HasSuffix(name, "!") {
+				// patch spec is resolved before rendering
+				continue
+			}
+
+			if idx := strings.LastIndexByte(name, '?'); idx != -1 {
+				name = name[:idx]
+			}
+
+			r, _, err := c.getRenderer(name)
+			if err != nil {
+				continue
+			}
+
+			if cr, ok := r.(ConcurrentRenderer); ok && cr.ConcurrencySafe() {
+				fields = append(fields, preRenderedKey{node: v, renderer: name})
+			}
+		}
+	}
+
+	return fields
+}
+
+type preRenderedKey struct {
+	node     *yaml.Node
+	renderer string
+}
+
+func newPreRenderedResults() *preRenderedResults {
+	return &preRenderedResults{
+		results: make(map[preRenderedKey][]byte),
+	}
+}
+
+// preRenderedResults are results of PreRender
+type preRenderedResults struct {
+	mu      sync.RWMutex
+	results map[preRenderedKey][]byte
+}
+
+func (p *preRenderedResults) get(renderer string, rawData interface{}) ([]byte, bool) {
+	node, ok := rawData.(*yaml.Node)
+	if !ok || p == nil {
+		return nil, false
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	data, ok := p.results[preRenderedKey{node: node, renderer: renderer}]
+	return data, ok
+}
+
+func (p *preRenderedResults) set(k preRenderedKey, data []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.results[k] = data
+}
+
+func (p *preRenderedResults) delete(k preRenderedKey) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.results, k)
+}
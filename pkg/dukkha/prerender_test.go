@@ -0,0 +1,136 @@
+package dukkha
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"arhat.dev/pkg/fshelper"
+	"arhat.dev/rs"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+var _ ConcurrentRenderer = (*countingRenderer)(nil)
+
+type countingRenderer struct {
+	rs.BaseField
+
+	safe bool
+
+	mu        sync.Mutex
+	calls     int
+	active    int
+	maxActive int
+}
+
+func (r *countingRenderer) Init(*fshelper.OSFS) error { return nil }
+func (r *countingRenderer) Alias() string             { return "" }
+func (r *countingRenderer) ConcurrencySafe() bool     { return r.safe }
+
+func (r *countingRenderer) RenderYaml(
+	_ RenderingContext, rawData interface{}, _ []RendererAttribute,
+) ([]byte, error) {
+	r.mu.Lock()
+	r.calls++
+	r.active++
+	if r.active > r.maxActive {
+		r.maxActive = r.active
+	}
+	r.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	r.mu.Lock()
+	r.active--
+	r.mu.Unlock()
+
+	return []byte(rawData.(*yaml.Node).Value + "-rendered"), nil
+}
+
+func (r *countingRenderer) stats() (calls, maxActive int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.calls, r.maxActive
+}
+
+func TestPreRender(t *testing.T) {
+	const src = `
+a@safe: a
+b@safe: b
+c@safe#attr|safe: c
+d:
+  e@safe: e
+f@unsafe: f
+`
+
+	expected := map[string]interface{}{
+		"a": "a-rendered",
+		"b": "b-rendered",
+		"c": "c-rendered-rendered",
+		"d": map[string]interface{}{
+			"e": "e-rendered",
+		},
+		"f": "f-rendered",
+	}
+
+	for _, test := range []struct {
+		name        string
+		concurrency int
+
+		preRendered int
+	}{
+		{name: "Disabled", concurrency: 0, preRendered: 0},
+		{name: "Single", concurrency: 1, preRendered: 0},
+		{name: "Parallel", concurrency: 2, preRendered: 4},
+		{name: "Unbounded", concurrency: 10, preRendered: 4},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			safe, unsafe := &countingRenderer{safe: true}, &countingRenderer{}
+
+			rc := NewConfigResolvingContext(context.TODO(), nil, nil)
+			rc.AddRenderer("safe", safe)
+			rc.AddRenderer("unsafe", unsafe)
+			rc.(interface{ SetRenderConcurrency(n int) }).SetRenderConcurrency(test.concurrency)
+
+			doc := new(yaml.Node)
+			if !assert.NoError(t, yaml.Unmarshal([]byte(src), doc)) {
+				return
+			}
+
+			obj := &rs.AnyObject{}
+			if !assert.NoError(t, doc.Decode(obj)) {
+				return
+			}
+
+			done := PreRender(rc, doc)
+
+			calls, maxActive := safe.stats()
+			assert.Equal(t, test.preRendered, calls)
+			if test.preRendered != 0 {
+				assert.LessOrEqual(t, maxActive, test.concurrency)
+			}
+
+			unsafeCalls, _ := unsafe.stats()
+			assert.Equal(t, 0, unsafeCalls)
+
+			assert.NoError(t, obj.ResolveFields(rc, -1))
+			done()
+
+			assert.EqualValues(t, expected, obj.NormalizedValue())
+
+			if test.preRendered != 0 {
+				// only the second renderer in the chain of c is called
+				calls, _ = safe.stats()
+				assert.Equal(t, test.preRendered+1, calls)
+			}
+
+			unsafeCalls, _ = unsafe.stats()
+			assert.NotZero(t, unsafeCalls)
+
+			assert.Len(t, rc.(*dukkhaContext).preRendered.results, 0)
+		})
+	}
+}
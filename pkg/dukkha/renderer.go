@@ -19,8 +19,23 @@ type Renderer interface {
 	RenderYaml(rc RenderingContext, rawData interface{}, attributes []RendererAttribute) (result []byte, err error)
 }
 
+// ConcurrentRenderer declares whether a renderer is safe to render concurrently,
+// renderers not implementing it are deemed unsafe
+type ConcurrentRenderer interface {
+	// ConcurrencySafe returns true when RenderYaml can be called concurrently
+	// (e.g. renderers fetching content without side effects)
+	ConcurrencySafe() bool
+}
+
 // RendererManager to manage renderers
 type RendererManager interface {
 	AllRenderers() map[string]Renderer
 	AddRenderer(name string, renderer Renderer)
 }
+
+// RenderingTracer records rendering steps for troubleshooting
+type RenderingTracer interface {
+	// TraceRendering is called before renderer renders rawData, the returned
+	// func is called with the rendering result
+	TraceRendering(renderer string, rawData interface{}) func(result []byte, err error)
+}
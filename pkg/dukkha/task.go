@@ -54,4 +54,16 @@ type Task interface {
 	//
 	// The implementation MUST be thread safe
 	GetHookExecSpecs(rc TaskExecContext, state TaskExecStage) ([]TaskExecSpec, error)
+
+	// RunWithOutputCache calls run to execute execSpecs generated for matrix
+	// entry ms, when output caching is enabled, run is skipped and outputs
+	// are restored from cache if the same execution has been done before
+	//
+	// The implementation MUST be thread safe
+	RunWithOutputCache(
+		rc TaskExecContext,
+		ms matrix.Entry,
+		execSpecs []TaskExecSpec,
+		run func() error,
+	) error
 }
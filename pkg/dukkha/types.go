@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"sync"
 
 	"arhat.dev/rs"
 )
@@ -38,6 +39,30 @@ var globalTypeManager = &TypeManager{
 
 var GlobalInterfaceTypeHandler rs.InterfaceTypeHandler = globalTypeManager
 
+// TypeResolver registers factories on demand, it is consulted when no
+// registered factory matches the yaml key of an interface type
+type TypeResolver interface {
+	// Resolve registers factories for yamlKey of typ if possible, returns
+	// true when new factories were registered
+	Resolve(typ reflect.Type, yamlKey string) (bool, error)
+}
+
+// SetTypeResolver sets the resolver consulted by GlobalInterfaceTypeHandler
+// when there is no factory for the yaml key, nil to unset
+func SetTypeResolver(r TypeResolver) {
+	globalTypeManager.mu.Lock()
+	defer globalTypeManager.mu.Unlock()
+
+	globalTypeManager.resolver = r
+}
+
+// IsRegistered checks whether there is a factory registered for yamlKey
+// of typ, TypeResolver is not consulted
+func IsRegistered(typ reflect.Type, yamlKey string) bool {
+	_, ok, _ := globalTypeManager.match(typ, yamlKey)
+	return ok
+}
+
 // type values for interface type registration
 var (
 	rendererType = reflect.TypeOf((*Renderer)(nil)).Elem()
@@ -129,7 +154,10 @@ type (
 var _ rs.InterfaceTypeHandler = (*TypeManager)(nil)
 
 type TypeManager struct {
-	types map[IfaceTypeKey]*IfaceFactory
+	mu sync.RWMutex
+
+	types    map[IfaceTypeKey]*IfaceFactory
+	resolver TypeResolver
 }
 
 func (h *TypeManager) Types() map[IfaceTypeKey]*IfaceFactory {
@@ -138,31 +166,58 @@ func (h *TypeManager) Types() map[IfaceTypeKey]*IfaceFactory {
 
 // Create implements rs.InterfaceTypeHandler
 func (h *TypeManager) Create(typ reflect.Type, yamlKey string) (interface{}, error) {
-	key := IfaceTypeKey{
-		Typ: typ,
+	impl, ok, err := h.match(typ, yamlKey)
+	if !ok {
+		h.mu.RLock()
+		resolver := h.resolver
+		h.mu.RUnlock()
+
+		if resolver == nil {
+			return nil, err
+		}
+
+		resolved, err2 := resolver.Resolve(typ, yamlKey)
+		if err2 != nil {
+			return nil, fmt.Errorf("resolving yaml field %q as %q: %w", yamlKey, typ.String(), err2)
+		}
+
+		if !resolved {
+			return nil, err
+		}
+
+		impl, ok, err = h.match(typ, yamlKey)
+		if !ok {
+			return nil, err
+		}
 	}
 
-	v, ok := h.types[key]
+	if impl.exp.NumSubexp() == 0 {
+		return impl.Create(nil), nil
+	}
+
+	return impl.Create(impl.exp.FindStringSubmatch(yamlKey)), nil
+}
+
+// match finds the first factory matching yamlKey of typ
+func (h *TypeManager) match(typ reflect.Type, yamlKey string) (*IfaceFactoryImpl, bool, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	v, ok := h.types[IfaceTypeKey{Typ: typ}]
 	if !ok {
-		return nil, fmt.Errorf(
+		return nil, false, fmt.Errorf(
 			"interface type %q not registered: %w",
 			typ.String(), rs.ErrInterfaceTypeNotHandled,
 		)
 	}
 
 	for _, impl := range v.Factories {
-		if !impl.exp.MatchString(yamlKey) {
-			continue
+		if impl.exp.MatchString(yamlKey) {
+			return impl, true, nil
 		}
-
-		if impl.exp.NumSubexp() == 0 {
-			return impl.Create(nil), nil
-		}
-
-		return impl.Create(impl.exp.FindStringSubmatch(yamlKey)), nil
 	}
 
-	return nil, fmt.Errorf("yaml field %q not resolved as %q", yamlKey, typ.String())
+	return nil, false, fmt.Errorf("yaml field %q not resolved as %q", yamlKey, typ.String())
 }
 
 func (h *TypeManager) register(
@@ -171,6 +226,9 @@ func (h *TypeManager) register(
 	yamlKeyMatch *regexp.Regexp,
 	createField IfaceFactoryFunc,
 ) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	key := IfaceTypeKey{
 		Typ: ifaceType,
 	}
@@ -0,0 +1,112 @@
+// Package ini converts ini data from and to generic map values
+package ini
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+// Unmarshal decodes ini data, keys in the default section are placed at
+// top level, other sections are maps of string values
+func Unmarshal(data []byte) (map[string]interface{}, error) {
+	f, err := ini.Load(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]interface{})
+	for _, sec := range f.Sections() {
+		target := ret
+		if sec.Name() != ini.DefaultSection {
+			target = make(map[string]interface{})
+			ret[sec.Name()] = target
+		}
+
+		for _, k := range sec.Keys() {
+			target[k.Name()] = k.Value()
+		}
+	}
+
+	return ret, nil
+}
+
+// Marshal encodes top-level scalars to the default section, maps to
+// sections, nested maps are encoded as sections with dotted names
+func Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("top-level value must be a map, got %T", v)
+	}
+
+	f := ini.Empty()
+	err := writeSection(f, ini.DefaultSection, m)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	_, err = f.WriteTo(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Next(buf.Len()), nil
+}
+
+func writeSection(f *ini.File, name string, m map[string]interface{}) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	sec, err := f.NewSection(name)
+	if err != nil {
+		return err
+	}
+
+	var subSections []string
+	for _, k := range keys {
+		var val string
+		switch t := m[k].(type) {
+		case map[string]interface{}:
+			subSections = append(subSections, k)
+			continue
+		case nil:
+		case string:
+			val = t
+		case float64:
+			val = strconv.FormatFloat(t, 'g', -1, 64)
+		case time.Time:
+			val = t.Format(time.RFC3339Nano)
+		case []interface{}:
+			return fmt.Errorf("unsupported list value of key %q in section %q", k, name)
+		default:
+			val = fmt.Sprint(t)
+		}
+
+		_, err = sec.NewKey(k, val)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, k := range subSections {
+		subName := k
+		if name != ini.DefaultSection {
+			subName = name + "." + k
+		}
+
+		err = writeSection(f, subName, m[k].(map[string]interface{}))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
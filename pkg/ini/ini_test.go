@@ -0,0 +1,44 @@
+package ini
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshal(t *testing.T) {
+	v, err := Unmarshal([]byte("name = foo\n\n[server]\nport = 8080\n\n[server.tls]\nenabled = true\n"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"name": "foo",
+		"server": map[string]interface{}{
+			"port": "8080",
+		},
+		"server.tls": map[string]interface{}{
+			"enabled": "true",
+		},
+	}, v)
+}
+
+func TestMarshal(t *testing.T) {
+	data, err := Marshal(map[string]interface{}{
+		"name": "foo",
+		"server": map[string]interface{}{
+			"port": 8080,
+			"tls": map[string]interface{}{
+				"enabled": true,
+			},
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "name = foo\n\n[server]\nport = 8080\n\n[server.tls]\nenabled = true\n\n", string(data))
+
+	_, err = Marshal([]interface{}{"foo"})
+	assert.Error(t, err)
+}
@@ -21,7 +21,7 @@ func WriteExecStart(
 		// task name
 		string(k.Name),
 		// commands
-		"[", strings.Join(cmd, " "), "]",
+		"[", MaskSecrets(strings.Join(cmd, " ")), "]",
 	}
 
 	if len(scriptName) != 0 {
@@ -55,7 +55,7 @@ func WriteExecResult(
 	}
 
 	if err != nil {
-		output = append(output, "}:", err.Error())
+		output = append(output, "}:", MaskSecrets(err.Error()))
 	} else {
 		output = append(output, "}")
 	}
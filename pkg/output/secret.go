@@ -0,0 +1,150 @@
+package output
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SecretMask replaces secret values in output
+const SecretMask = "******"
+
+// minSecretSize is the minimum size of secret values to mask, shorter
+// values (e.g. `true`, `1`) would make output unreadable
+const minSecretSize = 4
+
+var secrets struct {
+	mu sync.RWMutex
+
+	// values sorted by length in descending order, so longer secrets
+	// containing shorter ones are masked first
+	values [][]byte
+	set    map[string]struct{}
+}
+
+// AddSecrets registers secret values to be masked in output, multi-line
+// values are also masked line by line
+func AddSecrets(values ...string) {
+	secrets.mu.Lock()
+	defer secrets.mu.Unlock()
+
+	if secrets.set == nil {
+		secrets.set = make(map[string]struct{})
+	}
+
+	add := func(v string) {
+		v = strings.TrimSpace(v)
+		if len(v) < minSecretSize {
+			return
+		}
+
+		if _, ok := secrets.set[v]; ok {
+			return
+		}
+
+		secrets.set[v] = struct{}{}
+		secrets.values = append(secrets.values, []byte(v))
+	}
+
+	for _, v := range values {
+		add(v)
+
+		if strings.Contains(v, "\n") {
+			for _, line := range strings.Split(v, "\n") {
+				add(line)
+			}
+		}
+	}
+
+	sort.SliceStable(secrets.values, func(i, j int) bool {
+		return len(secrets.values[i]) > len(secrets.values[j])
+	})
+}
+
+// MaskSecrets replaces registered secret values in s
+func MaskSecrets(s string) string {
+	secrets.mu.RLock()
+	defer secrets.mu.RUnlock()
+
+	for _, v := range secrets.values {
+		s = strings.ReplaceAll(s, string(v), SecretMask)
+	}
+
+	return s
+}
+
+func maskBytes(data []byte) []byte {
+	for _, v := range secrets.values {
+		data = bytes.ReplaceAll(data, v, []byte(SecretMask))
+	}
+
+	return data
+}
+
+// partialSecretSuffix returns size of the longest suffix of data which is
+// a prefix of some secret value
+func partialSecretSuffix(data []byte) int {
+	ret := 0
+	for _, v := range secrets.values {
+		n := len(v) - 1
+		if n > len(data) {
+			n = len(data)
+		}
+
+		for ; n > ret; n-- {
+			if bytes.HasSuffix(data, v[:n]) {
+				ret = n
+				break
+			}
+		}
+	}
+
+	return ret
+}
+
+// MaskingWriter masks registered secret values written to the underlying
+// writer, data possibly being the beginning of a secret value is held
+// until more data written or Flush called
+type MaskingWriter struct {
+	w io.Writer
+
+	pending []byte
+}
+
+// NewMaskingWriter creates a MaskingWriter writing to w
+func NewMaskingWriter(w io.Writer) *MaskingWriter {
+	return &MaskingWriter{w: w}
+}
+
+func (m *MaskingWriter) Write(p []byte) (int, error) {
+	secrets.mu.RLock()
+	defer secrets.mu.RUnlock()
+
+	if len(secrets.values) == 0 && len(m.pending) == 0 {
+		return m.w.Write(p)
+	}
+
+	data := maskBytes(append(m.pending, p...))
+	hold := partialSecretSuffix(data)
+
+	m.pending = append(m.pending[:0:0], data[len(data)-hold:]...)
+	_, err := m.w.Write(data[:len(data)-hold])
+	if err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Flush writes held data
+func (m *MaskingWriter) Flush() error {
+	if len(m.pending) == 0 {
+		return nil
+	}
+
+	_, err := m.w.Write(m.pending)
+	m.pending = nil
+	return err
+}
@@ -0,0 +1,29 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskingWriter(t *testing.T) {
+	AddSecrets("s3cr3t-value", "abc", "multi\nline-secret\n")
+
+	assert.Equal(t, "token=******, short=abc", MaskSecrets("token=s3cr3t-value, short=abc"))
+
+	buf := &bytes.Buffer{}
+	w := NewMaskingWriter(buf)
+
+	// secret split across writes
+	for _, s := range []string{"token=s3c", "r3t-val", "ue\n", "line-secret", " end s3", "x\n", "tail s3cr"} {
+		n, err := w.Write([]byte(s))
+		assert.NoError(t, err)
+		assert.Equal(t, len(s), n)
+	}
+
+	assert.Equal(t, "token=******\n****** end s3x\ntail ", buf.String())
+
+	assert.NoError(t, w.Flush())
+	assert.Equal(t, "token=******\n****** end s3x\ntail s3cr", buf.String())
+}
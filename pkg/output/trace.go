@@ -0,0 +1,328 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	di "arhat.dev/dukkha/internal"
+	"arhat.dev/dukkha/pkg/cache"
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+// traceValueMaxSize is the max size of input/output value kept in trace
+const traceValueMaxSize = 256
+
+var _ dukkha.RenderingTracer = (*RenderingTracer)(nil)
+
+// NewRenderingTracer creates a RenderingTracer, set it to the rendering
+// context before loading config to trace fields by yaml path
+func NewRenderingTracer() *RenderingTracer {
+	return &RenderingTracer{
+		fields:  make(map[*yaml.Node]*tracedField),
+		pending: make(map[*RenderingStep]*RenderingRecord),
+	}
+}
+
+// RenderingTracer records every renderer invocation, grouped by fields
+// using rendering suffix
+type RenderingTracer struct {
+	mu sync.Mutex
+
+	// value node of fields using rendering suffix in added documents
+	fields map[*yaml.Node]*tracedField
+
+	records []*RenderingRecord
+
+	// steps being rendered, the last one is the innermost
+	active []*RenderingStep
+
+	// last record started in each active step (nil for top level), used to
+	// group renderers in the same rendering chain
+	pending map[*RenderingStep]*RenderingRecord
+}
+
+type tracedField struct {
+	source string
+	path   string
+	chain  string
+
+	// count of renderers to be called in the chain
+	calls int
+}
+
+// RenderingRecord is the rendering history of a single field
+type RenderingRecord struct {
+	// Source is the name of the document containing this field
+	Source string `yaml:"source,omitempty"`
+
+	// Path is the yaml path to this field in Source
+	//
+	// empty when the field is not from any document added to the tracer
+	// (e.g. fields in rendered values)
+	Path string `yaml:"path,omitempty"`
+
+	// Line of this field in Source
+	Line int `yaml:"line,omitempty"`
+
+	// Chain is the rendering suffix of this field
+	Chain string `yaml:"chain,omitempty"`
+
+	Steps []*RenderingStep `yaml:"steps"`
+
+	// count of renderers not called yet in the chain
+	calls int
+}
+
+// RenderingStep is a single renderer invocation
+type RenderingStep struct {
+	// Renderer name with attributes
+	Renderer string `yaml:"renderer"`
+
+	// Input and Output are truncated with secrets masked
+	Input  string `yaml:"input"`
+	Output string `yaml:"output,omitempty"`
+	Error  string `yaml:"error,omitempty"`
+
+	// CacheHits and CacheMisses during this step, including nested rendering
+	CacheHits   uint64 `yaml:"cache_hits,omitempty"`
+	CacheMisses uint64 `yaml:"cache_misses,omitempty"`
+
+	Duration string `yaml:"duration"`
+
+	// Nested rendering done by the renderer (e.g. resolving its input spec)
+	Nested []*RenderingRecord `yaml:"nested,omitempty"`
+}
+
+// AddDocument makes fields using rendering suffix in doc traceable
+// by yaml path
+func (t *RenderingTracer) AddDocument(source string, doc *yaml.Node) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.addNode(source, "", doc)
+}
+
+func (t *RenderingTracer) addNode(source, prefix string, n *yaml.Node) {
+	if n == nil {
+		return
+	}
+
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			t.addNode(source, prefix, c)
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			t.addNode(source, prefix+"["+strconv.FormatInt(int64(i), 10)+"]", c)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+
+			key, chain := k.Value, ""
+			if idx := strings.LastIndexByte(key, '@'); idx != -1 {
+				key, chain = key[:idx], key[idx+1:]
+			} else if tag := strings.TrimPrefix(
+				strings.TrimPrefix(v.Tag, "!rs:"), "!tag:arhat.dev/rs:",
+			); tag != v.Tag {
+				chain = tag
+			}
+
+			path := key
+			if len(prefix) != 0 {
+				path = prefix + "." + key
+			}
+
+			if len(chain) != 0 {
+				t.fields[v] = &tracedField{
+					source: source,
+					path:   path,
+					chain:  chain,
+					calls:  countRendererCalls(chain),
+				}
+			}
+
+			t.addNode(source, path, v)
+		}
+	}
+}
+
+// countRendererCalls counts renderers called for rendering suffix, type
+// hint and patch spec (e.g. `?str`, `!`) are not renderers
+func countRendererCalls(chain string) int {
+	ret := 0
+	for _, part := range strings.Split(chain, "|") {
+		part = strings.TrimSuffix(part, "!")
+		if idx := strings.LastIndexByte(part, '?'); idx != -1 {
+			part = part[:idx]
+		}
+
+		if len(part) != 0 {
+			ret++
+		}
+	}
+
+	return ret
+}
+
+// TraceRendering implements dukkha.RenderingTracer
+func (t *RenderingTracer) TraceRendering(renderer string, rawData interface{}) func(result []byte, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var parent *RenderingStep
+	if n := len(t.active); n != 0 {
+		parent = t.active[n-1]
+	}
+
+	node, _ := rawData.(*yaml.Node)
+	rec := t.pending[parent]
+	if f, ok := t.fields[node]; ok {
+		rec = &RenderingRecord{
+			Source: f.source,
+			Path:   f.path,
+			Line:   node.Line,
+			Chain:  f.chain,
+			calls:  f.calls,
+		}
+		t.addRecord(parent, rec)
+	} else if rec == nil || rec.calls <= 0 {
+		// not the next renderer in a chain, and not from any document
+		rec = &RenderingRecord{calls: 1}
+		t.addRecord(parent, rec)
+	}
+
+	rec.calls--
+	t.pending[parent] = rec
+
+	step := &RenderingStep{
+		Renderer: renderer,
+		Input:    formatTraceValue(rawData),
+	}
+	rec.Steps = append(rec.Steps, step)
+	t.active = append(t.active, step)
+
+	hits, misses := cache.Stats()
+	start := time.Now()
+
+	return func(result []byte, err error) {
+		duration := time.Since(start)
+		currentHits, currentMisses := cache.Stats()
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		step.Duration = duration.String()
+		step.CacheHits = currentHits - hits
+		step.CacheMisses = currentMisses - misses
+		if err != nil {
+			step.Error = MaskSecrets(err.Error())
+		} else {
+			step.Output = formatTraceValue(result)
+		}
+
+		for i := len(t.active) - 1; i >= 0; i-- {
+			if t.active[i] == step {
+				t.active = append(t.active[:i], t.active[i+1:]...)
+				break
+			}
+		}
+
+		delete(t.pending, step)
+	}
+}
+
+func (t *RenderingTracer) addRecord(parent *RenderingStep, rec *RenderingRecord) {
+	if parent == nil {
+		t.records = append(t.records, rec)
+	} else {
+		parent.Nested = append(parent.Nested, rec)
+	}
+}
+
+// Records returns all rendering records in the order of rendering
+func (t *RenderingTracer) Records() []*RenderingRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]*RenderingRecord(nil), t.records...)
+}
+
+// Write writes all rendering records as yaml to w
+func (t *RenderingTracer) Write(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	err := enc.Encode(t.Records())
+	if err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// formatTraceValue formats v as string, with secrets masked and
+// truncated to traceValueMaxSize
+func formatTraceValue(v interface{}) string {
+	var s string
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		s = t
+	case []byte:
+		s = string(t)
+	default:
+		if n, ok := t.(*yaml.Node); ok && n.Kind == yaml.ScalarNode {
+			s = n.Value
+			break
+		}
+
+		data, err := yaml.Marshal(t)
+		if err != nil {
+			s = fmt.Sprint(t)
+		} else {
+			s = strings.TrimSuffix(string(data), "\n")
+		}
+	}
+
+	s = MaskSecrets(s)
+	if len(s) <= traceValueMaxSize {
+		return s
+	}
+
+	n := traceValueMaxSize
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n] + "... (" + strconv.FormatInt(int64(len(s)-n), 10) + " bytes truncated)"
+}
+
+// GetRenderingTracer returns the RenderingTracer set to rc, nil if rendering
+// is not traced
+func GetRenderingTracer(rc dukkha.RenderingContext) *RenderingTracer {
+	g, ok := rc.(di.RenderingTracerGetter)
+	if !ok {
+		return nil
+	}
+
+	t, _ := g.RenderingTracer().(*RenderingTracer)
+	return t
+}
+
+// TraceDocument makes fields in doc traceable by yaml path when rendering is
+// traced in rc
+func TraceDocument(rc dukkha.RenderingContext, source string, doc *yaml.Node) {
+	if t := GetRenderingTracer(rc); t != nil {
+		t.AddDocument(source, doc)
+	}
+}
@@ -0,0 +1,98 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRenderingTracer(t *testing.T) {
+	AddSecrets("trace-secret-value")
+
+	doc := new(yaml.Node)
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+foo:
+  bar@http|T?str|tpl: https://example.com
+  list:
+  - item: !rs:env ""
+  - plain: value
+`), doc))
+
+	tracer := NewRenderingTracer()
+	tracer.AddDocument("test.yaml", doc)
+
+	bar := doc.Content[0].Content[1].Content[1]
+	item := doc.Content[0].Content[1].Content[3].Content[0].Content[1]
+
+	// chain of 3 renderers, the second one renders a nested field
+	done := tracer.TraceRendering("http", bar)
+	done([]byte("trace-secret-value"), nil)
+
+	done = tracer.TraceRendering("T#use-spec", &yaml.Node{Kind: yaml.ScalarNode, Value: "generated"})
+	nestedDone := tracer.TraceRendering("echo", &yaml.Node{Kind: yaml.ScalarNode, Value: "nested"})
+	nestedDone([]byte("nested"), nil)
+	done([]byte(strings.Repeat("a", traceValueMaxSize+1)), nil)
+
+	done = tracer.TraceRendering("tpl", &yaml.Node{Kind: yaml.ScalarNode, Value: "b"})
+	done(nil, assert.AnError)
+
+	// field in rendered value
+	done = tracer.TraceRendering("echo", &yaml.Node{Kind: yaml.ScalarNode, Value: "x"})
+	done([]byte("x"), nil)
+
+	done = tracer.TraceRendering("env", item)
+	done([]byte("y"), nil)
+
+	records := tracer.Records()
+	if !assert.Len(t, records, 3) {
+		return
+	}
+
+	rec := records[0]
+	assert.Equal(t, "test.yaml", rec.Source)
+	assert.Equal(t, "foo.bar", rec.Path)
+	assert.Equal(t, 3, rec.Line)
+	assert.Equal(t, "http|T?str|tpl", rec.Chain)
+	if assert.Len(t, rec.Steps, 3) {
+		assert.Equal(t, "https://example.com", rec.Steps[0].Input)
+		assert.Equal(t, SecretMask, rec.Steps[0].Output)
+
+		assert.Equal(t, "T#use-spec", rec.Steps[1].Renderer)
+		assert.True(t, strings.HasSuffix(rec.Steps[1].Output, "... (1 bytes truncated)"))
+		if assert.Len(t, rec.Steps[1].Nested, 1) {
+			assert.Empty(t, rec.Steps[1].Nested[0].Path)
+			assert.Equal(t, "nested", rec.Steps[1].Nested[0].Steps[0].Output)
+		}
+
+		assert.Equal(t, assert.AnError.Error(), rec.Steps[2].Error)
+	}
+
+	assert.Empty(t, records[1].Path)
+	assert.Len(t, records[1].Steps, 1)
+
+	assert.Equal(t, "foo.list[0].item", records[2].Path)
+	assert.Equal(t, "env", records[2].Chain)
+
+	buf := &strings.Builder{}
+	assert.NoError(t, tracer.Write(buf))
+	assert.NotContains(t, buf.String(), "trace-secret-value")
+}
+
+func TestCountRendererCalls(t *testing.T) {
+	for _, test := range []struct {
+		chain    string
+		expected int
+	}{
+		{"tpl", 1},
+		{"http|T|tpl", 3},
+		{"file?str", 1},
+		{"!", 0},
+		{"tpl!", 1},
+		{"?str", 0},
+		{"http#cached-file|tpl?str!", 2},
+	} {
+		assert.Equal(t, test.expected, countRendererCalls(test.chain), test.chain)
+	}
+}
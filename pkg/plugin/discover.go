@@ -0,0 +1,87 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Discover finds plugin executables named `dukkha-plugin-*` in dirs
+//
+// when there are plugins with the same name, the one in the dir appeared
+// first is used (same as PATH lookup)
+func Discover(dirs []string) ([]*Plugin, error) {
+	var (
+		ret     []*Plugin
+		visited = make(map[string]struct{})
+	)
+
+	for _, dir := range dirs {
+		if len(dir) == 0 {
+			continue
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+				continue
+			}
+
+			return nil, fmt.Errorf("list plugins in dir %q: %w", dir, err)
+		}
+
+		for _, ent := range entries {
+			name, ok := pluginName(ent.Name())
+			if !ok {
+				continue
+			}
+
+			if _, ok = visited[name]; ok {
+				continue
+			}
+
+			file := filepath.Join(dir, ent.Name())
+			info, err := os.Stat(file)
+			if err != nil || !isExecutable(info) {
+				continue
+			}
+
+			visited[name] = struct{}{}
+			ret = append(ret, &Plugin{
+				Name: name,
+				Path: file,
+			})
+		}
+	}
+
+	return ret, nil
+}
+
+func pluginName(filename string) (string, bool) {
+	if runtime.GOOS == "windows" {
+		filename = strings.TrimSuffix(filename, ".exe")
+	}
+
+	name := strings.TrimPrefix(filename, ExecutablePrefix)
+	if name == filename || len(name) == 0 {
+		return "", false
+	}
+
+	return name, true
+}
+
+func isExecutable(info fs.FileInfo) bool {
+	if !info.Mode().IsRegular() {
+		return false
+	}
+
+	if runtime.GOOS == "windows" {
+		return true
+	}
+
+	return info.Mode().Perm()&0111 != 0
+}
@@ -0,0 +1,175 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	"go.uber.org/multierr"
+)
+
+// running plugins, stopped by CloseAll
+var running = struct {
+	mu      sync.Mutex
+	plugins map[*Plugin]struct{}
+}{plugins: make(map[*Plugin]struct{})}
+
+// CloseAll stops all running plugin processes, it SHOULD be called before
+// dukkha exits
+func CloseAll() error {
+	running.mu.Lock()
+	plugins := make([]*Plugin, 0, len(running.plugins))
+	for p := range running.plugins {
+		plugins = append(plugins, p)
+	}
+	running.mu.Unlock()
+
+	var err error
+	for _, p := range plugins {
+		err = multierr.Append(err, p.Close())
+	}
+
+	return err
+}
+
+// Plugin is an external plugin executable
+//
+// the plugin process is started on first call and kept running until Close
+type Plugin struct {
+	// Name of the plugin, without ExecutablePrefix
+	Name string
+
+	// Path to the plugin executable
+	Path string
+
+	mu sync.Mutex
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	enc    *json.Encoder
+	dec    *json.Decoder
+	nextID uint64
+}
+
+// Call method with params, and decode result into result
+//
+// calls are serialized
+func (p *Plugin) Call(method string, params, result interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cmd == nil {
+		err := p.start()
+		if err != nil {
+			return fmt.Errorf("plugin %q: start: %w", p.Name, err)
+		}
+	}
+
+	paramsBytes, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("plugin %q: marshal params of %q: %w", p.Name, method, err)
+	}
+
+	p.nextID++
+	req := &request{
+		JSONRPC: "2.0",
+		ID:      p.nextID,
+		Method:  method,
+		Params:  paramsBytes,
+	}
+
+	err = p.enc.Encode(req)
+	if err != nil {
+		p.stop()
+		return fmt.Errorf("plugin %q: send request %q: %w", p.Name, method, err)
+	}
+
+	resp := &response{}
+	err = p.dec.Decode(resp)
+	if err != nil {
+		p.stop()
+		return fmt.Errorf("plugin %q: read response of %q: %w", p.Name, method, err)
+	}
+
+	if resp.ID != req.ID {
+		p.stop()
+		return fmt.Errorf("plugin %q: unexpected response id %d, want %d", p.Name, resp.ID, req.ID)
+	}
+
+	if resp.Error != nil {
+		return fmt.Errorf("plugin %q: %s: %w", p.Name, method, resp.Error)
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	err = json.Unmarshal(resp.Result, result)
+	if err != nil {
+		return fmt.Errorf("plugin %q: unmarshal result of %q: %w", p.Name, method, err)
+	}
+
+	return nil
+}
+
+// Close stops the plugin process
+func (p *Plugin) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.stop()
+}
+
+func (p *Plugin) start() error {
+	cmd := exec.Command(p.Path)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		_ = stdin.Close()
+		return err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
+
+	p.cmd = cmd
+	p.stdin = stdin
+	p.enc = json.NewEncoder(stdin)
+	p.dec = json.NewDecoder(bufio.NewReader(stdout))
+
+	running.mu.Lock()
+	running.plugins[p] = struct{}{}
+	running.mu.Unlock()
+
+	return nil
+}
+
+func (p *Plugin) stop() error {
+	if p.cmd == nil {
+		return nil
+	}
+
+	// plugin is expected to exit when stdin closed
+	_ = p.stdin.Close()
+	err := p.cmd.Wait()
+
+	p.cmd, p.stdin, p.enc, p.dec = nil, nil, nil, nil
+
+	running.mu.Lock()
+	delete(running.plugins, p)
+	running.mu.Unlock()
+
+	return err
+}
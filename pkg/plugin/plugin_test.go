@@ -0,0 +1,174 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"arhat.dev/pkg/fshelper"
+	"arhat.dev/rs"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	di "arhat.dev/dukkha/internal"
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+	dukkha_test "arhat.dev/dukkha/pkg/dukkha/test"
+)
+
+const envRunAsTestPlugin = "DUKKHA_TEST_RUN_AS_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(envRunAsTestPlugin) == "1" {
+		err := Serve(os.Stdin, os.Stdout, &testHandler{})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+// testHandler is a stand-in plugin
+type testHandler struct{}
+
+func (h *testHandler) Initialize(params *InitializeParams) (*InitializeResult, error) {
+	return &InitializeResult{
+		Renderers: []string{"test-plugin-upper"},
+		Tools: []ToolInfo{{
+			Kind:       "test-plugin-tool",
+			Executable: "foo",
+			Tasks:      []string{"greet"},
+		}},
+	}, nil
+}
+
+func (h *testHandler) Render(params *RenderParams) (*RenderResult, error) {
+	data, ok := params.Data.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected non string data")
+	}
+
+	return &RenderResult{
+		Data: strings.ToUpper(data) + params.Env["TEST_PLUGIN_SUFFIX"],
+	}, nil
+}
+
+func (h *testHandler) GetExecSpecs(params *GetExecSpecsParams) (*GetExecSpecsResult, error) {
+	return &GetExecSpecsResult{
+		Specs: []ExecSpec{{
+			Command: []string{constant.DUKKHA_TOOL_CMD, "greet", fmt.Sprint(params.Fields["who"])},
+			EnvSuggest: map[string]string{
+				"TASK": params.TaskName,
+			},
+		}},
+	}, nil
+}
+
+func createTestPlugin(t *testing.T) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugin not supported on windows")
+	}
+
+	exe, err := os.Executable()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	dir := t.TempDir()
+	script := fmt.Sprintf("#!/bin/sh\n%s=1 exec %q \"$@\"\n", envRunAsTestPlugin, exe)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ExecutablePrefix+"test"), []byte(script), 0755))
+	// not executable, should be ignored
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ExecutablePrefix+"noexec"), []byte(script), 0644))
+	// not a plugin
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "dukkha-other"), []byte(script), 0755))
+
+	return dir
+}
+
+func TestPlugin(t *testing.T) {
+	dir := createTestPlugin(t)
+
+	plugins, err := Discover([]string{filepath.Join(dir, "missing"), dir, dir})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	if !assert.Len(t, plugins, 1) {
+		return
+	}
+	defer func() { assert.NoError(t, CloseAll()) }()
+
+	assert.Equal(t, "test", plugins[0].Name)
+
+	dukkha.SetTypeResolver(NewResolver(plugins))
+	defer dukkha.SetTypeResolver(nil)
+
+	t.Run("Lazy", func(t *testing.T) {
+		assert.Nil(t, plugins[0].cmd, "should not start plugin before referenced")
+
+		_, err := dukkha.GlobalInterfaceTypeHandler.Create(toolType, "test-plugin-tool")
+		assert.NoError(t, err)
+		assert.NotNil(t, plugins[0].cmd)
+
+		_, err = dukkha.GlobalInterfaceTypeHandler.Create(toolType, "test-plugin-missing")
+		assert.Error(t, err)
+
+		assert.NoError(t, CloseAll())
+		assert.Nil(t, plugins[0].cmd)
+	})
+
+	t.Run("Renderer", func(t *testing.T) {
+		rc := dukkha_test.NewTestContext(context.TODO())
+		rc.AddEnv(true, &dukkha.EnvEntry{Name: "TEST_PLUGIN_SUFFIX", Value: "!"})
+
+		r, err := dukkha.GlobalInterfaceTypeHandler.Create(rendererType, "test-plugin-upper")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		d := rs.Init(r.(rs.Field), nil).(dukkha.Renderer)
+		assert.NoError(t, d.Init(nil))
+
+		ret, err := d.RenderYaml(rc, "hello", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "HELLO!", string(ret))
+	})
+
+	t.Run("Task", func(t *testing.T) {
+		rc := dukkha_test.NewTestContext(context.TODO())
+		rc.(di.CacheDirSetter).SetCacheDir(t.TempDir())
+
+		tsk, err := dukkha.GlobalInterfaceTypeHandler.Create(taskType, "test-plugin-tool:greet")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		task := rs.Init(tsk.(rs.Field), nil).(*Task)
+		task.TaskName = "foo"
+		who := rs.Init(&rs.AnyObject{}, nil).(*rs.AnyObject)
+		assert.NoError(t, yaml.Unmarshal([]byte("world"), who))
+		task.Fields = map[string]*rs.AnyObject{"who": who}
+		assert.NoError(t, task.Init(fshelper.NewOSFS(false, func() (string, error) {
+			return t.TempDir(), nil
+		})))
+
+		specs, err := task.GetExecSpecs(rc, dukkha_test.CreateTaskMatrixExecOptions())
+		assert.NoError(t, err)
+		assert.EqualValues(t, []dukkha.TaskExecSpec{{
+			Command:    []string{constant.DUKKHA_TOOL_CMD, "greet", "world"},
+			EnvSuggest: dukkha.Env{{Name: "TASK", Value: "foo"}},
+		}}, specs)
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		assert.Error(t, Register(plugins), "should not register twice")
+	})
+}
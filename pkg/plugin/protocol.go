@@ -0,0 +1,156 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ExecutablePrefix is the filename prefix of plugin executables
+const ExecutablePrefix = "dukkha-plugin-"
+
+// ProtocolVersion of the plugin protocol
+const ProtocolVersion = "v1"
+
+// methods in plugin protocol
+const (
+	// MethodInitialize is the first call to a plugin, to query supported
+	// renderers, tools and tasks
+	MethodInitialize = "initialize"
+
+	// MethodRender is called when a renderer provided by the plugin is used
+	MethodRender = "render"
+
+	// MethodGetExecSpecs is called when running a task provided by the plugin
+	MethodGetExecSpecs = "getExecSpecs"
+)
+
+// Messages are exchanged as JSON-RPC 2.0 objects, one per line,
+// dukkha writes requests to plugin's stdin and reads responses from its stdout,
+// plugin's stderr is forwarded to dukkha's stderr
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      uint64          `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      uint64          `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is the JSON-RPC error object
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("plugin error %d: %s", e.Code, e.Message)
+}
+
+// JSON-RPC 2.0 error codes
+const (
+	ErrCodeParse          = -32700
+	ErrCodeMethodNotFound = -32601
+	ErrCodeInvalidParams  = -32602
+	ErrCodeInternal       = -32603
+)
+
+// InitializeParams is the params of MethodInitialize
+type InitializeParams struct {
+	// Version of the plugin protocol
+	Version string `json:"version"`
+}
+
+// InitializeResult is the result of MethodInitialize
+type InitializeResult struct {
+	// Renderers names provided by the plugin
+	Renderers []string `json:"renderers,omitempty"`
+
+	// Tools provided by the plugin
+	Tools []ToolInfo `json:"tools,omitempty"`
+}
+
+// ToolInfo describes a tool kind provided by the plugin
+type ToolInfo struct {
+	// Kind of the tool
+	Kind string `json:"kind"`
+
+	// Executable is the default executable of the tool
+	Executable string `json:"executable,omitempty"`
+
+	// Tasks are task kinds supported by this tool
+	Tasks []string `json:"tasks,omitempty"`
+}
+
+// RenderParams is the params of MethodRender
+type RenderParams struct {
+	// Renderer name (without attributes)
+	Renderer string `json:"renderer"`
+
+	// Config of the renderer, as set in `renderers` section
+	Config map[string]interface{} `json:"config,omitempty"`
+
+	// Data is the resolved raw data to render
+	Data interface{} `json:"data"`
+
+	// Attributes are renderer attributes
+	Attributes []string `json:"attributes,omitempty"`
+
+	// Env are all environment variables available
+	Env map[string]string `json:"env,omitempty"`
+
+	// Values are global values
+	Values map[string]interface{} `json:"values,omitempty"`
+}
+
+// RenderResult is the result of MethodRender
+type RenderResult struct {
+	// Data is the rendered yaml (or plain text) data
+	Data string `json:"data"`
+}
+
+// GetExecSpecsParams is the params of MethodGetExecSpecs
+type GetExecSpecsParams struct {
+	ToolKind string `json:"tool_kind"`
+	ToolName string `json:"tool_name"`
+	TaskKind string `json:"task_kind"`
+	TaskName string `json:"task_name"`
+
+	// Fields of the task (excluding common task fields), resolved
+	Fields map[string]interface{} `json:"fields,omitempty"`
+
+	// Env are all environment variables available, including task and matrix env
+	Env map[string]string `json:"env,omitempty"`
+
+	// Values are global values
+	Values map[string]interface{} `json:"values,omitempty"`
+}
+
+// GetExecSpecsResult is the result of MethodGetExecSpecs
+type GetExecSpecsResult struct {
+	Specs []ExecSpec `json:"specs"`
+}
+
+// ExecSpec is the json form of dukkha.TaskExecSpec
+type ExecSpec struct {
+	// Command to run, use `DUKKHA_TOOL_CMD` as the first element
+	// to reference the tool executable
+	Command []string `json:"command"`
+
+	Chdir string `json:"chdir,omitempty"`
+
+	EnvSuggest  map[string]string `json:"env_suggest,omitempty"`
+	EnvOverride map[string]string `json:"env_override,omitempty"`
+
+	Stdin string `json:"stdin,omitempty"`
+
+	IgnoreError bool `json:"ignore_error,omitempty"`
+
+	UseShell  bool   `json:"use_shell,omitempty"`
+	ShellName string `json:"shell_name,omitempty"`
+}
@@ -0,0 +1,96 @@
+package plugin
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+var (
+	rendererType = reflect.TypeOf((*dukkha.Renderer)(nil)).Elem()
+	toolType     = reflect.TypeOf((*dukkha.Tool)(nil)).Elem()
+	taskType     = reflect.TypeOf((*dukkha.Task)(nil)).Elem()
+)
+
+// Register initializes plugins and registers renderers, tools and tasks
+// provided by them
+//
+// renderers, tools and tasks already registered (e.g. built-in ones or
+// provided by other plugins) cannot be overridden
+func Register(plugins []*Plugin) error {
+	for _, p := range plugins {
+		err := initializeAndRegister(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func initializeAndRegister(p *Plugin) error {
+	info := &InitializeResult{}
+	err := p.Call(MethodInitialize, &InitializeParams{
+		Version: ProtocolVersion,
+	}, info)
+	if err != nil {
+		return err
+	}
+
+	err = register(p, info)
+	if err != nil {
+		return fmt.Errorf("plugin %q: %w", p.Name, err)
+	}
+
+	return nil
+}
+
+func register(p *Plugin, info *InitializeResult) error {
+	for _, name := range info.Renderers {
+		if strings.Contains(name, ":") || strings.Contains(name, "#") {
+			return fmt.Errorf("invalid renderer name %q containing `:` or `#`", name)
+		}
+
+		if dukkha.IsRegistered(rendererType, name) {
+			return fmt.Errorf("renderer %q already registered", name)
+		}
+
+		dukkha.RegisterRenderer(name, func(name string) dukkha.Renderer {
+			return &Renderer{name: name, plugin: p}
+		})
+	}
+
+	for i := range info.Tools {
+		toolInfo := &info.Tools[i]
+
+		toolKind := dukkha.ToolKind(toolInfo.Kind)
+		if len(toolKind) == 0 || strings.Contains(toolInfo.Kind, ":") {
+			return fmt.Errorf("invalid tool kind %q", toolKind)
+		}
+
+		if dukkha.IsRegistered(toolType, toolInfo.Kind) {
+			return fmt.Errorf("tool kind %q already registered", toolKind)
+		}
+
+		dukkha.RegisterTool(toolKind, func() dukkha.Tool {
+			return &Tool{info: toolInfo}
+		})
+
+		for _, tk := range toolInfo.Tasks {
+			taskKind := dukkha.TaskKind(tk)
+			if len(taskKind) == 0 || strings.Contains(tk, ":") {
+				return fmt.Errorf("invalid task kind %q of tool %q", tk, toolKind)
+			}
+
+			dukkha.RegisterTask(toolKind, taskKind, func(toolName string) dukkha.Task {
+				t := &Task{toolKind: toolKind, taskKind: taskKind, plugin: p}
+				t.InitBaseTask(toolKind, dukkha.ToolName(toolName), t)
+				return t
+			})
+		}
+	}
+
+	return nil
+}
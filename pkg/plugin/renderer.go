@@ -0,0 +1,88 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/renderer"
+	"arhat.dev/dukkha/pkg/utils"
+)
+
+var _ dukkha.Renderer = (*Renderer)(nil)
+
+// Renderer is a renderer backed by plugin
+type Renderer struct {
+	rs.BaseField `yaml:"-"`
+
+	renderer.BaseRenderer `yaml:",inline"`
+
+	// Config is passed to the plugin as is
+	Config rs.AnyObjectMap `yaml:"config"`
+
+	name   string
+	plugin *Plugin
+}
+
+func (d *Renderer) RenderYaml(
+	rc dukkha.RenderingContext, rawData interface{}, attributes []dukkha.RendererAttribute,
+) ([]byte, error) {
+	rawData, err := rs.NormalizeRawData(rawData)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, ok := rawData.([]byte); ok {
+		rawData = string(data)
+	}
+
+	var attrs []string
+	for _, attr := range d.Attributes(attributes) {
+		attrs = append(attrs, string(attr))
+	}
+
+	result := &RenderResult{}
+	err = d.plugin.Call(MethodRender, &RenderParams{
+		Renderer:   d.name,
+		Config:     d.Config.NormalizedValue(),
+		Data:       rawData,
+		Attributes: attrs,
+		Env:        envMap(rc.Env()),
+		Values:     rc.Values(),
+	}, result)
+	if err != nil {
+		return nil, fmt.Errorf("renderer.%s: %w", d.name, err)
+	}
+
+	return []byte(result.Data), nil
+}
+
+func envMap(env map[string]utils.LazyValue) map[string]string {
+	ret := make(map[string]string, len(env))
+	for k, v := range env {
+		ret[k] = v.Get()
+	}
+
+	return ret
+}
+
+func toEnv(m map[string]string) dukkha.Env {
+	if len(m) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	ret := make(dukkha.Env, 0, len(names))
+	for _, k := range names {
+		ret = append(ret, &dukkha.EnvEntry{Name: k, Value: m[k]})
+	}
+
+	return ret
+}
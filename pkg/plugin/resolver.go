@@ -0,0 +1,65 @@
+package plugin
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+var _ dukkha.TypeResolver = (*Resolver)(nil)
+
+// Resolver starts plugins on demand and registers renderers, tools and tasks
+// provided by them, so plugins are only started when config references
+// renderers or tool kinds not registered
+type Resolver struct {
+	mu sync.Mutex
+
+	// plugins not started yet
+	pending []*Plugin
+}
+
+// NewResolver creates a Resolver for discovered plugins
+func NewResolver(plugins []*Plugin) *Resolver {
+	return &Resolver{pending: append([]*Plugin(nil), plugins...)}
+}
+
+// Resolve implements dukkha.TypeResolver
+//
+// the plugin named after the renderer or tool kind in yamlKey is started
+// first, other plugins are started in order until one of them provides it
+func (r *Resolver) Resolve(typ reflect.Type, yamlKey string) (bool, error) {
+	switch typ {
+	case rendererType, toolType, taskType:
+	default:
+		return false, nil
+	}
+
+	// <renderer-name>{:<suffix>}, <tool-kind>, <tool-kind>{:<tool-name>}:<task-kind>
+	name := strings.SplitN(yamlKey, ":", 2)[0]
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sort.SliceStable(r.pending, func(i, j int) bool {
+		return r.pending[i].Name == name && r.pending[j].Name != name
+	})
+
+	for len(r.pending) != 0 {
+		p := r.pending[0]
+		r.pending = r.pending[1:]
+
+		err := initializeAndRegister(p)
+		if err != nil {
+			return false, err
+		}
+
+		if dukkha.IsRegistered(typ, yamlKey) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
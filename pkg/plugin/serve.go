@@ -0,0 +1,109 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// Handler implements plugin methods, used with Serve to write plugins in golang
+type Handler interface {
+	Initialize(params *InitializeParams) (*InitializeResult, error)
+
+	Render(params *RenderParams) (*RenderResult, error)
+
+	GetExecSpecs(params *GetExecSpecsParams) (*GetExecSpecsResult, error)
+}
+
+// Serve plugin requests from r and write responses to w until r is closed
+func Serve(r io.Reader, w io.Writer, h Handler) error {
+	var (
+		dec = json.NewDecoder(bufio.NewReader(r))
+		enc = json.NewEncoder(w)
+	)
+
+	for {
+		req := &request{}
+		err := dec.Decode(req)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			_ = enc.Encode(&response{
+				JSONRPC: "2.0",
+				Error:   &Error{Code: ErrCodeParse, Message: err.Error()},
+			})
+
+			return err
+		}
+
+		err = enc.Encode(handle(h, req))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func handle(h Handler, req *request) *response {
+	var (
+		result interface{}
+		err    error
+	)
+
+	switch req.Method {
+	case MethodInitialize:
+		params := &InitializeParams{}
+		if err = unmarshalParams(req.Params, params); err == nil {
+			result, err = h.Initialize(params)
+		}
+	case MethodRender:
+		params := &RenderParams{}
+		if err = unmarshalParams(req.Params, params); err == nil {
+			result, err = h.Render(params)
+		}
+	case MethodGetExecSpecs:
+		params := &GetExecSpecsParams{}
+		if err = unmarshalParams(req.Params, params); err == nil {
+			result, err = h.GetExecSpecs(params)
+		}
+	default:
+		err = &Error{Code: ErrCodeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+
+	resp := &response{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+	}
+
+	if err != nil {
+		rpcErr := &Error{}
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: ErrCodeInternal, Message: err.Error()}
+		}
+
+		resp.Error = rpcErr
+		return resp
+	}
+
+	resp.Result, err = json.Marshal(result)
+	if err != nil {
+		resp.Error = &Error{Code: ErrCodeInternal, Message: err.Error()}
+	}
+
+	return resp
+}
+
+func unmarshalParams(data json.RawMessage, out interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	err := json.Unmarshal(data, out)
+	if err != nil {
+		return &Error{Code: ErrCodeInvalidParams, Message: err.Error()}
+	}
+
+	return nil
+}
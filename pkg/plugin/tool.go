@@ -0,0 +1,118 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"arhat.dev/pkg/fshelper"
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/tools"
+)
+
+var (
+	_ dukkha.Tool = (*Tool)(nil)
+	_ dukkha.Task = (*Task)(nil)
+)
+
+// Tool is a tool of kind provided by plugin
+type Tool struct {
+	rs.BaseField `yaml:"-"`
+
+	ToolName dukkha.ToolName `yaml:"name"`
+
+	tools.BaseTool `yaml:",inline"`
+
+	info *ToolInfo
+}
+
+func (t *Tool) Init(cacheFS *fshelper.OSFS) error {
+	executable := t.info.Executable
+	if len(executable) == 0 {
+		executable = t.info.Kind
+	}
+
+	return t.InitBaseTool(executable, cacheFS, t)
+}
+
+func (t *Tool) Name() dukkha.ToolName { return t.ToolName }
+func (t *Tool) Kind() dukkha.ToolKind { return dukkha.ToolKind(t.info.Kind) }
+func (t *Tool) Key() dukkha.ToolKey {
+	return dukkha.ToolKey{Kind: t.Kind(), Name: t.Name()}
+}
+
+// Task is a task of kind provided by plugin
+type Task struct {
+	rs.BaseField `yaml:"-"`
+
+	TaskName string `yaml:"name"`
+
+	tools.BaseTask `yaml:",inline"`
+
+	// Fields are passed to the plugin as is
+	Fields map[string]*rs.AnyObject `yaml:",inline"`
+
+	toolKind dukkha.ToolKind
+	taskKind dukkha.TaskKind
+
+	plugin *Plugin
+}
+
+func (c *Task) Kind() dukkha.TaskKind { return c.taskKind }
+func (c *Task) Name() dukkha.TaskName { return dukkha.TaskName(c.TaskName) }
+func (c *Task) Key() dukkha.TaskKey {
+	return dukkha.TaskKey{Kind: c.Kind(), Name: c.Name()}
+}
+
+func (c *Task) GetExecSpecs(
+	rc dukkha.TaskExecContext, options dukkha.TaskMatrixExecOptions,
+) ([]dukkha.TaskExecSpec, error) {
+	var steps []dukkha.TaskExecSpec
+	err := c.DoAfterFieldsResolved(rc, -1, true, func() error {
+		fields := make(map[string]interface{}, len(c.Fields))
+		for k, v := range c.Fields {
+			fields[k] = v.NormalizedValue()
+		}
+
+		result := &GetExecSpecsResult{}
+		err := c.plugin.Call(MethodGetExecSpecs, &GetExecSpecsParams{
+			ToolKind: string(c.toolKind),
+			ToolName: string(c.ToolName()),
+			TaskKind: string(c.taskKind),
+			TaskName: c.TaskName,
+			Fields:   fields,
+			Env:      envMap(rc.Env()),
+			Values:   rc.Values(),
+		}, result)
+		if err != nil {
+			return err
+		}
+
+		for i, s := range result.Specs {
+			if len(s.Command) == 0 {
+				return fmt.Errorf("invalid exec spec #%d: empty command", i)
+			}
+
+			step := dukkha.TaskExecSpec{
+				Chdir:       s.Chdir,
+				EnvSuggest:  toEnv(s.EnvSuggest),
+				EnvOverride: toEnv(s.EnvOverride),
+				Command:     s.Command,
+				IgnoreError: s.IgnoreError,
+				UseShell:    s.UseShell,
+				ShellName:   s.ShellName,
+			}
+
+			if len(s.Stdin) != 0 {
+				step.Stdin = strings.NewReader(s.Stdin)
+			}
+
+			steps = append(steps, step)
+		}
+
+		return nil
+	})
+
+	return steps, err
+}
@@ -0,0 +1,135 @@
+package af
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	arMagic     = "!<arch>\n"
+	arHeaderLen = 60
+)
+
+// sectionSource is an archiveSource of part of the parent archive
+type sectionSource struct {
+	*io.SectionReader
+}
+
+func (s *sectionSource) Close() error { return nil }
+
+// walkAr walks through unix ar archive, for debian packages, files in the
+// data.tar payload are walked instead of ar members
+func walkAr(src io.ReaderAt, password string, fn walkFunc) error {
+	var (
+		off       = int64(len(arMagic))
+		longNames []byte
+		isDeb     bool
+	)
+
+	for i := 0; ; i++ {
+		hdr := make([]byte, arHeaderLen)
+		n, err := src.ReadAt(hdr, off)
+		if n == 0 && err == io.EOF {
+			return nil
+		}
+
+		if n != arHeaderLen || string(hdr[58:60]) != "`\n" {
+			return fmt.Errorf("ar: invalid header at offset %d", off)
+		}
+
+		size, err := strconv.ParseInt(strings.TrimSpace(string(hdr[48:58])), 10, 64)
+		if err != nil || size < 0 {
+			return fmt.Errorf("ar: invalid size of member at offset %d", off)
+		}
+
+		dataOff := off + arHeaderLen
+		name := strings.TrimSpace(string(hdr[:16]))
+
+		// member data is aligned to 2 bytes
+		off = dataOff + size + size%2
+
+		switch {
+		case name == "//":
+			// gnu long names table
+			longNames = make([]byte, size)
+			_, err = src.ReadAt(longNames, dataOff)
+			if err != nil {
+				return fmt.Errorf("ar: reading long names: %w", err)
+			}
+
+			continue
+		case name == "/", name == "/SYM64/", name == "__.SYMDEF", name == "__.SYMDEF SORTED":
+			// symbol table
+			continue
+		case strings.HasPrefix(name, "#1/"):
+			// bsd long name stored at the beginning of member data
+			nameLen, err2 := strconv.ParseInt(name[3:], 10, 64)
+			if err2 != nil || nameLen > size {
+				return fmt.Errorf("ar: invalid bsd long name %q", name)
+			}
+
+			buf := make([]byte, nameLen)
+			_, err = src.ReadAt(buf, dataOff)
+			if err != nil {
+				return fmt.Errorf("ar: reading bsd long name: %w", err)
+			}
+
+			name = strings.TrimRight(string(buf), "\x00")
+			dataOff += nameLen
+			size -= nameLen
+		case strings.HasPrefix(name, "/") && len(longNames) != 0:
+			// gnu long name: offset in long names table
+			idx, err2 := strconv.Atoi(name[1:])
+			if err2 != nil || idx >= len(longNames) {
+				return fmt.Errorf("ar: invalid gnu long name %q", name)
+			}
+
+			name = string(longNames[idx:])
+			if end := strings.Index(name, "/\n"); end != -1 {
+				name = name[:end]
+			}
+		default:
+			// gnu style names end with slash
+			name = strings.TrimSuffix(name, "/")
+		}
+
+		section := &sectionSource{io.NewSectionReader(src, dataOff, size)}
+		if i == 0 && name == "debian-binary" {
+			isDeb = true
+		}
+
+		if isDeb {
+			if !strings.HasPrefix(name, "data.tar") {
+				continue
+			}
+
+			typ, err2 := matchType(section)
+			if err2 != nil {
+				return fmt.Errorf("deb: detecting %q: %w", name, err2)
+			}
+
+			return walkArchive(section, typ, password, fn)
+		}
+
+		mode, _ := strconv.ParseUint(strings.TrimSpace(string(hdr[40:48])), 8, 32)
+		mtime, _ := strconv.ParseInt(strings.TrimSpace(string(hdr[16:28])), 10, 64)
+		err = fn(&entry{
+			name: cleanEntryName(name),
+			info: &fileInfo{
+				name:    name,
+				size:    size,
+				mode:    unixMode(uint32(mode)),
+				modTime: time.Unix(mtime, 0),
+			},
+			open: func() (io.Reader, error) {
+				return section, nil
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"arhat.dev/pkg/yamlhelper"
 	"arhat.dev/rs"
 	"github.com/h2non/filetype"
+	"github.com/h2non/filetype/types"
 	"gopkg.in/yaml.v3"
 
 	"arhat.dev/dukkha/pkg/cache"
@@ -44,6 +45,9 @@ type Driver struct {
 	name string
 }
 
+// ConcurrencySafe implements dukkha.ConcurrentRenderer
+func (d *Driver) ConcurrencySafe() bool { return true }
+
 func (d *Driver) RenderYaml(
 	rc dukkha.RenderingContext, rawData interface{}, attributes []dukkha.RendererAttribute,
 ) ([]byte, error) {
@@ -87,13 +91,20 @@ func (d *Driver) RenderYaml(
 		spec.Path = path.Clean(spec.Path)
 	}
 
+	for _, attr := range d.Attributes(attributes) {
+		if attr == AttrList {
+			spec.list = true
+		}
+	}
+
 	data, err := renderer.HandleRenderingRequestWithRemoteFetch(
 		d.Cache,
 		spec,
 		func(obj cache.IdentifiableObject) (io.ReadCloser, error) {
-			return extractFileFromArchive(rc.FS(), obj)
+			return extractFromArchive(rc.FS(), obj)
 		},
 		d.Attributes(attributes),
+		renderer.FetchOptions{Resource: spec.Archive + ":" + spec.Path},
 	)
 	if err != nil {
 		return nil, fmt.Errorf("renderer.%s: %w", d.name, err)
@@ -121,21 +132,40 @@ func parseOneLineSpec(onelineSpec string) *inputSpec {
 	return ret
 }
 
-func extractFileFromArchive(ofs *fshelper.OSFS, obj cache.IdentifiableObject) (io.ReadCloser, error) {
+func extractFromArchive(ofs *fshelper.OSFS, obj cache.IdentifiableObject) (io.ReadCloser, error) {
 	spec := obj.(*inputSpec)
-	info, err := ofs.Stat(spec.Archive)
+	src, typ, err := openArchive(ofs, spec.Archive)
 	if err != nil {
 		return nil, err
 	}
 
-	typ, err := filetype.MatchFile(spec.Archive)
+	if !spec.multiple() {
+		return unarchive(src, typ, spec.Path, spec.Password)
+	}
+
+	defer func() { _ = src.Close() }()
+
+	if spec.list {
+		return listEntries(src, typ, spec.Path, spec.Password)
+	}
+
+	return extractEntries(src, typ, spec.Path, spec.Password)
+}
+
+func openArchive(ofs *fshelper.OSFS, archive string) (archiveSource, types.Type, error) {
+	info, err := ofs.Stat(archive)
 	if err != nil {
-		return nil, err
+		return nil, types.Unknown, err
 	}
 
-	f, err := ofs.Open(spec.Archive)
+	typ, err := filetype.MatchFile(archive)
 	if err != nil {
-		return nil, err
+		return nil, types.Unknown, err
+	}
+
+	f, err := ofs.Open(archive)
+	if err != nil {
+		return nil, types.Unknown, err
 	}
 
 	type src struct {
@@ -143,5 +173,5 @@ func extractFileFromArchive(ofs *fshelper.OSFS, obj cache.IdentifiableObject) (i
 		*os.File
 	}
 
-	return unarchive(&src{info, f.(*os.File)}, typ, spec.Path, spec.Password)
+	return &src{info, f.(*os.File)}, typ, nil
 }
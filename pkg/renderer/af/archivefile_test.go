@@ -82,6 +82,8 @@ func TestDriver(t *testing.T) {
 					"003.tar.bz2",
 					"004.tar.lzma",
 					"005.tar.xz",
+					"006.tar.zst",
+					"007.tar.lz4",
 
 					// zip
 					"101.zip",
@@ -89,6 +91,12 @@ func TestDriver(t *testing.T) {
 					"103.zip.bz2",
 					"104.zip.lzma",
 					"105.zip.xz",
+
+					// 7z
+					"201.7z",
+
+					// packages
+					"301.deb",
 				}, " "),
 			})
 
@@ -12,20 +12,43 @@ type inputSpec struct {
 	Archive string `yaml:"archive"`
 
 	// Path is the in archive path of the target file to extract
+	//
+	// when it's a glob pattern (e.g. `*/bin/kubectl`), a map of in archive
+	// path to file content of all matched regular files is generated
 	Path string `yaml:"path"`
 
-	// Flatten generate a map of files in archive
+	// Flatten generate a map of files in archive (under Path if set)
 	Flatten bool `yaml:"flatten"`
 
 	// Password for password protected archive files
 	Password string `yaml:"password"`
+
+	// list is set when rendering with attribute `list`
+	list bool
+}
+
+// multiple returns true when the result is a map or list of files
+// instead of single file content
+func (s *inputSpec) multiple() bool {
+	return s.list || s.Flatten || isGlob(s.Path)
 }
 
 func (s *inputSpec) ScopeUniqueID() string {
-	// return append([]byte(s.Archive), "|...|"+s.Path...))
-	return s.Archive
+	id := s.Archive + ":" + s.Path
+	switch {
+	case s.list:
+		id += "#list"
+	case s.Flatten:
+		id += "#flatten"
+	}
+
+	return id
 }
 
 func (s *inputSpec) Ext() string {
+	if s.multiple() {
+		return ".yaml"
+	}
+
 	return ""
 }
@@ -0,0 +1,118 @@
+package af
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	cpioMagic    = "070701"
+	cpioMagicCRC = "070702"
+
+	cpioHeaderLen = 110
+	cpioTrailer   = "TRAILER!!!"
+)
+
+// countingReader counts bytes read for alignment
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// align discards bytes until read offset is aligned to size
+func (r *countingReader) align(size int64) error {
+	_, err := io.CopyN(io.Discard, r, (size-r.n%size)%size)
+	return err
+}
+
+// walkCpio walks through cpio archive in portable ascii format (newc)
+func walkCpio(src io.Reader, fn walkFunc) error {
+	r := &countingReader{r: src}
+	hdr := make([]byte, cpioHeaderLen)
+	for {
+		_, err := io.ReadFull(r, hdr)
+		if err != nil {
+			return fmt.Errorf("cpio: reading header: %w", err)
+		}
+
+		if magic := string(hdr[:6]); magic != cpioMagic && magic != cpioMagicCRC {
+			return fmt.Errorf("cpio: invalid header magic %q", magic)
+		}
+
+		var fields [13]uint64
+		for i := range fields {
+			fields[i], err = strconv.ParseUint(string(hdr[6+i*8:14+i*8]), 16, 32)
+			if err != nil {
+				return fmt.Errorf("cpio: invalid header: %w", err)
+			}
+		}
+
+		var (
+			mode     = uint32(fields[1])
+			mtime    = int64(fields[5])
+			size     = int64(fields[6])
+			nameSize = int64(fields[11])
+		)
+
+		nameBytes := make([]byte, nameSize)
+		_, err = io.ReadFull(r, nameBytes)
+		if err == nil {
+			err = r.align(4)
+		}
+
+		if err != nil {
+			return fmt.Errorf("cpio: reading name: %w", err)
+		}
+
+		name := strings.TrimRight(string(nameBytes), "\x00")
+		if name == cpioTrailer {
+			return nil
+		}
+
+		e := &entry{
+			name: cleanEntryName(name),
+			info: &fileInfo{
+				name:    name,
+				size:    size,
+				mode:    unixMode(mode),
+				modTime: time.Unix(mtime, 0),
+			},
+		}
+
+		data := io.LimitReader(r, size)
+		e.open = func() (io.Reader, error) { return data, nil }
+
+		if e.info.Mode()&fs.ModeSymlink != 0 {
+			target, err2 := io.ReadAll(data)
+			if err2 != nil {
+				return fmt.Errorf("cpio: reading link %q: %w", name, err2)
+			}
+
+			e.linkname = string(target)
+		}
+
+		err = fn(e)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(io.Discard, data)
+		if err == nil {
+			err = r.align(4)
+		}
+
+		if err != nil {
+			return fmt.Errorf("cpio: skipping %q: %w", name, err)
+		}
+	}
+}
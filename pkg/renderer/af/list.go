@@ -0,0 +1,167 @@
+package af
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/h2non/filetype/types"
+	"gopkg.in/yaml.v3"
+)
+
+// AttrList makes af renderer list entries in archive instead of extracting file content
+const AttrList = "list"
+
+// entryInfo is the listing entry of a file in archive
+type entryInfo struct {
+	// Path in archive
+	Path string `yaml:"path"`
+
+	Size    int64  `yaml:"size"`
+	Mode    string `yaml:"mode"`
+	ModTime string `yaml:"mtime"`
+
+	// Link is the target of symlink or hard link
+	Link string `yaml:"link,omitempty"`
+}
+
+// newEntryMatcher creates matcher of entries selected by in archive path
+//
+// when pattern is a glob pattern, entries matching the pattern are selected,
+// otherwise the entry at the path and entries under it are selected
+func newEntryMatcher(pattern string) (func(name string) bool, error) {
+	if len(pattern) == 0 || pattern == "." {
+		return func(string) bool { return true }, nil
+	}
+
+	pattern = cleanEntryName(pattern)
+	if !isGlob(pattern) {
+		return func(name string) bool {
+			return name == pattern || strings.HasPrefix(name, pattern+"/")
+		}, nil
+	}
+
+	if !doublestar.ValidatePattern(pattern) {
+		return nil, fmt.Errorf("invalid glob pattern %q", pattern)
+	}
+
+	return func(name string) bool {
+		match, _ := doublestar.Match(pattern, name)
+		return match
+	}, nil
+}
+
+// listEntries lists entries in archive selected by pattern as yaml array
+func listEntries(src archiveSource, typ types.Type, pattern, password string) (io.ReadCloser, error) {
+	match, err := newEntryMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*entryInfo, 0)
+	err = walkArchive(src, typ, password, func(e *entry) error {
+		if len(e.name) == 0 || !match(e.name) {
+			return nil
+		}
+
+		ret = append(ret, &entryInfo{
+			Path:    e.name,
+			Size:    e.info.Size(),
+			Mode:    e.info.Mode().String(),
+			ModTime: e.info.ModTime().UTC().Format(time.RFC3339),
+			Link:    e.linkname,
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := yaml.Marshal(ret)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+// extractEntries extracts regular files in archive selected by pattern
+// as a yaml map of in archive path to file content
+//
+// links are followed, links not resolved to regular files are ignored
+func extractEntries(src archiveSource, typ types.Type, pattern, password string) (io.ReadCloser, error) {
+	match, err := newEntryMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	restore, err := prepareSeekRestore(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ret   = make(map[string]string)
+		links []string
+	)
+
+	err = walkArchive(src, typ, password, func(e *entry) error {
+		if !match(e.name) {
+			return nil
+		}
+
+		if !e.isRegular() {
+			if len(e.linkname) != 0 {
+				links = append(links, e.name)
+			}
+
+			return nil
+		}
+
+		r, err2 := e.open()
+		if err2 != nil {
+			return err2
+		}
+
+		data, err2 := io.ReadAll(r)
+		if err2 != nil {
+			return fmt.Errorf("reading %q: %w", e.name, err2)
+		}
+
+		ret[e.name] = string(data)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range links {
+		err = restore()
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := extractEntry(src, typ, name, password)
+		switch {
+		case err == nil:
+			ret[name] = string(data)
+		case errors.Is(err, errNotRegular), errors.Is(err, errEntryNotFound):
+			// link to directory or to file not in archive
+		default:
+			return nil, fmt.Errorf("resolving link %q: %w", name, err)
+		}
+	}
+
+	data, err := yaml.Marshal(ret)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
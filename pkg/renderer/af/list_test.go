@@ -0,0 +1,268 @@
+package af
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/h2non/filetype"
+	"github.com/h2non/filetype/matchers"
+	"github.com/h2non/filetype/types"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+var testArchives = []string{
+	"001.tar",
+	"002.tar.gz",
+	"005.tar.xz",
+	"006.tar.zst",
+	"007.tar.lz4",
+	"101.zip",
+	"201.7z",
+	"301.deb",
+}
+
+func openTestArchive(t *testing.T, name string) (archiveSource, types.Type) {
+	f, err := os.Open("testdata/" + name)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	t.Cleanup(func() { _ = f.Close() })
+
+	info, err := f.Stat()
+	assert.NoError(t, err)
+
+	typ, err := filetype.MatchFile("testdata/" + name)
+	assert.NoError(t, err)
+
+	type src struct {
+		sizeIface
+		*os.File
+	}
+
+	return &src{info, f}, typ
+}
+
+func TestListEntries(t *testing.T) {
+	for _, name := range testArchives {
+		t.Run(name, func(t *testing.T) {
+			src, typ := openTestArchive(t, name)
+
+			r, err := listEntries(src, typ, "", "")
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var entries []*entryInfo
+			assert.NoError(t, yaml.NewDecoder(r).Decode(&entries))
+
+			byPath := make(map[string]*entryInfo)
+			for _, e := range entries {
+				byPath[e.Path] = e
+			}
+
+			if assert.Contains(t, byPath, "top-level-data.yaml") {
+				e := byPath["top-level-data.yaml"]
+				assert.EqualValues(t, 9, e.Size)
+				assert.Equal(t, "-", e.Mode[:1])
+				assert.NotEmpty(t, e.ModTime)
+			}
+
+			if assert.Contains(t, byPath, "level-1") {
+				assert.Equal(t, "d", byPath["level-1"].Mode[:1])
+			}
+
+			if e, ok := byPath["level-1/level-2/top-level-data-symlink"]; assert.True(t, ok) && e.Mode[:1] == "L" {
+				assert.Equal(t, "../../top-level-data.yaml", e.Link)
+			}
+		})
+	}
+}
+
+func TestExtractEntries(t *testing.T) {
+	for _, name := range testArchives {
+		t.Run(name, func(t *testing.T) {
+			for _, test := range []struct {
+				pattern  string
+				expected map[string]string
+			}{
+				{
+					pattern: "**/*.yaml",
+					expected: map[string]string{
+						"top-level-data.yaml":       "foo: bar\n",
+						"level-1/level-1-data.yaml": "foo: bar\nbar: foo\n",
+					},
+				},
+				{
+					pattern: "*/level-2/*",
+					expected: map[string]string{
+						"level-1/level-2/top-level-data-symlink": "foo: bar\n",
+					},
+				},
+				{
+					pattern: "level-1/level-2",
+					expected: map[string]string{
+						"level-1/level-2/top-level-data-symlink": "foo: bar\n",
+					},
+				},
+			} {
+				src, typ := openTestArchive(t, name)
+
+				r, err := extractEntries(src, typ, test.pattern, "")
+				if !assert.NoError(t, err, test.pattern) {
+					continue
+				}
+
+				actual := make(map[string]string)
+				assert.NoError(t, yaml.NewDecoder(r).Decode(&actual))
+				assert.EqualValues(t, test.expected, actual, test.pattern)
+			}
+		})
+	}
+}
+
+func TestNewEntryMatcher(t *testing.T) {
+	for _, test := range []struct {
+		pattern  string
+		name     string
+		expected bool
+	}{
+		{"", "foo", true},
+		{".", "foo/bar", true},
+		{"foo", "foo", true},
+		{"foo", "foo/bar", true},
+		{"/foo/", "foo/bar", true},
+		{"foo", "foobar", false},
+		{"*/bin/kubectl", "kubernetes-v1.22/bin/kubectl", true},
+		{"*/bin/kubectl", "kubernetes/client/bin/kubectl", false},
+		{"**/bin/kubectl", "kubernetes/client/bin/kubectl", true},
+		{"{foo,bar}", "bar", true},
+	} {
+		t.Run(test.pattern+"|"+test.name, func(t *testing.T) {
+			match, err := newEntryMatcher(test.pattern)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, match(test.name))
+		})
+	}
+
+	_, err := newEntryMatcher("[foo")
+	assert.Error(t, err)
+}
+
+// newCpioBuf creates cpio archive in newc format
+func newCpioBuf(files map[string]string, order []string) []byte {
+	buf := &bytes.Buffer{}
+	pad := func() {
+		for buf.Len()%4 != 0 {
+			buf.WriteByte(0)
+		}
+	}
+
+	add := func(name string, mode uint32, content string) {
+		fmt.Fprintf(buf, "%s%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x",
+			cpioMagic, 0, mode, 0, 0, 1, 0, len(content), 0, 0, 0, 0, len(name)+1, 0,
+		)
+		buf.WriteString(name)
+		buf.WriteByte(0)
+		pad()
+		buf.WriteString(content)
+		pad()
+	}
+
+	for _, name := range order {
+		add(name, 0100644, files[name])
+	}
+
+	add(cpioTrailer, 0, "")
+	return buf.Bytes()
+}
+
+// newRpmBuf creates rpm package with empty headers and gzip compressed
+// cpio payload
+func newRpmBuf(payload []byte) []byte {
+	buf := &bytes.Buffer{}
+
+	lead := make([]byte, rpmLeadLen)
+	copy(lead, []byte{0xed, 0xab, 0xee, 0xdb, 3, 0})
+	buf.Write(lead)
+
+	header := func() {
+		hdr := make([]byte, rpmHeaderLen)
+		copy(hdr, rpmHeaderMagic)
+		// one index entry with 3 bytes of data
+		binary.BigEndian.PutUint32(hdr[8:12], 1)
+		binary.BigEndian.PutUint32(hdr[12:16], 3)
+		buf.Write(hdr)
+		buf.Write(make([]byte, 16+3))
+	}
+
+	// signature header is padded to 8 bytes
+	header()
+	for buf.Len()%8 != 0 {
+		buf.WriteByte(0)
+	}
+
+	header()
+
+	zw := gzip.NewWriter(buf)
+	_, _ = zw.Write(payload)
+	_ = zw.Close()
+
+	return buf.Bytes()
+}
+
+func TestWalk_cpio_rpm(t *testing.T) {
+	files := map[string]string{
+		"./usr/bin/foo":       "foo",
+		"./usr/share/foo.txt": "hello",
+	}
+	order := []string{"./usr/bin/foo", "./usr/share/foo.txt"}
+
+	cpio := newCpioBuf(files, order)
+	for _, test := range []struct {
+		name string
+		data []byte
+		typ  types.Type
+	}{
+		{"cpio", cpio, cpioType},
+		{"rpm", newRpmBuf(cpio), matchers.TypeRpm},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			typ, err := filetype.Match(test.data)
+			assert.NoError(t, err)
+			assert.Equal(t, test.typ, typ)
+
+			type src struct {
+				*io.SectionReader
+				io.Closer
+			}
+
+			newSrc := func() archiveSource {
+				return &src{
+					SectionReader: io.NewSectionReader(bytes.NewReader(test.data), 0, int64(len(test.data))),
+					Closer:        io.NopCloser(nil),
+				}
+			}
+
+			data, err := extractEntry(newSrc(), typ, "usr/share/foo.txt", "")
+			assert.NoError(t, err)
+			assert.Equal(t, "hello", string(data))
+
+			r, err := extractEntries(newSrc(), typ, "usr/**", "")
+			assert.NoError(t, err)
+
+			actual := make(map[string]string)
+			assert.NoError(t, yaml.NewDecoder(r).Decode(&actual))
+			assert.EqualValues(t, map[string]string{
+				"usr/bin/foo":       "foo",
+				"usr/share/foo.txt": "hello",
+			}, actual)
+		})
+	}
+}
@@ -7,14 +7,36 @@ import (
 	"github.com/nwaples/rardecode"
 )
 
-// nolint:unparam
-func unrar(src io.Reader, target, password string) (io.Reader, error) {
-	// TODO: implement
-	_ = target
+func walkRar(src io.Reader, password string, fn walkFunc) error {
 	r, err := rardecode.NewReader(src, password)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("unrar: %w", err)
+	}
+
+	for {
+		hdr, err := r.Next()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return fmt.Errorf("unrar: %w", err)
+		}
+
+		err = fn(&entry{
+			name: cleanEntryName(hdr.Name),
+			info: &fileInfo{
+				name:    hdr.Name,
+				size:    hdr.UnPackedSize,
+				mode:    hdr.Mode(),
+				modTime: hdr.ModificationTime,
+			},
+			open: func() (io.Reader, error) {
+				return r, nil
+			},
+		})
+		if err != nil {
+			return err
+		}
 	}
-	_ = r
-	return nil, fmt.Errorf("no implementation")
 }
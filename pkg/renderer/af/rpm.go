@@ -0,0 +1,59 @@
+package af
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	rpmLeadLen   = 96
+	rpmHeaderLen = 16
+)
+
+var rpmHeaderMagic = []byte{0x8e, 0xad, 0xe8, 0x01}
+
+// walkRpm walks through files in the cpio payload of rpm package
+func walkRpm(src io.ReaderAt, password string, fn walkFunc) error {
+	// lead, signature header (aligned to 8 bytes), header, payload
+	off := int64(rpmLeadLen)
+	for i := 0; i < 2; i++ {
+		size, err := readRpmHeaderSize(src, off)
+		if err != nil {
+			return err
+		}
+
+		off += size
+		if i == 0 {
+			off += (8 - off%8) % 8
+		}
+	}
+
+	payload := &sectionSource{io.NewSectionReader(src, off, math.MaxInt64-off)}
+	typ, err := matchType(payload)
+	if err != nil {
+		return fmt.Errorf("rpm: detecting payload: %w", err)
+	}
+
+	return walkArchive(payload, typ, password, fn)
+}
+
+// readRpmHeaderSize returns total size of rpm header structure at off
+func readRpmHeaderSize(src io.ReaderAt, off int64) (int64, error) {
+	hdr := make([]byte, rpmHeaderLen)
+	_, err := src.ReadAt(hdr, off)
+	if err != nil {
+		return 0, fmt.Errorf("rpm: reading header at offset %d: %w", off, err)
+	}
+
+	if string(hdr[:4]) != string(rpmHeaderMagic) {
+		return 0, fmt.Errorf("rpm: invalid header magic at offset %d", off)
+	}
+
+	// 16 bytes for each index entry
+	indexCount := int64(binary.BigEndian.Uint32(hdr[8:12]))
+	storeSize := int64(binary.BigEndian.Uint32(hdr[12:16]))
+
+	return rpmHeaderLen + indexCount*16 + storeSize, nil
+}
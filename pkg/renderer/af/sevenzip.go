@@ -0,0 +1,145 @@
+package af
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/saracen/go7z"
+	"github.com/saracen/go7z/headers"
+)
+
+// windows file attributes
+const (
+	szAttrReadOnly  = 0x01
+	szAttrDirectory = 0x10
+
+	// high 16 bits are unix mode when set
+	szAttrUnixExtension = 0x8000
+)
+
+func walk7z(src SizedReaderAt, password string, fn walkFunc) error {
+	r, err := go7z.NewReader(src, src.Size())
+	if err != nil {
+		return fmt.Errorf("7z: %w", err)
+	}
+
+	r.Options.SetPassword(password)
+
+	for {
+		hdr, err := r.Next()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return fmt.Errorf("7z: %w", err)
+		}
+
+		if hdr.IsAntiFile {
+			continue
+		}
+
+		content := &szContent{r: r}
+		if hdr.IsEmptyStream {
+			content.data = []byte{}
+		}
+
+		e := &entry{
+			name: cleanEntryName(hdr.Name),
+			info: &szFileInfo{
+				fileInfo: fileInfo{
+					name:    hdr.Name,
+					mode:    szMode(hdr),
+					modTime: hdr.ModifiedAt,
+				},
+				content: content,
+			},
+			open: content.open,
+		}
+
+		if e.info.Mode()&fs.ModeSymlink != 0 {
+			err = content.load()
+			if err != nil {
+				return err
+			}
+
+			e.linkname = string(content.data)
+		}
+
+		err = fn(e)
+		if err != nil {
+			return err
+		}
+
+		// checksum of an entry is verified when moving to next entry, which
+		// requires the entry being fully read
+		_, err = io.Copy(io.Discard, r)
+		if err != nil {
+			return fmt.Errorf("7z: %w", err)
+		}
+	}
+}
+
+func szMode(hdr *headers.FileInfo) fs.FileMode {
+	if hdr.Attrib&szAttrUnixExtension != 0 {
+		return unixMode(hdr.Attrib >> 16)
+	}
+
+	var mode fs.FileMode = 0644
+	if hdr.Attrib&szAttrDirectory != 0 {
+		mode = fs.ModeDir | 0755
+	}
+
+	if hdr.Attrib&szAttrReadOnly != 0 {
+		mode &^= 0222
+	}
+
+	return mode
+}
+
+// szFileInfo reads size from content, as 7z reader doesn't expose unpacked
+// size of entries
+type szFileInfo struct {
+	fileInfo
+
+	content *szContent
+}
+
+func (i *szFileInfo) Size() int64 {
+	if i.content.load() != nil {
+		return 0
+	}
+
+	return int64(len(i.content.data))
+}
+
+// szContent is the content of current entry in 7z archive, only buffered
+// when size is required
+type szContent struct {
+	r    io.Reader
+	data []byte
+}
+
+func (c *szContent) load() error {
+	if c.data != nil {
+		return nil
+	}
+
+	data, err := io.ReadAll(c.r)
+	if err != nil {
+		return fmt.Errorf("7z: %w", err)
+	}
+
+	c.data = data
+	return nil
+}
+
+func (c *szContent) open() (io.Reader, error) {
+	if c.data != nil {
+		return bytes.NewReader(c.data), nil
+	}
+
+	return c.r, nil
+}
@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"fmt"
 	"io"
+	"io/fs"
 	"path"
 
 	"arhat.dev/pkg/pathhelper"
@@ -61,3 +62,40 @@ func untar(r io.ReadSeeker, target string) (io.Reader, error) {
 
 	return nil, fmt.Errorf("untar: file %q not found in archive", target)
 }
+
+func walkTar(r io.Reader, fn walkFunc) error {
+	rd := tar.NewReader(r)
+	for {
+		hdr, err := rd.Next()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return fmt.Errorf("untar: %w", err)
+		}
+
+		e := &entry{
+			name:     cleanEntryName(hdr.Name),
+			info:     hdr.FileInfo(),
+			linkname: hdr.Linkname,
+			open: func() (io.Reader, error) {
+				return rd, nil
+			},
+		}
+
+		if hdr.Typeflag == tar.TypeLink {
+			// hard link has no content
+			e.info = &fileInfo{
+				name:    hdr.Name,
+				mode:    e.info.Mode() | fs.ModeIrregular,
+				modTime: hdr.ModTime,
+			}
+		}
+
+		err = fn(e)
+		if err != nil {
+			return err
+		}
+	}
+}
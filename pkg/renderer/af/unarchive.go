@@ -1,11 +1,13 @@
 package af
 
 import (
+	"bytes"
 	"compress/bzip2"
 	"compress/flate"
 	"compress/gzip"
 	"fmt"
 	"io"
+	"io/ioutil"
 
 	"arhat.dev/pkg/iohelper"
 	"github.com/h2non/filetype"
@@ -20,6 +22,7 @@ import (
 var (
 	lzmaType = filetype.NewType("lzma", "application/vnd.lzma")
 	lz4Type  = filetype.NewType("lz4", "application/vnd.lz4")
+	cpioType = filetype.NewType("cpio", "application/x-cpio")
 )
 
 // magic number ref: https://www.kernel.org/doc/html/latest/x86/boot.html
@@ -30,8 +33,15 @@ func init() {
 	})
 
 	filetype.AddMatcher(lz4Type, func(b []byte) bool {
-		return len(b) >= 2 &&
-			b[0] == 0x02 && b[1] == 0x21
+		// legacy format or frame format
+		return (len(b) >= 2 && b[0] == 0x02 && b[1] == 0x21) ||
+			(len(b) >= 4 && b[0] == 0x04 && b[1] == 0x22 && b[2] == 0x4d && b[3] == 0x18)
+	})
+
+	// only portable ascii format (newc) is supported
+	filetype.AddMatcher(cpioType, func(b []byte) bool {
+		return len(b) >= 6 &&
+			(string(b[:6]) == cpioMagic || string(b[:6]) == cpioMagicCRC)
 	})
 }
 
@@ -51,111 +61,96 @@ func unarchive(src archiveSource, typ types.Type, inArchivePath, password string
 		}
 
 		return iohelper.CustomReadCloser(r, src.Close), nil
-	case matchers.TypeRar:
-		rd, err := unrar(src, inArchivePath, password)
+	case matchers.TypeRar,
+		matchers.Type7z,
+		matchers.TypeDeb,
+		matchers.TypeAr,
+		matchers.TypeRpm,
+		cpioType:
+		// not seekable by in archive path, extract by walking through
+		data, err := extractEntry(src, typ, inArchivePath, password)
+		_ = src.Close()
 		if err != nil {
 			return nil, err
 		}
 
-		return iohelper.CustomReadCloser(rd, src.Close), nil
-	case matchers.TypeGz:
-		r, err := gzip.NewReader(src)
-		if err != nil {
-			return nil, err
-		}
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	case matchers.TypePdf:
+		// TODO
+		return nil, fmt.Errorf("no implementation")
+	}
 
-		if len(inArchivePath) == 0 {
-			return iohelper.CustomReadCloser(r, func() error {
-				_ = r.Close()
-				return src.Close()
-			}), nil
-		}
+	r, ok, err := decompress(src, typ)
+	if err != nil {
+		return nil, err
+	}
 
-		return unarchiveNext(src, r, inArchivePath, password)
-	case matchers.TypeBz2:
-		r := bzip2.NewReader(src)
-		if len(inArchivePath) == 0 {
-			return iohelper.CustomReadCloser(r, func() error {
-				return src.Close()
-			}), nil
-		}
+	if !ok {
+		// assume deflate
+		r = flate.NewReader(src)
+	}
 
-		return unarchiveNext(src, r, inArchivePath, password)
-	case matchers.TypeXz:
-		r, err := xz.ReaderConfig{}.NewReader(src)
-		if err != nil {
-			return nil, err
-		}
+	if len(inArchivePath) == 0 {
+		return iohelper.CustomReadCloser(r, func() error {
+			_ = r.Close()
+			return src.Close()
+		}), nil
+	}
 
-		if len(inArchivePath) == 0 {
-			return iohelper.CustomReadCloser(r, func() error {
-				return src.Close()
-			}), nil
-		}
+	return unarchiveNext(src, r, inArchivePath, password)
+}
 
-		return unarchiveNext(src, r, inArchivePath, password)
-	case matchers.TypeZstd:
-		r, err := zstd.NewReader(src)
+// decompressors of supported compression formats
+var decompressors = map[types.Type]func(r io.Reader) (io.ReadCloser, error){
+	matchers.TypeGz: func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	matchers.TypeBz2: func(r io.Reader) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bzip2.NewReader(r)), nil
+	},
+	matchers.TypeXz: func(r io.Reader) (io.ReadCloser, error) {
+		xr, err := xz.ReaderConfig{}.NewReader(r)
 		if err != nil {
 			return nil, err
 		}
 
-		if len(inArchivePath) == 0 {
-			return iohelper.CustomReadCloser(r, func() error {
-				r.Close()
-				return src.Close()
-			}), nil
-		}
-
-		return unarchiveNext(src, r, inArchivePath, password)
-	case lz4Type:
-		r := lz4.NewReader(src)
-		if len(inArchivePath) == 0 {
-			return iohelper.CustomReadCloser(r, func() error {
-				return src.Close()
-			}), nil
-		}
-
-		return unarchiveNext(src, r, inArchivePath, password)
-	case lzmaType:
-		r, err := lzma.ReaderConfig{}.NewReader(src)
+		return ioutil.NopCloser(xr), nil
+	},
+	matchers.TypeZstd: func(r io.Reader) (io.ReadCloser, error) {
+		zr, err := zstd.NewReader(r)
 		if err != nil {
 			return nil, err
 		}
 
-		if len(inArchivePath) == 0 {
-			return iohelper.CustomReadCloser(r, func() error {
-				return src.Close()
-			}), nil
+		return zr.IOReadCloser(), nil
+	},
+	lz4Type: func(r io.Reader) (io.ReadCloser, error) {
+		return ioutil.NopCloser(lz4.NewReader(r)), nil
+	},
+	lzmaType: func(r io.Reader) (io.ReadCloser, error) {
+		lr, err := lzma.ReaderConfig{}.NewReader(r)
+		if err != nil {
+			return nil, err
 		}
 
-		return unarchiveNext(src, r, inArchivePath, password)
-	case matchers.Type7z:
-		// TODO
-	case matchers.TypePdf:
-		// TODO
-	case matchers.TypeDeb:
-		// TODO
-	case matchers.TypeAr:
-		// unix archive
-		// TODO
-	case matchers.TypeRpm:
-		// TODO
-	default:
-		// assume deflate
-		r := flate.NewReader(src)
+		return ioutil.NopCloser(lr), nil
+	},
+}
 
-		if len(inArchivePath) == 0 {
-			return iohelper.CustomReadCloser(r, func() error {
-				_ = r.Close()
-				return src.Close()
-			}), nil
-		}
+// decompress returns decompressed content of src, ok is false when typ
+// is not a supported compression format
+func decompress(src io.Reader, typ types.Type) (_ io.ReadCloser, ok bool, _ error) {
+	newReader, ok := decompressors[typ]
+	if !ok {
+		return nil, false, nil
+	}
 
-		return unarchiveNext(src, r, inArchivePath, password)
+	r, err := newReader(src)
+	if err != nil {
+		return nil, true, err
 	}
 
-	return nil, fmt.Errorf("no implementation")
+	return r, true, nil
 }
 
 func unarchiveNext(src archiveSource, r io.Reader, inArchivePath, password string) (io.ReadCloser, error) {
@@ -0,0 +1,251 @@
+package af
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"arhat.dev/pkg/pathhelper"
+	"github.com/h2non/filetype"
+	"github.com/h2non/filetype/matchers"
+	"github.com/h2non/filetype/types"
+)
+
+// entry is a file in archive
+type entry struct {
+	// name is the cleaned in archive path without leading slash
+	name string
+	info fs.FileInfo
+
+	// linkname is the raw link target of symlink or hard link
+	linkname string
+
+	// open returns content of regular file, only valid during the call
+	// to walkFunc
+	open func() (io.Reader, error)
+}
+
+func (e *entry) isRegular() bool { return e.info.Mode().IsRegular() }
+
+// walkFunc is called for every entry in archive, return errStopWalk to stop
+// walking without error
+type walkFunc func(e *entry) error
+
+var errStopWalk = errors.New("stop walking")
+
+var (
+	errEntryNotFound = errors.New("not found in archive")
+	errNotRegular    = errors.New("not a regular file")
+)
+
+// walkArchive calls fn with every entry in archive in the order they are stored,
+// compressed archives (e.g. tar.gz) are decompressed first
+func walkArchive(src archiveSource, typ types.Type, password string, fn walkFunc) error {
+	var err error
+	switch typ {
+	case matchers.TypeTar:
+		err = walkTar(src, fn)
+	case matchers.TypeZip:
+		err = walkZip(src, password, fn)
+	case matchers.TypeRar:
+		err = walkRar(src, password, fn)
+	case matchers.Type7z:
+		err = walk7z(src, password, fn)
+	case matchers.TypeDeb, matchers.TypeAr:
+		err = walkAr(src, password, fn)
+	case matchers.TypeRpm:
+		err = walkRpm(src, password, fn)
+	case cpioType:
+		err = walkCpio(src, fn)
+	default:
+		r, ok, err2 := decompress(src, typ)
+		if err2 != nil {
+			return err2
+		}
+
+		if !ok {
+			return fmt.Errorf("unsupported archive type %q", typ.Extension)
+		}
+
+		defer func() { _ = r.Close() }()
+
+		next, nextTyp, err2 := detectNext(src, r)
+		if err2 != nil {
+			return err2
+		}
+
+		if nextTyp == types.Unknown {
+			return fmt.Errorf("not an archive")
+		}
+
+		return walkArchive(next, nextTyp, password, fn)
+	}
+
+	if errors.Is(err, errStopWalk) {
+		return nil
+	}
+
+	return err
+}
+
+// detectNext creates archive source from r and detects its type
+func detectNext(parent archiveSource, r io.Reader) (archiveSource, types.Type, error) {
+	src := newArchiveSource(parent, r)
+	typ, err := matchType(src)
+	return src, typ, err
+}
+
+// matchType detects file type of src without changing its offset
+func matchType(src io.ReadSeeker) (types.Type, error) {
+	restore, err := prepareSeekRestore(src)
+	if err != nil {
+		return types.Unknown, err
+	}
+
+	typ, err := filetype.MatchReader(src)
+	if err != nil {
+		return types.Unknown, err
+	}
+
+	return typ, restore()
+}
+
+// maxLinkDepth limits how many links are followed when extracting
+const maxLinkDepth = 16
+
+// extractEntry reads content of target file in archive by walking through it,
+// links in archive are followed, when target is empty or `.`, content of the
+// first regular file is returned
+func extractEntry(src archiveSource, typ types.Type, target, password string) ([]byte, error) {
+	restore, err := prepareSeekRestore(src)
+	if err != nil {
+		return nil, err
+	}
+
+	firstRegular := len(target) == 0 || target == "."
+	target = cleanEntryName(target)
+	for i := 0; i < maxLinkDepth; i++ {
+		var (
+			data   []byte
+			found  bool
+			linkTo string
+		)
+
+		err = walkArchive(src, typ, password, func(e *entry) error {
+			switch {
+			case firstRegular && !e.isRegular():
+				return nil
+			case !firstRegular && e.name != target:
+				return nil
+			}
+
+			found = true
+			switch {
+			case e.isRegular():
+				r, err2 := e.open()
+				if err2 != nil {
+					return err2
+				}
+
+				data, err2 = io.ReadAll(r)
+				if err2 != nil {
+					return err2
+				}
+			case e.info.Mode()&fs.ModeSymlink != 0:
+				linkTo = cleanEntryName(pathhelper.EvalLink(e.name, e.linkname))
+			case len(e.linkname) != 0:
+				// hard link target is relative to archive root
+				linkTo = cleanEntryName(e.linkname)
+			default:
+				return fmt.Errorf("%q: %w", e.name, errNotRegular)
+			}
+
+			return errStopWalk
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case !found && firstRegular:
+			return nil, fmt.Errorf("no regular file in archive")
+		case !found:
+			return nil, fmt.Errorf("file %q %w", target, errEntryNotFound)
+		case len(linkTo) == 0:
+			return data, nil
+		}
+
+		target = linkTo
+		err = restore()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("too many levels of links")
+}
+
+// cleanEntryName cleans in archive path and removes leading slash
+func cleanEntryName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// isGlob checks whether in archive path is a glob pattern
+func isGlob(p string) bool {
+	return strings.ContainsAny(p, "*?[{")
+}
+
+var _ fs.FileInfo = (*fileInfo)(nil)
+
+// fileInfo of entries in archive formats without fs.FileInfo support
+type fileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i *fileInfo) Name() string       { return path.Base(i.name) }
+func (i *fileInfo) Size() int64        { return i.size }
+func (i *fileInfo) Mode() fs.FileMode  { return i.mode }
+func (i *fileInfo) ModTime() time.Time { return i.modTime }
+func (i *fileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *fileInfo) Sys() interface{}   { return nil }
+
+// unixMode converts unix file mode bits (including file type bits)
+// to fs.FileMode
+func unixMode(m uint32) fs.FileMode {
+	mode := fs.FileMode(m & 0777)
+	switch m & 0170000 {
+	case 0040000:
+		mode |= fs.ModeDir
+	case 0120000:
+		mode |= fs.ModeSymlink
+	case 0010000:
+		mode |= fs.ModeNamedPipe
+	case 0140000:
+		mode |= fs.ModeSocket
+	case 0020000:
+		mode |= fs.ModeDevice | fs.ModeCharDevice
+	case 0060000:
+		mode |= fs.ModeDevice
+	}
+
+	if m&04000 != 0 {
+		mode |= fs.ModeSetuid
+	}
+
+	if m&02000 != 0 {
+		mode |= fs.ModeSetgid
+	}
+
+	if m&01000 != 0 {
+		mode |= fs.ModeSticky
+	}
+
+	return mode
+}
@@ -27,6 +27,104 @@ func unzip(src SizedReaderAt, target, password string) (io.Reader, error) {
 	// TODO: support encrypted zip file
 	_ = password
 
+	r, err := newZipReader(src)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		f, err := r.Open(strings.TrimPrefix(target, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("unzip: %w", err)
+		}
+
+		info, err := f.Stat()
+		if err != nil {
+			return nil, fmt.Errorf("unzip: %w", err)
+		}
+
+		switch m := info.Mode() & fs.ModeType; m {
+		case 0:
+			// file
+			return f, nil
+		case fs.ModeSymlink:
+			// TODO: redirect to links
+			targetBytes, err := io.ReadAll(f)
+			if err != nil {
+				return nil, err
+			}
+
+			target = pathhelper.EvalLink(info.Name(), string(targetBytes))
+			continue
+		default:
+			return nil, fmt.Errorf("unzip: unsupported non regular file %q: %v", info.Name(), m)
+		}
+	}
+}
+
+// nolint:unparam
+func walkZip(src SizedReaderAt, password string, fn walkFunc) error {
+	// TODO: support encrypted zip file
+	_ = password
+
+	r, err := newZipReader(src)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range r.File {
+		f := f
+		e := &entry{
+			name: cleanEntryName(f.Name),
+			info: f.FileInfo(),
+		}
+
+		var rc io.ReadCloser
+		e.open = func() (io.Reader, error) {
+			if rc == nil {
+				var err2 error
+				rc, err2 = f.Open()
+				if err2 != nil {
+					return nil, fmt.Errorf("unzip: %w", err2)
+				}
+			}
+
+			return rc, nil
+		}
+
+		if e.info.Mode()&fs.ModeSymlink != 0 {
+			var target []byte
+			rd, err2 := e.open()
+			if err2 == nil {
+				target, err2 = io.ReadAll(rd)
+			}
+
+			if rc != nil {
+				_ = rc.Close()
+				rc = nil
+			}
+
+			if err2 != nil {
+				return err2
+			}
+
+			e.linkname = string(target)
+		}
+
+		err = fn(e)
+		if rc != nil {
+			_ = rc.Close()
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newZipReader(src SizedReaderAt) (*zip.Reader, error) {
 	r, err := zip.NewReader(src, src.Size())
 	if err != nil {
 		return nil, err
@@ -61,32 +159,5 @@ func unzip(src SizedReaderAt, target, password string) (io.Reader, error) {
 		return ioutil.NopCloser(xr)
 	})
 
-	for {
-		f, err := r.Open(strings.TrimPrefix(target, "/"))
-		if err != nil {
-			return nil, fmt.Errorf("unzip: %w", err)
-		}
-
-		info, err := f.Stat()
-		if err != nil {
-			return nil, fmt.Errorf("unzip: %w", err)
-		}
-
-		switch m := info.Mode() & fs.ModeType; m {
-		case 0:
-			// file
-			return f, nil
-		case fs.ModeSymlink:
-			// TODO: redirect to links
-			targetBytes, err := io.ReadAll(f)
-			if err != nil {
-				return nil, err
-			}
-
-			target = pathhelper.EvalLink(info.Name(), string(targetBytes))
-			continue
-		default:
-			return nil, fmt.Errorf("unzip: unsupported non regular file %q: %v", info.Name(), m)
-		}
-	}
+	return r, nil
 }
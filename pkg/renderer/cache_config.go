@@ -17,13 +17,15 @@ type CacheConfig struct {
 	// * for renderers reading data directly from local disk (e.g. file):
 	//     will cache content in memory with size limit applied
 	// * for renderers doing remote fetch (e.g. http, git, af):
-	//     will cache data on local disk first (cache size limiting is not effective at this time)
-	// 	   then cache data in memory with size limit applied
+	//     will cache data on local disk first, then cache data in memory,
+	//     size limits are applied to both local cache files and memory,
+	//     data is also shared through remote cache when `--remote-cache` is set
 	//
 	// Defaults to `false`
 	Enabled bool `yaml:"enabled"`
 
-	// MaxItemSize is the maximum size limit an item can be cached in memory
+	// MaxItemSize is the maximum size limit an item can be cached in memory,
+	// also applied to local cache files unless `max_disk_item_size` is set
 	//
 	// Format: <number><unit>
 	// 	where unit can be one of: [ , B, KB, MB, GB, TB, PB]
@@ -31,7 +33,8 @@ type CacheConfig struct {
 	// Defaults to `0` (no size limit for single item)
 	MaxItemSize utils.Size `yaml:"max_item_size"`
 
-	// Size limits maximum in memory size of cached content
+	// Size limits maximum in memory size of cached content, also applied to
+	// local cache files unless `disk_size` is set
 	//
 	// Format: <number><unit>
 	// 	where unit can be one of: [ , B, KB, MB, GB, TB, PB]
@@ -39,6 +42,24 @@ type CacheConfig struct {
 	// Defaults to `0` (no size limit)
 	Size utils.Size `yaml:"size"`
 
+	// MaxDiskItemSize is the maximum size limit of a local cache file,
+	// files larger than this limit are removed once not in use
+	//
+	// Format: <number><unit>
+	// 	where unit can be one of: [ , B, KB, MB, GB, TB, PB]
+	//
+	// Defaults to `0` (using value of `max_item_size`)
+	MaxDiskItemSize utils.Size `yaml:"max_disk_item_size"`
+
+	// DiskSize limits total size of local cache files, least recently used
+	// files are removed first
+	//
+	// Format: <number><unit>
+	// 	where unit can be one of: [ , B, KB, MB, GB, TB, PB]
+	//
+	// Defaults to `0` (using value of `size`)
+	DiskSize utils.Size `yaml:"disk_size"`
+
 	// Timeout is the data caching duration
 	//
 	// if caching is enabled and this option is set to 0:
@@ -48,3 +69,18 @@ type CacheConfig struct {
 	// Defaults to `0`
 	Timeout time.Duration `yaml:"timeout"`
 }
+
+// diskLimits returns size limits of local cache files, falls back to
+// in memory size limits when not set
+func (c *CacheConfig) diskLimits() (itemMaxBytes, maxBytes int64) {
+	itemMaxBytes, maxBytes = int64(c.MaxDiskItemSize), int64(c.DiskSize)
+	if itemMaxBytes == 0 {
+		itemMaxBytes = int64(c.MaxItemSize)
+	}
+
+	if maxBytes == 0 {
+		maxBytes = int64(c.Size)
+	}
+
+	return
+}
@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"arhat.dev/pkg/rshelper"
+	"arhat.dev/pkg/yamlhelper"
+	"arhat.dev/rs"
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/cache"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/renderer"
+)
+
+const (
+	DefaultName = "cmd"
+)
+
+// attributes decoding command output as structured data
+const (
+	attrJSON = "json"
+	attrYAML = "yaml"
+)
+
+func init() { dukkha.RegisterRenderer(DefaultName, NewDefault) }
+
+func NewDefault(name string) dukkha.Renderer {
+	return &Driver{name: name}
+}
+
+var _ dukkha.Renderer = (*Driver)(nil)
+
+// Driver runs commands without shell and uses output from stdout as result
+type Driver struct {
+	rs.BaseField `yaml:"-"`
+
+	renderer.BaseTwoTierCachedRenderer `yaml:",inline"`
+
+	name string
+
+	DefaultConfig rendererCmdConfig `yaml:",inline"`
+}
+
+func (d *Driver) RenderYaml(
+	rc dukkha.RenderingContext, rawData interface{}, attributes []dukkha.RendererAttribute,
+) ([]byte, error) {
+	rawData, err := rs.NormalizeRawData(rawData)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		attrs      = d.Attributes(attributes)
+		cachedFile bool
+		format     string
+	)
+	for _, attr := range attrs {
+		switch attr {
+		case renderer.AttrCachedFile:
+			cachedFile = true
+		case attrJSON, attrYAML:
+			format = string(attr)
+		default:
+		}
+	}
+
+	if cachedFile && len(format) != 0 {
+		return nil, fmt.Errorf(
+			"renderer.%s: attribute %q does not apply to %q",
+			d.name, renderer.AttrCachedFile, format,
+		)
+	}
+
+	var (
+		spec   = &inputCmdSpec{}
+		config = &d.DefaultConfig
+	)
+
+	switch t := rawData.(type) {
+	case []interface{}:
+		for _, v := range t {
+			switch v.(type) {
+			case map[string]interface{}, []interface{}:
+				return nil, fmt.Errorf(
+					"renderer.%s: unexpected non scalar arg %T", d.name, v,
+				)
+			}
+
+			spec.Cmd = append(spec.Cmd, fmt.Sprint(v))
+		}
+	case string, []byte:
+		return nil, fmt.Errorf(
+			"renderer.%s: unsupported string input, expecting list of args or input spec",
+			d.name,
+		)
+	default:
+		var rawBytes []byte
+		rawBytes, err = yamlhelper.ToYamlBytes(rawData)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"renderer.%s: unexpected non yaml input: %w",
+				d.name, err,
+			)
+		}
+
+		spec = rshelper.InitAll(&inputCmdSpec{}, &rs.Options{
+			InterfaceTypeHandler: rc,
+		}).(*inputCmdSpec)
+		err = yaml.Unmarshal(rawBytes, spec)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"renderer.%s: unmarshal input spec: %w",
+				d.name, err,
+			)
+		}
+
+		err = spec.ResolveFields(rc, -1)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"renderer.%s: resolving input spec: %w",
+				d.name, err,
+			)
+		}
+
+		config = &spec.Config
+	}
+
+	if len(spec.Cmd) == 0 {
+		return nil, fmt.Errorf("renderer.%s: no command provided", d.name)
+	}
+
+	c := &command{
+		argv:   spec.Cmd,
+		stdin:  spec.Stdin,
+		config: config,
+		dir:    rc.WorkDir(),
+	}
+
+	if len(config.Chdir) != 0 {
+		c.dir, err = rc.FS().Abs(config.Chdir)
+		if err != nil {
+			return nil, fmt.Errorf("renderer.%s: invalid chdir: %w", d.name, err)
+		}
+	}
+
+	data, err := d.getOutput(rc, c, attrs, cachedFile)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"renderer.%s: running command [ %s ]: %w",
+			d.name, strings.Join(spec.Cmd, " "), err,
+		)
+	}
+
+	data, err = decode(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("renderer.%s: %w", d.name, err)
+	}
+
+	return data, nil
+}
+
+// getOutput runs the command directly when caching is not enabled,
+// otherwise output is served from cache when possible
+//
+// unlike renderers fetching remote content, commands are still run in
+// offline mode
+func (d *Driver) getOutput(
+	rc dukkha.RenderingContext,
+	c *command,
+	attributes []dukkha.RendererAttribute,
+	cachedFile bool,
+) ([]byte, error) {
+	if !d.CacheConfig.Enabled && !cachedFile {
+		return c.run(rc)
+	}
+
+	key, err := c.cacheKey(rc.FS())
+	if err != nil {
+		return nil, err
+	}
+
+	return renderer.HandleRenderingRequestWithRemoteFetch(
+		d.Cache,
+		cache.IdentifiableString(key),
+		func(_ cache.IdentifiableObject) (io.ReadCloser, error) {
+			data, err2 := c.run(rc)
+			if err2 != nil {
+				return nil, err2
+			}
+
+			return io.NopCloser(bytes.NewReader(data)), nil
+		},
+		attributes,
+		renderer.FetchOptions{FetchWhenOffline: true},
+	)
+}
+
+// run the command with env overrides, returns output from stdout
+func (c *command) run(rc dukkha.RenderingContext) ([]byte, error) {
+	var ctx context.Context = rc
+	if timeout := c.config.Timeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(rc, timeout)
+		defer cancel()
+	}
+
+	env := make([]string, 0, len(rc.Env())+len(c.config.Env))
+	for k, v := range rc.Env() {
+		env = append(env, k+"="+v.Get())
+	}
+
+	// later entries take precedence
+	for _, e := range c.config.Env {
+		env = append(env, e.Name+"="+e.Value)
+	}
+
+	var (
+		stdout = &bytes.Buffer{}
+		stderr = &bytes.Buffer{}
+	)
+
+	cmd := exec.CommandContext(ctx, c.argv[0], c.argv[1:]...)
+	cmd.Dir = c.dir
+	cmd.Env = env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if c.stdin != nil {
+		cmd.Stdin = strings.NewReader(*c.stdin)
+	}
+
+	err := cmd.Run()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timeout after %s: %w", c.config.Timeout, err)
+		}
+
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// decode command output according to the format attribute, and returns
+// it as yaml, output with multiple json values (e.g. `go list -json`) or
+// yaml documents is decoded as a list
+func decode(format string, data []byte) ([]byte, error) {
+	var values []interface{}
+	switch format {
+	case attrJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		for {
+			var v interface{}
+			err := dec.Decode(&v)
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("decoding json output: %w", err)
+			}
+
+			values = append(values, v)
+		}
+	case attrYAML:
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		for {
+			var v interface{}
+			err := dec.Decode(&v)
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("decoding yaml output: %w", err)
+			}
+
+			values = append(values, v)
+		}
+	default:
+		return data, nil
+	}
+
+	switch len(values) {
+	case 0:
+		return []byte("null"), nil
+	case 1:
+		return yaml.Marshal(values[0])
+	default:
+		return yaml.Marshal(values)
+	}
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	di "arhat.dev/dukkha/internal"
+	"arhat.dev/dukkha/pkg/dukkha"
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/renderer"
+)
+
+var _ dukkha.Renderer = (*Driver)(nil)
+
+// TestHelperProcess is the command run in tests, not a real test
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("DUKKHA_TEST_HELPER_PROCESS") != "1" {
+		return
+	}
+
+	args := os.Args
+	for i, arg := range args {
+		if arg == "--" {
+			args = args[i+1:]
+			break
+		}
+	}
+
+	switch args[0] {
+	case "info":
+		cwd, _ := os.Getwd()
+		stdin, _ := io.ReadAll(os.Stdin)
+		_ = json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"args":  args[1:],
+			"stdin": string(stdin),
+			"foo":   os.Getenv("FOO"),
+			"dir":   filepath.Base(cwd),
+		})
+	case "stream":
+		fmt.Println(`{"name": "a"}`)
+		fmt.Println(`{"name": "b"}`)
+	case "time":
+		fmt.Print(time.Now().UnixNano())
+	case "sleep":
+		time.Sleep(10 * time.Second)
+	default:
+		fmt.Fprint(os.Stderr, "unknown command "+args[0])
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+}
+
+func helperCmd(args ...string) []interface{} {
+	ret := []interface{}{os.Args[0], "-test.run=TestHelperProcess", "--"}
+	for _, arg := range args {
+		ret = append(ret, arg)
+	}
+
+	return ret
+}
+
+func newDriver(t *testing.T, d *Driver) (*Driver, dukkha.Context) {
+	rc := dt.NewTestContext(context.TODO())
+	rc.(di.CacheDirSetter).SetCacheDir(t.TempDir())
+	rc.AddEnv(true, &dukkha.EnvEntry{Name: "DUKKHA_TEST_HELPER_PROCESS", Value: "1"})
+	assert.NoError(t, d.Init(rc.RendererCacheFS("test")))
+
+	return d, rc
+}
+
+func TestNewDriver(t *testing.T) {
+	assert.NotNil(t, NewDefault(""))
+}
+
+func TestDriver_RenderYaml(t *testing.T) {
+	t.Run("Args", func(t *testing.T) {
+		d, rc := newDriver(t, &Driver{})
+		result, err := d.RenderYaml(rc, append(helperCmd("info", `C:\Program Files\foo`, "a b"), 1), nil)
+		assert.NoError(t, err)
+
+		var info map[string]interface{}
+		assert.NoError(t, json.Unmarshal(result, &info))
+		assert.EqualValues(t, []interface{}{`C:\Program Files\foo`, "a b", "1"}, info["args"])
+		assert.EqualValues(t, "", info["foo"])
+	})
+
+	t.Run("Spec", func(t *testing.T) {
+		d, rc := newDriver(t, &Driver{})
+		result, err := d.RenderYaml(rc, map[string]interface{}{
+			"cmd":   helperCmd("info"),
+			"stdin": "input",
+			"env": []interface{}{
+				map[string]interface{}{"name": "FOO", "value": "bar"},
+			},
+			"chdir": "..",
+		}, []dukkha.RendererAttribute{attrJSON})
+		assert.NoError(t, err)
+
+		var info map[string]interface{}
+		assert.NoError(t, yaml.Unmarshal(result, &info))
+		assert.EqualValues(t, map[string]interface{}{
+			"args":  []interface{}{},
+			"stdin": "input",
+			"foo":   "bar",
+			"dir":   "renderer",
+		}, info)
+	})
+
+	t.Run("JSON Stream", func(t *testing.T) {
+		d, rc := newDriver(t, &Driver{})
+		result, err := d.RenderYaml(rc, helperCmd("stream"), []dukkha.RendererAttribute{attrJSON})
+		assert.NoError(t, err)
+		assert.Equal(t, "- name: a\n- name: b\n", string(result))
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		d, rc := newDriver(t, &Driver{})
+		_, err := d.RenderYaml(rc, helperCmd("invalid"), nil)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "unknown command invalid")
+		}
+
+		_, err = d.RenderYaml(rc, "echo foo", nil)
+		assert.Error(t, err)
+
+		_, err = d.RenderYaml(rc, []interface{}{}, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		d, rc := newDriver(t, &Driver{
+			DefaultConfig: rendererCmdConfig{Timeout: 100 * time.Millisecond},
+		})
+
+		start := time.Now()
+		_, err := d.RenderYaml(rc, helperCmd("sleep"), nil)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "timeout after 100ms")
+		}
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("Cache", func(t *testing.T) {
+		dir := t.TempDir()
+		input := filepath.Join(dir, "input.txt")
+		assert.NoError(t, os.WriteFile(input, []byte("a"), 0600))
+
+		d, rc := newDriver(t, &Driver{
+			BaseTwoTierCachedRenderer: renderer.BaseTwoTierCachedRenderer{
+				CacheConfig: renderer.CacheConfig{Enabled: true, Timeout: time.Hour},
+			},
+			DefaultConfig: rendererCmdConfig{
+				InputFiles: []string{filepath.Join(dir, "*.txt")},
+			},
+		})
+
+		render := func() string {
+			result, err := d.RenderYaml(rc, helperCmd("time"), nil)
+			assert.NoError(t, err)
+			return string(result)
+		}
+
+		first := render()
+		assert.Equal(t, first, render())
+
+		// other args
+		result, err := d.RenderYaml(rc, helperCmd("time", "foo"), nil)
+		assert.NoError(t, err)
+		assert.NotEqual(t, first, string(result))
+
+		// input file changed
+		assert.NoError(t, os.WriteFile(input, []byte("b"), 0600))
+		second := render()
+		assert.NotEqual(t, first, second)
+		assert.Equal(t, second, render())
+	})
+}
+
+func TestDecode(t *testing.T) {
+	for _, test := range []struct {
+		format   string
+		data     string
+		expected string
+	}{
+		{"", "a: b", "a: b"},
+		{attrJSON, "", "null"},
+		{attrJSON, `{"a": "b"}`, "a: b\n"},
+		{attrJSON, `{"a": 1} {"a": 2}`, "- a: 1\n- a: 2\n"},
+		{attrYAML, "a: b", "a: b\n"},
+		{attrYAML, "a: 1\n---\na: 2\n", "- a: 1\n- a: 2\n"},
+	} {
+		ret, err := decode(test.format, []byte(test.data))
+		assert.NoError(t, err, test.data)
+		assert.Equal(t, test.expected, string(ret), test.data)
+	}
+
+	_, err := decode(attrJSON, []byte("{"))
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "json"))
+}
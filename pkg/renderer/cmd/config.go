@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"arhat.dev/pkg/fshelper"
+	"arhat.dev/rs"
+	"github.com/bmatcuk/doublestar/v4"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+type rendererCmdConfig struct {
+	rs.BaseField `yaml:"-"`
+
+	// Env to set for the command, in addition to dukkha env
+	Env dukkha.Env `yaml:"env"`
+
+	// Chdir is the working dir of the command, relative path is relative
+	// to DUKKHA_WORKDIR
+	//
+	// Defaults to DUKKHA_WORKDIR
+	Chdir string `yaml:"chdir"`
+
+	// Timeout of the command, the command is killed when exceeded
+	//
+	// Defaults to `0` (no timeout)
+	Timeout time.Duration `yaml:"timeout"`
+
+	// InputFiles are paths or glob patterns of files read by the command,
+	// content of these files is part of the cache key, so cached output
+	// is invalidated once they are changed
+	InputFiles []string `yaml:"input_files"`
+}
+
+// inputCmdSpec for renderer value
+type inputCmdSpec struct {
+	rs.BaseField `yaml:"-"`
+
+	// Cmd is the command and its arguments, passed to the command as is
+	// without any shell parsing
+	Cmd []string `yaml:"cmd"`
+
+	// Stdin is the data written to stdin of the command
+	Stdin *string `yaml:"stdin"`
+
+	// Config of the renderer input
+	Config rendererCmdConfig `yaml:",inline"`
+}
+
+// command is the resolved command to run
+type command struct {
+	argv   []string
+	stdin  *string
+	config *rendererCmdConfig
+
+	// dir is the absolute path of working dir
+	dir string
+}
+
+// cacheKey identifies output of the command by its argv, stdin, env
+// overrides, working dir and content of input files
+func (c *command) cacheKey(ofs *fshelper.OSFS) (string, error) {
+	h := sha256.New()
+	for _, arg := range c.argv {
+		_, _ = h.Write([]byte(strconv.Quote(arg) + " "))
+	}
+
+	_, _ = h.Write([]byte("\ndir: " + c.dir))
+	if c.stdin != nil {
+		sum := sha256.Sum256([]byte(*c.stdin))
+		_, _ = h.Write([]byte("\nstdin-sha256: " + hex.EncodeToString(sum[:])))
+	}
+
+	for _, e := range c.config.Env {
+		_, _ = h.Write([]byte("\nenv: " + strconv.Quote(e.Name+"="+e.Value)))
+	}
+
+	files, err := collectInputFiles(ofs, c.config.InputFiles)
+	if err != nil {
+		return "", err
+	}
+
+	for _, f := range files {
+		data, err := ofs.ReadFile(f)
+		if err != nil {
+			return "", fmt.Errorf("reading input file: %w", err)
+		}
+
+		sum := sha256.Sum256(data)
+		_, _ = h.Write([]byte("\nfile-sha256: " + strconv.Quote(f) + " " + hex.EncodeToString(sum[:])))
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// collectInputFiles returns sorted paths of regular files matched by
+// patterns, directories are ignored
+func collectInputFiles(ofs *fshelper.OSFS, patterns []string) ([]string, error) {
+	seen := make(map[string]struct{})
+	for _, p := range patterns {
+		if !strings.ContainsAny(p, "*?[{") {
+			seen[p] = struct{}{}
+			continue
+		}
+
+		base, pattern := doublestar.SplitPattern(p)
+		if !doublestar.ValidatePattern(pattern) {
+			return nil, fmt.Errorf("invalid glob pattern %q", p)
+		}
+
+		subFS, err := ofs.Sub(base)
+		if err != nil {
+			return nil, err
+		}
+
+		matches, err := doublestar.Glob(subFS, pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range matches {
+			target := path.Join(base, name)
+
+			var info fs.FileInfo
+			info, err = ofs.Stat(target)
+			if err != nil {
+				return nil, err
+			}
+
+			if !info.IsDir() {
+				seen[target] = struct{}{}
+			}
+		}
+	}
+
+	ret := make([]string, 0, len(seen))
+	for f := range seen {
+		ret = append(ret, f)
+	}
+
+	sort.Strings(ret)
+	return ret, nil
+}
@@ -0,0 +1,329 @@
+package cue
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"arhat.dev/pkg/yamlhelper"
+	"arhat.dev/rs"
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/load"
+	"cuelang.org/go/cue/parser"
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/renderer"
+)
+
+const (
+	DefaultName = "cue"
+)
+
+const (
+	// AttrValidate validates input against cue schema and returns input as is
+	AttrValidate = "validate"
+)
+
+// names of generated files
+const (
+	inlineFilename = "dukkha_input.cue"
+	dukkhaFilename = "dukkha_gen.cue"
+)
+
+func init() { dukkha.RegisterRenderer(DefaultName, NewDefault) }
+
+func NewDefault(name string) dukkha.Renderer {
+	return &Driver{
+		name: name,
+	}
+}
+
+var _ dukkha.Renderer = (*Driver)(nil)
+
+// Driver evaluates cue code
+type Driver struct {
+	rs.BaseField `yaml:"-"`
+
+	renderer.BaseRenderer `yaml:",inline"`
+
+	name string
+
+	Options configSpec `yaml:",inline"`
+}
+
+func (d *Driver) RenderYaml(
+	rc dukkha.RenderingContext, rawData interface{},
+	attributes []dukkha.RendererAttribute,
+) ([]byte, error) {
+	rawData, err := rs.NormalizeRawData(rawData)
+	if err != nil {
+		return nil, err
+	}
+
+	inputBytes, err := yamlhelper.ToYamlBytes(rawData)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"renderer.%s: unsupported input type %T: %w",
+			d.name, rawData, err,
+		)
+	}
+
+	var (
+		useSpec  bool
+		validate bool
+	)
+	for _, attr := range d.Attributes(attributes) {
+		switch attr {
+		case renderer.AttrUseSpec:
+			useSpec = true
+		case AttrValidate:
+			validate = true
+		default:
+		}
+	}
+
+	var (
+		config = &d.Options
+		code   string
+		value  []byte
+	)
+
+	switch {
+	case useSpec:
+		spec := rs.Init(&inputSpec{}, nil).(*inputSpec)
+		err = yaml.Unmarshal(inputBytes, spec)
+		if err != nil {
+			return nil, fmt.Errorf("renderer.%s: invalid input spec: %w", d.name, err)
+		}
+
+		err = spec.ResolveFields(rc, -1)
+		if err != nil {
+			return nil, fmt.Errorf("renderer.%s: resolving input spec: %w", d.name, err)
+		}
+
+		config, code = &spec.Config, spec.Code
+		if validate {
+			value, err = yamlhelper.ToYamlBytes(spec.Value.NormalizedValue())
+			if err != nil {
+				return nil, fmt.Errorf("renderer.%s: invalid value: %w", d.name, err)
+			}
+		}
+	case validate:
+		value = inputBytes
+	default:
+		code = string(inputBytes)
+	}
+
+	var ret []byte
+	if validate {
+		err = d.validate(rc, config, code, value)
+		ret = value
+	} else {
+		ret, err = d.export(rc, config, code)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("renderer.%s: %w", d.name, err)
+	}
+
+	return ret, nil
+}
+
+// export evaluates cue files and inline code, returns json output
+func (d *Driver) export(rc dukkha.RenderingContext, config *configSpec, code string) ([]byte, error) {
+	v, err := d.evaluate(rc, config, code)
+	if err != nil {
+		return nil, err
+	}
+
+	err = v.Validate(cue.Concrete(true))
+	if err != nil {
+		return nil, formatError(rc, err)
+	}
+
+	ret, err := v.MarshalJSON()
+	if err != nil {
+		return nil, formatError(rc, err)
+	}
+
+	return ret, nil
+}
+
+// validate checks value against the schema expression
+func (d *Driver) validate(rc dukkha.RenderingContext, config *configSpec, code string, value []byte) error {
+	schema, err := d.evaluate(rc, config, code)
+	if err != nil {
+		return err
+	}
+
+	var data interface{}
+	err = yaml.Unmarshal(value, &data)
+	if err != nil {
+		return fmt.Errorf("decoding value to validate: %w", err)
+	}
+
+	v := schema.Context().Encode(data)
+	if err = v.Err(); err != nil {
+		return fmt.Errorf("encoding value to validate: %w", err)
+	}
+
+	err = schema.Unify(v).Validate(cue.Concrete(true))
+	if err != nil {
+		return formatError(rc, err)
+	}
+
+	return nil
+}
+
+// evaluate loads all cue files, inline code and the generated dukkha values
+// as a single instance, returns value of the expression (if any)
+//
+// cue files are loaded in place, so imports are resolved from the cue module
+// containing DUKKHA_WORKDIR (if any)
+func (d *Driver) evaluate(
+	rc dukkha.RenderingContext,
+	config *configSpec,
+	code string,
+) (cue.Value, error) {
+	var (
+		files   []string
+		pkg     string
+		overlay = make(map[string]load.Source)
+	)
+
+	for _, f := range config.Files {
+		file, err := rc.FS().Abs(f)
+		if err != nil {
+			return cue.Value{}, fmt.Errorf("resolving cue file path: %w", err)
+		}
+
+		if len(pkg) == 0 {
+			var data []byte
+			data, err = rc.FS().ReadFile(f)
+			if err != nil {
+				return cue.Value{}, fmt.Errorf("reading cue file: %w", err)
+			}
+
+			pkg = packageName(data)
+		}
+
+		files = append(files, file)
+	}
+
+	if len(code) != 0 {
+		if p := packageName([]byte(code)); len(p) != 0 {
+			pkg = p
+		}
+
+		file := filepath.Join(rc.WorkDir(), inlineFilename)
+		overlay[file] = load.FromString(code)
+		files = append(files, file)
+	}
+
+	if len(files) == 0 {
+		return cue.Value{}, fmt.Errorf("no cue code or file provided")
+	}
+
+	dukkhaCue, err := generateDukkhaCue(rc, pkg)
+	if err != nil {
+		return cue.Value{}, err
+	}
+
+	file := filepath.Join(rc.WorkDir(), dukkhaFilename)
+	overlay[file] = load.FromBytes(dukkhaCue)
+	files = append(files, file)
+
+	insts := load.Instances(files, &load.Config{
+		Dir:     rc.WorkDir(),
+		Overlay: overlay,
+	})
+
+	// files are loaded as a single instance
+	inst := insts[0]
+	if inst.Err != nil {
+		return cue.Value{}, formatError(rc, inst.Err)
+	}
+
+	v := cuecontext.New().BuildInstance(inst)
+	if err = v.Err(); err != nil {
+		return cue.Value{}, formatError(rc, err)
+	}
+
+	if len(config.Expression) == 0 {
+		return v, nil
+	}
+
+	expr, err := parser.ParseExpr("expression", config.Expression)
+	if err != nil {
+		return cue.Value{}, formatError(rc, err)
+	}
+
+	v = v.Context().BuildExpr(expr, cue.Scope(v), cue.InferBuiltins(true))
+	if err = v.Err(); err != nil {
+		return cue.Value{}, formatError(rc, err)
+	}
+
+	return v, nil
+}
+
+// formatError formats cue errors with positions relative to DUKKHA_WORKDIR
+func formatError(rc dukkha.RenderingContext, err error) error {
+	return fmt.Errorf("%s", strings.TrimSpace(errors.Details(err, &errors.Config{
+		Cwd: rc.WorkDir(),
+	})))
+}
+
+// generateDukkhaCue generates cue source with hidden field `_dukkha`
+// containing env, values and matrix, hidden fields are only visible in
+// the same package, so it's generated with the package name of the input
+func generateDukkhaCue(rc dukkha.RenderingContext, pkg string) ([]byte, error) {
+	env := make(map[string]string)
+	for k, v := range rc.Env() {
+		env[k] = v.Get()
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"env":    env,
+		"values": rc.Values(),
+		"matrix": rc.MatrixFilter().AsEntry(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encoding dukkha values: %w", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if len(pkg) != 0 {
+		buf.WriteString("package " + pkg + "\n\n")
+	}
+
+	buf.WriteString("_dukkha: ")
+	buf.Write(data)
+	buf.WriteString("\n")
+
+	return buf.Bytes(), nil
+}
+
+// packageName finds the package clause in cue source
+func packageName(src []byte) string {
+	for _, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case len(line) == 0,
+			strings.HasPrefix(line, "//"),
+			// file attributes
+			strings.HasPrefix(line, "@"):
+			continue
+		case strings.HasPrefix(line, "package "), strings.HasPrefix(line, "package\t"):
+			return strings.TrimSpace(strings.TrimPrefix(line, "package"))
+		default:
+			return ""
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,222 @@
+package cue
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"arhat.dev/rs"
+	"github.com/stretchr/testify/assert"
+
+	di "arhat.dev/dukkha/internal"
+	"arhat.dev/dukkha/pkg/dukkha"
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/renderer"
+)
+
+func TestNewDriver(t *testing.T) {
+	assert.NotNil(t, NewDefault(""))
+}
+
+func TestPackageName(t *testing.T) {
+	for _, test := range []struct {
+		src      string
+		expected string
+	}{
+		{"", ""},
+		{"a: 1", ""},
+		{"package foo\n\na: 1", "foo"},
+		{"// comment\n\n@extern(embed)\npackage\tbar\n", "bar"},
+		{"a: 1\npackage foo", ""},
+	} {
+		assert.Equal(t, test.expected, packageName([]byte(test.src)), test.src)
+	}
+}
+
+func TestGenerateDukkhaCue(t *testing.T) {
+	rc := dt.NewTestContext(context.TODO())
+	assert.NoError(t, rc.AddValues(map[string]interface{}{"foo": "bar"}))
+
+	data, err := generateDukkhaCue(rc, "")
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `_dukkha: {`)
+	assert.Contains(t, string(data), `"values":{"foo":"bar"}`)
+	assert.NotContains(t, string(data), "package")
+
+	data, err = generateDukkhaCue(rc, "config")
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "package config\n\n_dukkha: {")
+}
+
+func TestDriver_RenderYaml(t *testing.T) {
+	withSchema := NewDefault("cue-schema").(*Driver)
+	withSchema.Options.Files = []string{"testdata/schema.cue"}
+	withSchema.Options.Expression = "#Config"
+
+	dt.TestFixturesUsingRenderingSuffix(t, "./fixtures",
+		map[string]dukkha.Renderer{
+			DefaultName:  NewDefault(DefaultName),
+			"cue-schema": withSchema,
+		},
+		func() rs.Field { return &rs.AnyObjectMap{} },
+		func() rs.Field { return &rs.AnyObjectMap{} },
+		func(t *testing.T, ctx dukkha.Context, ts, cs rs.Field) {
+			actual, expected := ts.(*rs.AnyObjectMap), cs.(*rs.AnyObjectMap)
+
+			assert.EqualValues(t, expected.NormalizedValue(), actual.NormalizedValue())
+		},
+	)
+}
+
+func TestDriver_Validate(t *testing.T) {
+	rc := dt.NewTestContext(context.TODO())
+	d := NewDefault(DefaultName).(*Driver)
+	d.Options.Files = []string{"testdata/schema.cue"}
+	d.Options.Expression = "#Config"
+
+	for _, test := range []struct {
+		name     string
+		value    map[string]interface{}
+		expected string
+	}{
+		{
+			name:     "Out of Bound",
+			value:    map[string]interface{}{"replicas": 20},
+			expected: "replicas: invalid value 20 (out of bound <=10):\n    ./testdata/schema.cue:4:",
+		},
+		{
+			name:     "Pattern Mismatch",
+			value:    map[string]interface{}{"replicas": 1, "image_names": []interface{}{"Foo"}},
+			expected: `image_names.0: invalid value "Foo"`,
+		},
+		{
+			name:     "Closed Definition",
+			value:    map[string]interface{}{"replicas": 1, "unknown": true},
+			expected: "#Config: field not allowed: unknown",
+		},
+		{
+			name:     "Incomplete",
+			value:    map[string]interface{}{"image_names": []interface{}{}},
+			expected: "replicas: incomplete value",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := d.RenderYaml(rc, test.value, []dukkha.RendererAttribute{AttrValidate})
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), "renderer.cue: ")
+				assert.Contains(t, err.Error(), test.expected)
+			}
+		})
+	}
+}
+
+func TestDriver_RenderYaml_Files(t *testing.T) {
+	dir := t.TempDir()
+	for f, content := range map[string]string{
+		"cue.mod/module.cue": `module: "example.com/app"`,
+		"lib/lib.cue":        "package lib\n\n#Port: int & >0 & <65536\n",
+		"a/schema.cue":       "package config\n\nimport \"example.com/app/lib\"\n\n#Config: port: lib.#Port\n",
+		"b/schema.cue":       "package config\n\nname: _dukkha.values.name\n",
+	} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(f)), 0755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, f), []byte(content), 0644))
+	}
+
+	rc := dt.NewTestContext(context.TODO())
+	rc.(di.WorkDirOverrider).OverrideWorkDir(dir)
+	assert.NoError(t, rc.AddValues(map[string]interface{}{"name": "foo"}))
+
+	newDriver := func() *Driver {
+		d := NewDefault(DefaultName).(*Driver)
+		d.Options.Files = []string{"a/schema.cue", "b/schema.cue"}
+		return d
+	}
+
+	t.Run("Export", func(t *testing.T) {
+		ret, err := newDriver().RenderYaml(rc, "package config\n\nport: #Config.port & 8080", nil)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"name": "foo", "port": 8080}`, string(ret))
+	})
+
+	t.Run("Expression", func(t *testing.T) {
+		d := newDriver()
+		d.Options.Expression = "[name, strings.ToUpper(name)]"
+
+		ret, err := d.RenderYaml(rc, "package config", nil)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `["foo", "FOO"]`, string(ret))
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		d := newDriver()
+		d.Options.Expression = "#Config"
+
+		ret, err := d.RenderYaml(rc, map[string]interface{}{
+			"port": 80,
+		}, []dukkha.RendererAttribute{AttrValidate})
+		assert.NoError(t, err)
+		assert.Equal(t, "port: 80\n", string(ret))
+
+		_, err = d.RenderYaml(rc, map[string]interface{}{
+			"port": 65536,
+		}, []dukkha.RendererAttribute{AttrValidate})
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "port: invalid value 65536 (out of bound <65536)")
+			// position of the constraint in imported package
+			assert.Contains(t, err.Error(), filepath.Join("lib", "lib.cue"))
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, test := range []struct {
+			name     string
+			files    []string
+			code     string
+			expected string
+		}{
+			{
+				name:     "Syntax Error",
+				code:     "package config\n\nfoo: {",
+				expected: "expected '}', found 'EOF'",
+			},
+			{
+				name:     "Conflict",
+				code:     "package config\n\nname: \"bar\"",
+				expected: `name: conflicting values "foo" and "bar"`,
+			},
+			{
+				name:     "Missing File",
+				files:    []string{"c/schema.cue"},
+				code:     "a: 1",
+				expected: "no such file or directory",
+			},
+			{
+				name:     "Missing Import",
+				code:     "package config\n\nimport \"example.com/app/missing\"\n\nfoo: missing.foo",
+				expected: `cannot find package "example.com/app/missing"`,
+			},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				d := newDriver()
+				if test.files != nil {
+					d.Options.Files = test.files
+				}
+
+				_, err := d.RenderYaml(rc, test.code, nil)
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), test.expected)
+				}
+			})
+		}
+	})
+
+	t.Run("No Input", func(t *testing.T) {
+		d := newDriver()
+		d.Options.Files = nil
+		_, err := d.RenderYaml(rc, map[string]interface{}{
+			"code": "",
+		}, []dukkha.RendererAttribute{renderer.AttrUseSpec})
+		assert.Error(t, err)
+	})
+}
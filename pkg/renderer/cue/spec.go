@@ -0,0 +1,30 @@
+package cue
+
+import "arhat.dev/rs"
+
+type configSpec struct {
+	rs.BaseField
+
+	// Files are cue files loaded along with the input
+	//
+	// relative paths are relative to DUKKHA_WORKDIR
+	Files []string `yaml:"files"`
+
+	// Expression to evaluate and export instead of the top level value
+	//
+	// when validating, it's the schema expression (e.g. `#Config`) the
+	// rendered value should conform to
+	Expression string `yaml:"expression"`
+}
+
+type inputSpec struct {
+	rs.BaseField
+
+	// Code is the inline cue source
+	Code string `yaml:"code"`
+
+	// Value to validate when `validate` attribute applied
+	Value rs.AnyObject `yaml:"value"`
+
+	Config configSpec `yaml:",inline"`
+}
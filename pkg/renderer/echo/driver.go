@@ -24,6 +24,9 @@ type Driver struct {
 	name string
 }
 
+// ConcurrencySafe implements dukkha.ConcurrentRenderer
+func (d *Driver) ConcurrencySafe() bool { return true }
+
 func (d *Driver) RenderYaml(
 	_ dukkha.RenderingContext, rawData interface{}, _ []dukkha.RendererAttribute,
 ) ([]byte, error) {
@@ -3,7 +3,6 @@ package file
 import (
 	"encoding/hex"
 	"fmt"
-	"io/fs"
 	"strings"
 
 	"arhat.dev/pkg/fshelper"
@@ -39,6 +38,9 @@ type Driver struct {
 	BasePath string `yaml:"base_path"`
 }
 
+// ConcurrencySafe implements dukkha.ConcurrentRenderer
+func (d *Driver) ConcurrencySafe() bool { return true }
+
 func (d *Driver) RenderYaml(
 	rc dukkha.RenderingContext,
 	rawData interface{},
@@ -55,8 +57,10 @@ func (d *Driver) RenderYaml(
 	}
 
 	var (
-		cacheData  bool
-		cachedFile bool
+		cacheData   bool
+		cachedFile  bool
+		formatOpts  formatOptions
+		collectOpts collectOptions
 	)
 	for _, attr := range d.Attributes(attributes) {
 		switch attr {
@@ -64,19 +68,61 @@ func (d *Driver) RenderYaml(
 			cacheData = true
 		case renderer.AttrCachedFile:
 			cachedFile = true
+		case attrDotenv, attrINI, attrTOML, attrYAML, attrJSON, attrLines:
+			formatOpts.format = string(attr)
+		case attrEnv:
+			formatOpts.env = true
+		case attrExpand:
+			formatOpts.expand = true
+		case attrList:
+			collectOpts.list = true
+		case attrTree:
+			collectOpts.tree = true
 		default:
 		}
 	}
 
+	err = formatOpts.validate()
+	if err != nil {
+		return nil, fmt.Errorf("renderer.%s: %w", d.name, err)
+	}
+
 	if cacheData {
 		return d.cacheData(dataBytes)
 	}
 
-	return d.readFile(
-		rc.FS(),
-		strings.TrimSpace(string(dataBytes)),
-		cachedFile,
-	)
+	ofs, err := d.fs(rc.FS())
+	if err != nil {
+		return nil, fmt.Errorf("renderer.%s: %w", d.name, err)
+	}
+
+	target := strings.TrimSpace(string(dataBytes))
+	collectOpts.glob = isGlob(ofs, target)
+	err = collectOpts.validate(cachedFile)
+	if err != nil {
+		return nil, fmt.Errorf("renderer.%s: %w", d.name, err)
+	}
+
+	if collectOpts.multiple() {
+		data, err2 := d.readFiles(rc, ofs, target, &collectOpts, &formatOpts)
+		if err2 != nil {
+			return nil, fmt.Errorf("renderer.%s: %w", d.name, err2)
+		}
+
+		return data, nil
+	}
+
+	data, err := d.readFile(rc.FS(), target, cachedFile)
+	if err != nil || cachedFile {
+		return data, err
+	}
+
+	data, err = formatOpts.decode(rc, data)
+	if err != nil {
+		return nil, fmt.Errorf("renderer.%s: %w", d.name, err)
+	}
+
+	return data, nil
 }
 
 func (d *Driver) cacheData(data []byte) ([]byte, error) {
@@ -94,20 +140,39 @@ func (d *Driver) cacheData(data []byte) ([]byte, error) {
 	return []byte(path), nil
 }
 
-func (d *Driver) readFile(ofs *fshelper.OSFS, target string, getPath bool) ([]byte, error) {
-	var (
-		data []byte
-		err  error
-	)
+// readFiles reads files matched by glob pattern or all files in directory
+func (d *Driver) readFiles(
+	rc dukkha.RenderingContext,
+	ofs *fshelper.OSFS,
+	target string,
+	collectOpts *collectOptions,
+	formatOpts *formatOptions,
+) ([]byte, error) {
+	if collectOpts.tree {
+		return d.readTree(rc, ofs, target, formatOpts)
+	}
 
-	if len(d.BasePath) != 0 {
-		var fs2 fs.FS
-		fs2, err = ofs.Sub(d.BasePath)
-		if err != nil {
-			return nil, err
-		}
+	return d.readGlob(rc, ofs, target, collectOpts, formatOpts)
+}
+
+// fs returns ofs with base path applied
+func (d *Driver) fs(ofs *fshelper.OSFS) (*fshelper.OSFS, error) {
+	if len(d.BasePath) == 0 {
+		return ofs, nil
+	}
 
-		ofs = fs2.(*fshelper.OSFS)
+	fs2, err := ofs.Sub(d.BasePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return fs2.(*fshelper.OSFS), nil
+}
+
+func (d *Driver) readFile(ofs *fshelper.OSFS, target string, getPath bool) ([]byte, error) {
+	ofs, err := d.fs(ofs)
+	if err != nil {
+		return nil, err
 	}
 
 	if getPath {
@@ -120,6 +185,16 @@ func (d *Driver) readFile(ofs *fshelper.OSFS, target string, getPath bool) ([]by
 		return []byte(ret), nil
 	}
 
+	data, err := d.read(ofs, target)
+	if err != nil {
+		return nil, fmt.Errorf("renderer.%s: %w", d.name, err)
+	}
+
+	return data, err
+}
+
+// read reads file content using cache if enabled
+func (d *Driver) read(ofs *fshelper.OSFS, target string) (data []byte, err error) {
 	if d.Cache != nil {
 		data, err = d.Cache.Get(
 			cache.IdentifiableString(target),
@@ -131,9 +206,5 @@ func (d *Driver) readFile(ofs *fshelper.OSFS, target string, getPath bool) ([]by
 		data, err = ofs.ReadFile(target)
 	}
 
-	if err != nil {
-		return nil, fmt.Errorf("renderer.%s: %w", d.name, err)
-	}
-
-	return data, err
+	return
 }
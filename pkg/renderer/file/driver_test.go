@@ -10,6 +10,7 @@ import (
 
 	"arhat.dev/pkg/fshelper"
 	"arhat.dev/pkg/sha256helper"
+	"arhat.dev/rs"
 	"github.com/stretchr/testify/assert"
 
 	"arhat.dev/dukkha/pkg/dukkha"
@@ -120,3 +121,18 @@ func TestDriver_cacheData(t *testing.T) {
 	assert.NoError(t, err)
 	assert.EqualValues(t, testdata, string(data))
 }
+
+func TestDriver_RenderYaml_formats(t *testing.T) {
+	dukkha_test.TestFixturesUsingRenderingSuffix(t, "./fixtures",
+		map[string]dukkha.Renderer{
+			"file": NewDefault("file"),
+		},
+		func() rs.Field { return &rs.AnyObjectMap{} },
+		func() rs.Field { return &rs.AnyObjectMap{} },
+		func(t *testing.T, ctx dukkha.Context, ts, cs rs.Field) {
+			actual, expected := ts.(*rs.AnyObjectMap), cs.(*rs.AnyObjectMap)
+
+			assert.EqualValues(t, expected.NormalizedValue(), actual.NormalizedValue())
+		},
+	)
+}
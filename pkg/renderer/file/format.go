@@ -0,0 +1,236 @@
+package file
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+	"mvdan.cc/sh/v3/expand"
+
+	"arhat.dev/dukkha/pkg/dotenv"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/ini"
+	"arhat.dev/dukkha/pkg/templateutils"
+)
+
+// attributes parsing file content as structured data
+const (
+	attrDotenv = "dotenv"
+	attrINI    = "ini"
+	attrTOML   = "toml"
+	attrYAML   = "yaml"
+	attrJSON   = "json"
+
+	// attrLines splits file content into a list of lines
+	attrLines = "lines"
+
+	// attrEnv outputs dotenv entries as name/value list (dukkha.Env)
+	attrEnv = "env"
+	// attrExpand expands env references in dotenv values
+	attrExpand = "expand"
+)
+
+type formatOptions struct {
+	format string
+	env    bool
+	expand bool
+}
+
+func (o *formatOptions) validate() error {
+	if (o.env || o.expand) && o.format != attrDotenv {
+		return fmt.Errorf("attribute %q and %q only apply to %q", attrEnv, attrExpand, attrDotenv)
+	}
+
+	return nil
+}
+
+// decode parses file content according to the format attribute, and
+// returns it as yaml
+func (o *formatOptions) decode(rc dukkha.RenderingContext, data []byte) ([]byte, error) {
+	if len(o.format) == 0 {
+		return data, nil
+	}
+
+	v, err := o.decodeValue(rc, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(v)
+}
+
+// decodeValue parses file content according to the format attribute,
+// file content is returned as string when there is no format attribute
+func (o *formatOptions) decodeValue(rc dukkha.RenderingContext, data []byte) (interface{}, error) {
+	var (
+		v   interface{}
+		err error
+	)
+
+	switch o.format {
+	case attrDotenv:
+		v, err = o.decodeDotenv(rc, data)
+	case attrINI:
+		v, err = ini.Unmarshal(data)
+	case attrTOML:
+		err = toml.Unmarshal(data, &v)
+	case attrYAML:
+		err = yaml.Unmarshal(data, &v)
+	case attrJSON:
+		v, err = decodeJSON(data)
+	case attrLines:
+		v = splitLines(string(data))
+	default:
+		return string(data), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s file: %w", o.format, err)
+	}
+
+	return v, nil
+}
+
+// decodeJSON decodes json data with numbers kept as is, so large integers
+// and floats are not rounded to float64
+func decodeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	err := dec.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = dec.Token()
+	if !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("unexpected data after top-level value")
+	}
+
+	return jsonNumbersToYaml(v), nil
+}
+
+// jsonNumbersToYaml replaces json.Number in v with yaml scalar nodes
+// of the same text
+func jsonNumbersToYaml(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(string(t), ".eE") {
+			tag = "!!float"
+		}
+
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: string(t)}
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = jsonNumbersToYaml(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = jsonNumbersToYaml(e)
+		}
+	}
+
+	return v
+}
+
+// splitLines splits s into lines without line endings, the last line ending
+// is optional
+func splitLines(s string) []string {
+	ret := make([]string, 0)
+	if len(s) == 0 {
+		return ret
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		ret = append(ret, strings.TrimSuffix(line, "\r"))
+	}
+
+	return ret
+}
+
+func (o *formatOptions) decodeDotenv(rc dukkha.RenderingContext, data []byte) (interface{}, error) {
+	entries, err := dotenv.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.expand {
+		err = expandDotenv(rc, entries)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !o.env {
+		return dotenv.ToMap(entries), nil
+	}
+
+	ret := make([]map[string]string, len(entries))
+	for i, e := range entries {
+		ret[i] = map[string]string{"name": e.Name, "value": e.Value}
+	}
+
+	return ret, nil
+}
+
+// expandDotenv expands env references in unquoted and double quoted values,
+// values can reference entries defined before them in the same file
+func expandDotenv(rc dukkha.RenderingContext, entries []*dotenv.Entry) error {
+	envRC := &dotenvContext{
+		RenderingContext: rc,
+		env:              make(map[string]string),
+	}
+
+	for _, e := range entries {
+		if !e.SingleQuoted {
+			value, err := templateutils.ExpandEnv(envRC, e.Value, false)
+			if err != nil {
+				return fmt.Errorf("expanding %q: %w", e.Name, err)
+			}
+
+			e.Value = value
+		}
+
+		envRC.env[e.Name] = e.Value
+	}
+
+	return nil
+}
+
+// dotenvContext overrides env in RenderingContext with entries defined
+// in dotenv file, without changing env of the caller
+type dotenvContext struct {
+	dukkha.RenderingContext
+
+	env map[string]string
+}
+
+func (c *dotenvContext) Get(name string) expand.Variable {
+	if v, ok := c.env[name]; ok {
+		return expand.Variable{Exported: true, Kind: expand.String, Str: v}
+	}
+
+	return c.RenderingContext.Get(name)
+}
+
+func (c *dotenvContext) Each(fn func(name string, vr expand.Variable) bool) {
+	for name := range c.env {
+		if !fn(name, c.Get(name)) {
+			return
+		}
+	}
+
+	c.RenderingContext.Each(func(name string, vr expand.Variable) bool {
+		if _, ok := c.env[name]; ok {
+			return true
+		}
+
+		return fn(name, vr)
+	})
+}
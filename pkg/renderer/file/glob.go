@@ -0,0 +1,223 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"arhat.dev/pkg/fshelper"
+	"github.com/bmatcuk/doublestar/v4"
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/renderer"
+)
+
+// attributes reading multiple files
+const (
+	// attrList outputs files matched by glob pattern as a list of path/content
+	// pairs instead of a map
+	attrList = "list"
+
+	// attrTree outputs all files in a directory as nested maps
+	attrTree = "tree"
+)
+
+type collectOptions struct {
+	list bool
+	tree bool
+
+	// glob is set when target is a glob pattern, see isGlob
+	glob bool
+}
+
+func (o *collectOptions) validate(cachedFile bool) error {
+	switch {
+	case o.list && o.tree:
+		return fmt.Errorf("attribute %q and %q are mutually exclusive", attrList, attrTree)
+	case o.list && !o.glob:
+		return fmt.Errorf("attribute %q only applies to glob pattern", attrList)
+	case o.tree && o.glob:
+		return fmt.Errorf("attribute %q does not apply to glob pattern", attrTree)
+	case cachedFile && o.multiple():
+		return fmt.Errorf("attribute %q does not apply to multiple files", renderer.AttrCachedFile)
+	}
+
+	return nil
+}
+
+// multiple returns true when more than one file is read
+func (o *collectOptions) multiple() bool {
+	return o.tree || o.glob
+}
+
+// isGlob checks whether target is a glob pattern, existing paths with glob
+// meta characters (e.g. `data[1].json`) are read as is
+func isGlob(ofs *fshelper.OSFS, target string) bool {
+	if !strings.ContainsAny(target, "*?[{") {
+		return false
+	}
+
+	_, err := ofs.Lstat(target)
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+// fileEntry is the list item of files matched by glob pattern
+type fileEntry struct {
+	Path    string      `yaml:"path"`
+	Content interface{} `yaml:"content"`
+}
+
+// readGlob reads all files matched by glob pattern, directories are ignored
+//
+// paths in result are relative to the directory before the first glob
+// segment of the pattern, e.g. `a.yaml` for `configs/*.yaml`
+func (d *Driver) readGlob(
+	rc dukkha.RenderingContext,
+	ofs *fshelper.OSFS,
+	pattern string,
+	opts *collectOptions,
+	formatOpts *formatOptions,
+) ([]byte, error) {
+	base, pattern := doublestar.SplitPattern(pattern)
+	if !doublestar.ValidatePattern(pattern) {
+		return nil, fmt.Errorf("invalid glob pattern %q", pattern)
+	}
+
+	subFS, err := ofs.Sub(base)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := doublestar.Glob(subFS, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(matches)
+
+	var (
+		m    = make(map[string]interface{})
+		list = make([]*fileEntry, 0, len(matches))
+	)
+
+	for _, name := range matches {
+		target := path.Join(base, name)
+		info, err := ofs.Stat(target)
+		if err != nil {
+			return nil, err
+		}
+
+		if info.IsDir() {
+			continue
+		}
+
+		v, err := d.readValue(rc, ofs, target, formatOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		if opts.list {
+			list = append(list, &fileEntry{Path: name, Content: v})
+		} else {
+			m[name] = v
+		}
+	}
+
+	if opts.list {
+		return yaml.Marshal(list)
+	}
+
+	return yaml.Marshal(m)
+}
+
+// readTree reads all files in dir as nested maps, keys are file names,
+// values are file content or maps of sub directories
+//
+// symlinks to directories are not followed
+func (d *Driver) readTree(
+	rc dukkha.RenderingContext,
+	ofs *fshelper.OSFS,
+	dir string,
+	formatOpts *formatOptions,
+) ([]byte, error) {
+	subFS, err := ofs.Sub(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		root = make(map[string]interface{})
+		dirs = map[string]map[string]interface{}{".": root}
+	)
+
+	err = fs.WalkDir(subFS, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if name == "." {
+			if !entry.IsDir() {
+				return fmt.Errorf("%q is not a directory", dir)
+			}
+
+			return nil
+		}
+
+		parent := dirs[path.Dir(name)]
+		if entry.IsDir() {
+			m := make(map[string]interface{})
+			dirs[name] = m
+			parent[entry.Name()] = m
+			return nil
+		}
+
+		target := path.Join(dir, name)
+		if entry.Type()&fs.ModeSymlink != 0 {
+			info, err2 := ofs.Stat(target)
+			if err2 != nil {
+				return err2
+			}
+
+			if info.IsDir() {
+				return nil
+			}
+		}
+
+		v, err := d.readValue(rc, ofs, target, formatOpts)
+		if err != nil {
+			return err
+		}
+
+		parent[entry.Name()] = v
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(root)
+}
+
+// readValue reads file content and decodes it according to format attributes
+func (d *Driver) readValue(
+	rc dukkha.RenderingContext,
+	ofs *fshelper.OSFS,
+	target string,
+	formatOpts *formatOptions,
+) (interface{}, error) {
+	data, err := d.read(ofs, target)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := formatOpts.decodeValue(rc, data)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", target, err)
+	}
+
+	return v, nil
+}
@@ -16,10 +16,15 @@ import (
 type FetchSpec struct {
 	rs.BaseField `yaml:"-"`
 
-	// Repo you want to fetch from
+	// Repo you want to fetch from, can be
+	// 	- repo name in ssh git service (e.g. `my-org/foo.git`)
+	// 	- http(s) url of the repo (e.g. `https://github.com/my-org/foo.git`)
+	// 	- local path to work tree or git dir (e.g. `.git`, `../foo`, `/path/to/foo.git`)
 	Repo string `yaml:"repo"`
 
 	// Ref is the git object reference, usually branch/tag name, defaults to `HEAD`
+	//
+	// for local repo, it can be any revision accepted by git (e.g. `HEAD~1`)
 	Ref string `yaml:"ref"`
 
 	// Path of the target file
@@ -31,6 +36,7 @@ type inputFetchSpec struct {
 
 	Fetch FetchSpec `yaml:",inline"`
 	SSH   *ssh.Spec `yaml:"ssh,omitempty"`
+	HTTP  *HTTPSpec `yaml:"http,omitempty"`
 }
 
 func (f *FetchSpec) fetchRemote(sshConfig *ssh.Spec) (io.ReadCloser, error) {
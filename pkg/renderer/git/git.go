@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/http"
 	"strconv"
 	"strings"
 
@@ -37,6 +38,9 @@ type Driver struct {
 	name string
 
 	SSHConfig ssh.Spec `yaml:",inline"`
+
+	// HTTPConfig for repos using http(s) url
+	HTTPConfig HTTPSpec `yaml:"http"`
 }
 
 func (d *Driver) RenderYaml(
@@ -46,6 +50,7 @@ func (d *Driver) RenderYaml(
 		// reqURL format: <repo-name>.git/<path-in-repo>[@ref]
 		reqURL      string
 		sshConfig   *ssh.Spec
+		httpConfig  *HTTPSpec
 		fetchConfig *FetchSpec
 	)
 
@@ -58,9 +63,11 @@ func (d *Driver) RenderYaml(
 	case string:
 		reqURL = t
 		sshConfig = &d.SSHConfig
+		httpConfig = &d.HTTPConfig
 	case []byte:
 		reqURL = string(t)
 		sshConfig = &d.SSHConfig
+		httpConfig = &d.HTTPConfig
 	default:
 		var rawBytes []byte
 		rawBytes, err = yamlhelper.ToYamlBytes(rawData)
@@ -92,29 +99,40 @@ func (d *Driver) RenderYaml(
 		}
 
 		sshConfig = spec.SSH
+		if sshConfig == nil {
+			sshConfig = &d.SSHConfig
+		}
+
+		httpConfig = spec.HTTP
+		if httpConfig == nil {
+			httpConfig = &d.HTTPConfig
+		}
+
 		fetchConfig = &spec.Fetch
 	}
 
 	if len(reqURL) != 0 {
 		fetchConfig = &FetchSpec{}
 
-		if idx := strings.LastIndexByte(reqURL, '@'); idx > 0 {
+		// `@` can also be in the user info part of http url
+		if idx := strings.LastIndexByte(reqURL, '@'); idx > 0 &&
+			!strings.Contains(reqURL[idx+1:], ".git/") {
 			fetchConfig.Ref = reqURL[idx+1:]
 			reqURL = reqURL[:idx]
 		}
 
-		parts := strings.SplitAfterN(reqURL, ".git", 2)
-		if len(parts) != 2 {
+		idx := strings.Index(reqURL, ".git/")
+		if idx < 0 {
 			return nil, fmt.Errorf(
 				"invalid request url %q: no `.git` found",
 				reqURL,
 			)
 		}
 
-		fetchConfig.Repo, fetchConfig.Path = parts[0], parts[1]
-		fetchConfig.Path = strings.TrimPrefix(fetchConfig.Path, "/")
+		fetchConfig.Repo, fetchConfig.Path = reqURL[:idx+4], reqURL[idx+5:]
 
-		if idx := strings.LastIndexByte(fetchConfig.Repo, ':'); idx > 0 {
+		if idx := strings.LastIndexByte(fetchConfig.Repo, ':'); idx > 0 &&
+			!isLocalRepo(fetchConfig.Repo) && !isHTTPRepo(fetchConfig.Repo) {
 			sshConfig = sshConfig.Clone()
 			sshConfig.Host = fetchConfig.Repo[:idx]
 			sshConfig.Port = 0 // reset to default
@@ -133,14 +151,69 @@ func (d *Driver) RenderYaml(
 		}
 	}
 
+	var (
+		key   string
+		fetch func() (io.ReadCloser, error)
+
+		// ref as requested, refs of local repo are resolved to commit id
+		ref = fetchConfig.Ref
+	)
+
+	switch repo := fetchConfig.Repo; {
+	case isHTTPRepo(repo):
+		key = stripUserinfo(repo)
+		withCredentials := key != repo || len(httpConfig.User)+len(httpConfig.Password) != 0
+		fetch = func() (io.ReadCloser, error) {
+			ret, err2 := fetchConfig.fetchHTTP(http.DefaultClient, httpConfig)
+			if err2 != nil || !withCredentials {
+				return ret, err2
+			}
+
+			return cache.PrivateContent(ret), nil
+		}
+	case isLocalRepo(repo):
+		repoPath := localRepoPath(rc.WorkDir(), repo)
+
+		// refs in local repo move with commits, resolve to commit id so
+		// cached content is never stale
+		commit, err2 := resolveLocalCommit(rc, repoPath, fetchConfig.Ref)
+		if err2 != nil {
+			return nil, fmt.Errorf("renderer.%s: %w", d.name, err2)
+		}
+
+		fetchConfig.Ref = commit
+		key = "file://" + repoPath
+		fetch = func() (io.ReadCloser, error) {
+			return fetchConfig.fetchLocal(rc, repoPath)
+		}
+	default:
+		key = sshConfig.User + "@" + sshConfig.Host + ":" + strconv.Itoa(sshConfig.Port) + ":" + repo
+		fetch = func() (io.ReadCloser, error) {
+			ret, err2 := fetchConfig.fetchRemote(sshConfig)
+			if err2 != nil {
+				return nil, err2
+			}
+
+			// ssh access always requires credentials
+			return cache.PrivateContent(ret), nil
+		}
+	}
+
+	resource := key
+	if len(ref) != 0 {
+		resource += "@" + ref
+	}
+	resource += ":" + strings.TrimPrefix(fetchConfig.Path, "/")
+
 	data, err := renderer.HandleRenderingRequestWithRemoteFetch(
 		d.Cache,
-		cache.IdentifiableString(reqURL),
+		// ref goes before path to keep file extension of the cache key
+		cache.IdentifiableString(key+"@"+fetchConfig.Ref+"/"+strings.TrimPrefix(fetchConfig.Path, "/")),
 		func(_ cache.IdentifiableObject) (io.ReadCloser, error) {
-			// key is the url we passed in
-			return fetchConfig.fetchRemote(sshConfig)
+			return fetch()
 		},
 		d.Attributes(attributes),
+		renderer.FetchOptions{Resource: resource},
 	)
 
 	if err != nil {
@@ -1,9 +1,24 @@
 package git
 
 import (
+	"bytes"
+	"context"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"arhat.dev/rs"
+	"github.com/stretchr/testify/assert"
+
+	di "arhat.dev/dukkha/internal"
 	"arhat.dev/dukkha/pkg/dukkha"
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/renderer"
 )
 
 var _ dukkha.Renderer = (*Driver)(nil)
@@ -26,3 +41,216 @@ func TestFetchSpec(t *testing.T) {
 	//
 	// 	t.Log(string(data))
 }
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	cmd := exec.Command("git", append([]string{
+		"-c", "user.name=dukkha", "-c", "user.email=dukkha@example.com",
+		"-c", "init.defaultBranch=main", "-c", "commit.gpgsign=false",
+	}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if !assert.NoError(t, err, string(out)) {
+		t.FailNow()
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// createTestRepo creates a repo with two commits to config.yaml,
+// returns work tree dir
+func createTestRepo(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "env"), 0755))
+	for _, v := range []string{"v1", "v2"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "env", "config.yaml"), []byte("version: "+v+"\n"), 0644))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# "+v+"\n"), 0644))
+		runGit(t, dir, "add", "-A")
+		runGit(t, dir, "commit", "-q", "-m", v)
+	}
+
+	runGit(t, dir, "tag", "-a", "-m", "release", "release")
+	return dir
+}
+
+func newTestContext(t *testing.T) dukkha.ConfigResolvingContext {
+	rc := dt.NewTestContext(context.TODO())
+	rc.(di.CacheDirSetter).SetCacheDir(t.TempDir())
+	return rc
+}
+
+func TestDriver_RenderYaml_Local(t *testing.T) {
+	dir := createTestRepo(t)
+	rc := newTestContext(t)
+
+	d := NewDefault("git").(*Driver)
+	assert.NoError(t, d.Init(rc.RendererCacheFS("git")))
+
+	for _, test := range []struct {
+		name     string
+		input    interface{}
+		expected string
+		err      bool
+	}{
+		{
+			name:     "Git Dir",
+			input:    filepath.Join(dir, ".git") + "/env/config.yaml",
+			expected: "version: v2\n",
+		},
+		{
+			name:     "Git Dir With Ref",
+			input:    filepath.Join(dir, ".git") + "/env/config.yaml@HEAD~1",
+			expected: "version: v1\n",
+		},
+		{
+			name: "Work Tree Spec",
+			input: rs.Init(&inputFetchSpec{Fetch: FetchSpec{
+				Repo: dir, Path: "README.md", Ref: "main~1",
+			}}, nil),
+			expected: "# v1\n",
+		},
+		{
+			name:  "Not Found",
+			input: filepath.Join(dir, ".git") + "/not-found.yaml",
+			err:   true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ret, err := d.RenderYaml(rc, test.input, nil)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, string(ret))
+		})
+	}
+}
+
+func TestDriver_RenderYaml_Local_MovingRef(t *testing.T) {
+	dir := createTestRepo(t)
+	rc := newTestContext(t)
+
+	d := &Driver{
+		name: "git",
+		BaseTwoTierCachedRenderer: renderer.BaseTwoTierCachedRenderer{
+			CacheConfig: renderer.CacheConfig{Enabled: true, Timeout: time.Hour},
+		},
+	}
+	assert.NoError(t, d.Init(rc.RendererCacheFS("git")))
+
+	input := dir + "/.git/env/config.yaml@main"
+	ret, err := d.RenderYaml(rc, input, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "version: v2\n", string(ret))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "env", "config.yaml"), []byte("version: v3\n"), 0644))
+	runGit(t, dir, "commit", "-q", "-a", "-m", "v3")
+
+	ret, err = d.RenderYaml(rc, input, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "version: v3\n", string(ret))
+
+	ret, err = d.RenderYaml(rc, input, []dukkha.RendererAttribute{renderer.AttrCachedFile})
+	assert.NoError(t, err)
+	assert.Equal(t, ".yaml", filepath.Ext(string(ret)))
+}
+
+func TestDriver_RenderYaml_HTTP(t *testing.T) {
+	dir := createTestRepo(t)
+	gitPath, err := exec.LookPath("git")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	root := t.TempDir()
+	runGit(t, root, "clone", "-q", "--bare", dir, "filter.git")
+	runGit(t, filepath.Join(root, "filter.git"), "config", "uploadpack.allowFilter", "true")
+	runGit(t, root, "clone", "-q", "--bare", dir, "no-filter.git")
+
+	srv := httptest.NewServer(&cgi.Handler{
+		Path: gitPath,
+		Args: []string{"http-backend"},
+		Env: []string{
+			"GIT_PROJECT_ROOT=" + root,
+			"GIT_HTTP_EXPORT_ALL=1",
+		},
+	})
+	defer srv.Close()
+
+	rc := newTestContext(t)
+	d := NewDefault("git").(*Driver)
+	assert.NoError(t, d.Init(rc.RendererCacheFS("git")))
+
+	for _, repo := range []string{"filter.git", "no-filter.git"} {
+		t.Run(repo, func(t *testing.T) {
+			for ref, expected := range map[string]string{
+				"":         "version: v2\n",
+				"@main":    "version: v2\n",
+				"@release": "version: v2\n",
+				"@" + runGit(t, dir, "rev-parse", "HEAD~1"): "version: v1\n",
+			} {
+				ret, err := d.RenderYaml(rc, srv.URL+"/"+repo+"/env/config.yaml"+ref, nil)
+				assert.NoError(t, err, ref)
+				assert.Equal(t, expected, string(ret), ref)
+			}
+
+			_, err := d.RenderYaml(rc, srv.URL+"/"+repo+"/env/not-found.yaml", nil)
+			assert.Error(t, err)
+
+			_, err = d.RenderYaml(rc, srv.URL+"/"+repo+"/env/config.yaml@not-found", nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParsePackfile(t *testing.T) {
+	dir := createTestRepo(t)
+
+	// more similar blobs to get deltified objects
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, os.WriteFile(
+			filepath.Join(dir, "README.md"),
+			bytes.Repeat([]byte("line of text "+strings.Repeat("x", i)+"\n"), 100), 0644,
+		))
+		runGit(t, dir, "commit", "-q", "-a", "-m", "update")
+	}
+
+	objects := strings.Fields(runGit(t, dir, "rev-list", "--objects", "--all"))
+	var ids []string
+	for _, o := range objects {
+		if objectIDRegexp.MatchString(o) {
+			ids = append(ids, o)
+		}
+	}
+
+	cmd := exec.Command("git", "pack-objects", "--stdout", "--delta-base-offset")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(strings.Join(ids, "\n") + "\n")
+	pack, err := cmd.Output()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	parsed, err := parsePackfile(pack)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Len(t, parsed, len(ids))
+	for _, id := range ids {
+		obj, ok := parsed[id]
+		if !assert.True(t, ok, id) {
+			continue
+		}
+
+		expected := runGit(t, dir, "cat-file", obj.typ.String(), id)
+		assert.Equal(t, expected, strings.TrimSpace(string(obj.data)), id)
+	}
+}
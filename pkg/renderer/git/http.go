@@ -0,0 +1,424 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"arhat.dev/pkg/log"
+	"arhat.dev/rs"
+)
+
+// HTTPSpec is the config for git smart http protocol
+type HTTPSpec struct {
+	rs.BaseField `yaml:"-"`
+
+	// User for http basic auth
+	User string `yaml:"user"`
+
+	// Password for http basic auth, usually an access token
+	Password string `yaml:"password"`
+}
+
+var objectIDRegexp = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// httpFetcher fetches file using git smart http protocol (version 2)
+//
+// ref: https://git-scm.com/docs/protocol-v2
+type httpFetcher struct {
+	client *http.Client
+	config *HTTPSpec
+
+	// repoURL is the url of the repo, e.g. https://github.com/foo/bar.git
+	repoURL string
+
+	// capabilities advertised by server, command -> features
+	capabilities map[string][]string
+}
+
+func (f *FetchSpec) fetchHTTP(client *http.Client, config *HTTPSpec) (io.ReadCloser, error) {
+	if len(f.Path) == 0 {
+		return nil, fmt.Errorf("invalid no path in repo set")
+	}
+
+	h := &httpFetcher{
+		client:  client,
+		config:  config,
+		repoURL: strings.TrimSuffix(f.Repo, "/"),
+	}
+
+	err := h.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := h.resolveRef(f.Ref)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"want " + commit + "\n"}
+	shallow := h.hasFeature("fetch", "shallow")
+	if shallow {
+		args = append(args, "deepen 1\n")
+	}
+
+	filtered := h.hasFeature("fetch", "filter")
+	if filtered {
+		args = append(args, "filter blob:none\n")
+	}
+
+	if !shallow || !filtered {
+		log.Log.I("git server lacks partial fetch support, fetching full content",
+			log.String("repo", stripUserinfo(h.repoURL)),
+			log.Bool("shallow", shallow),
+			log.Bool("filter", filtered),
+		)
+	}
+
+	objects, err := h.fetch(args)
+	if err != nil {
+		return nil, fmt.Errorf("fetch commit %s: %w", commit, err)
+	}
+
+	blobID, err := findBlob(objects, commit, f.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	blob, ok := objects[blobID]
+	if !ok && filtered {
+		objects, err = h.fetch([]string{"want " + blobID + "\n"})
+		if err != nil {
+			return nil, fmt.Errorf("fetch blob %s: %w", blobID, err)
+		}
+
+		blob, ok = objects[blobID]
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("blob %s of %q not found", blobID, f.Path)
+	}
+
+	return io.NopCloser(bytes.NewReader(blob.data)), nil
+}
+
+// stripUserinfo removes credentials in http repo url
+func stripUserinfo(repo string) string {
+	u, err := url.Parse(repo)
+	if err != nil || u.User == nil {
+		return repo
+	}
+
+	u.User = nil
+	return u.String()
+}
+
+// discover capabilities of remote server
+func (h *httpFetcher) discover() error {
+	req, err := h.newRequest(http.MethodGet, "/info/refs?service=git-upload-pack", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("discover capabilities: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("discover capabilities: unexpected status %q", resp.Status)
+	}
+
+	r := bufio.NewReader(resp.Body)
+	h.capabilities = make(map[string][]string)
+
+	var gotVersion bool
+	for {
+		line, status, err := readPktLine(r)
+		if err != nil {
+			if err == io.EOF && gotVersion {
+				return nil
+			}
+
+			return fmt.Errorf("read capability advertisement: %w", err)
+		}
+
+		switch {
+		case status == pktFlush:
+			if gotVersion {
+				return nil
+			}
+
+			// flush after `# service=git-upload-pack`
+			continue
+		case strings.HasPrefix(line, "# service="):
+			continue
+		case !gotVersion:
+			if line != "version 2" {
+				return fmt.Errorf("git protocol version 2 not supported by server")
+			}
+
+			gotVersion = true
+		default:
+			k, v, _ := cut(line, "=")
+			h.capabilities[k] = strings.Fields(v)
+		}
+	}
+}
+
+func (h *httpFetcher) hasFeature(command, feature string) bool {
+	for _, f := range h.capabilities[command] {
+		if f == feature {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolveRef resolves ref to commit id using ls-refs command
+func (h *httpFetcher) resolveRef(ref string) (string, error) {
+	if objectIDRegexp.MatchString(ref) {
+		return ref, nil
+	}
+
+	if len(ref) == 0 {
+		ref = "HEAD"
+	}
+
+	candidates := []string{ref}
+	if ref != "HEAD" && !strings.HasPrefix(ref, "refs/") {
+		candidates = append(candidates, "refs/heads/"+ref, "refs/tags/"+ref)
+	}
+
+	args := []string{"peel\n", "symrefs\n"}
+	for _, c := range candidates {
+		args = append(args, "ref-prefix "+c+"\n")
+	}
+
+	body, err := h.command("ls-refs", args)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = body.Close() }()
+
+	refs := make(map[string]string)
+	r := bufio.NewReader(body)
+	for {
+		line, status, err := readPktLine(r)
+		if err != nil {
+			return "", fmt.Errorf("read ls-refs response: %w", err)
+		}
+
+		if status == pktFlush {
+			break
+		}
+
+		// <oid> <refname> [symref-target:...] [peeled:<oid>]
+		parts := strings.Fields(line)
+		if len(parts) < 2 {
+			continue
+		}
+
+		id := parts[0]
+		for _, attr := range parts[2:] {
+			if strings.HasPrefix(attr, "peeled:") {
+				id = strings.TrimPrefix(attr, "peeled:")
+			}
+		}
+
+		refs[parts[1]] = id
+	}
+
+	for _, c := range candidates {
+		if id, ok := refs[c]; ok {
+			return id, nil
+		}
+	}
+
+	return "", fmt.Errorf("ref %q not found", ref)
+}
+
+// fetch objects using fetch command with args
+func (h *httpFetcher) fetch(args []string) (map[string]*gitObject, error) {
+	args = append(args, "ofs-delta\n", "no-progress\n", "done\n")
+
+	body, err := h.command("fetch", args)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = body.Close() }()
+
+	r := bufio.NewReader(body)
+
+	// skip sections before packfile (e.g. acknowledgments, shallow-info)
+	for {
+		line, status, err := readPktLine(r)
+		if err != nil {
+			return nil, fmt.Errorf("read fetch response: %w", err)
+		}
+
+		if status == pktNromal && line == "packfile" {
+			break
+		}
+	}
+
+	pack := &bytes.Buffer{}
+	for {
+		data, status, err := readPkt(r)
+		if err != nil {
+			return nil, fmt.Errorf("read packfile: %w", err)
+		}
+
+		if status == pktFlush {
+			break
+		}
+
+		if status != pktNromal || len(data) == 0 {
+			continue
+		}
+
+		switch sideBandType(data[0]) {
+		case sideBandPrimary:
+			pack.Write(data[1:])
+		case sideBandSecondary:
+			// progress message
+		case sideBandRemoteError:
+			return nil, fmt.Errorf("remote error: %s", strings.TrimSpace(string(data[1:])))
+		}
+	}
+
+	return parsePackfile(pack.Bytes())
+}
+
+// command sends a protocol v2 command request
+func (h *httpFetcher) command(command string, args []string) (io.ReadCloser, error) {
+	reqBody := &strings.Builder{}
+	reqBody.WriteString(formatPktLine("command=" + command + "\n"))
+	if len(h.capabilities["object-format"]) != 0 {
+		reqBody.WriteString(formatPktLine("object-format=sha1\n"))
+	}
+	reqBody.WriteString("0001")
+	reqBody.WriteString(formatPktLines(args))
+	reqBody.WriteString("0000")
+
+	req, err := h.newRequest(http.MethodPost, "/git-upload-pack", strings.NewReader(reqBody.String()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-git-upload-pack-request")
+	req.Header.Set("Accept", "application/x-git-upload-pack-result")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", command, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("%s: unexpected status %q", command, resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+func (h *httpFetcher) newRequest(method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, h.repoURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("creating http request: %w", err)
+	}
+
+	req.Header.Set("Git-Protocol", "version=2")
+	req.Header.Set("User-Agent", "git/dukkha")
+	if h.config != nil && len(h.config.User)+len(h.config.Password) != 0 {
+		req.SetBasicAuth(h.config.User, h.config.Password)
+	}
+
+	return req, nil
+}
+
+// findBlob finds id of the blob at path in the tree of commit
+func findBlob(objects map[string]*gitObject, commit, path string) (string, error) {
+	obj, ok := objects[commit]
+	if !ok || obj.typ != objectCommit {
+		return "", fmt.Errorf("commit %s not found", commit)
+	}
+
+	// first line of commit object: tree <oid>
+	firstLine, _, _ := cut(string(obj.data), "\n")
+	if !strings.HasPrefix(firstLine, "tree ") {
+		return "", fmt.Errorf("invalid commit object %s", commit)
+	}
+
+	id := strings.TrimPrefix(firstLine, "tree ")
+	for _, name := range strings.Split(strings.Trim(path, "/"), "/") {
+		tree, ok := objects[id]
+		if !ok || tree.typ != objectTree {
+			return "", fmt.Errorf("tree %s not found for %q", id, path)
+		}
+
+		id, ok = lookupTreeEntry(tree.data, name)
+		if !ok {
+			return "", fmt.Errorf("%q not found in commit %s", path, commit)
+		}
+	}
+
+	return id, nil
+}
+
+// lookupTreeEntry finds id of entry with name in tree object
+//
+// entry format: <mode> <name>\0<20-byte id>
+func lookupTreeEntry(tree []byte, name string) (string, bool) {
+	for len(tree) != 0 {
+		sp := bytes.IndexByte(tree, ' ')
+		nul := bytes.IndexByte(tree, 0)
+		if sp < 0 || nul < sp || nul+21 > len(tree) {
+			return "", false
+		}
+
+		entryName := string(tree[sp+1 : nul])
+		id := tree[nul+1 : nul+21]
+		tree = tree[nul+21:]
+
+		if entryName == name {
+			return fmt.Sprintf("%x", id), true
+		}
+	}
+
+	return "", false
+}
+
+// readPkt reads one pkt-line
+func readPkt(r io.Reader) ([]byte, pktStatus, error) {
+	var sizeBuf [4]byte
+	size, status, err := readPktSize(r, sizeBuf[:])
+	if err != nil || status != pktNromal {
+		return nil, status, err
+	}
+
+	data := make([]byte, size)
+	_, err = io.ReadFull(r, data)
+	return data, status, err
+}
+
+// readPktLine reads one pkt-line as text with trailing newline removed
+func readPktLine(r io.Reader) (string, pktStatus, error) {
+	data, status, err := readPkt(r)
+	return strings.TrimSuffix(string(data), "\n"), status, err
+}
+
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+
+	return s, "", false
+}
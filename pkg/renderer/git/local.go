@@ -0,0 +1,108 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// isLocalRepo checks whether repo is a local path
+//
+// local repo MUST be an absolute path, a relative path starting with
+// `./` or `../`, the `.git` dir, or using `file://` scheme
+func isLocalRepo(repo string) bool {
+	switch {
+	case strings.HasPrefix(repo, "file://"),
+		strings.HasPrefix(repo, "./"),
+		strings.HasPrefix(repo, "../"),
+		repo == ".git",
+		filepath.IsAbs(repo):
+		return true
+	default:
+		return false
+	}
+}
+
+// isHTTPRepo checks whether repo is a http(s) url
+func isHTTPRepo(repo string) bool {
+	return strings.HasPrefix(repo, "https://") || strings.HasPrefix(repo, "http://")
+}
+
+// localRepoPath returns the absolute path of local repo
+func localRepoPath(workDir, repo string) string {
+	repo = strings.TrimPrefix(repo, "file://")
+	if !filepath.IsAbs(repo) {
+		repo = filepath.Join(workDir, repo)
+	}
+
+	return filepath.Clean(repo)
+}
+
+// resolveLocalCommit returns the commit id ref points to in local repo,
+// ref defaults to `HEAD`
+func resolveLocalCommit(ctx context.Context, repoPath, ref string) (string, error) {
+	if len(ref) == 0 {
+		ref = "HEAD"
+	}
+
+	var (
+		stdout = &bytes.Buffer{}
+		stderr = &bytes.Buffer{}
+	)
+
+	cmd := exec.CommandContext(ctx,
+		"git", "-C", repoPath, "rev-parse", "--verify", "--end-of-options", ref+"^{commit}",
+	)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf(
+			"resolve %q in local repo %q: %w: %s",
+			ref, repoPath, err, strings.TrimSpace(stderr.String()),
+		)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// fetchLocal reads file content at ref from local repo using git
+//
+// the repo can be either a work tree or a git dir (e.g. `.git`, bare repo),
+// ref can be any revision accepted by git (e.g. `HEAD~1`, `main`)
+func (f *FetchSpec) fetchLocal(ctx context.Context, repoPath string) (io.ReadCloser, error) {
+	if len(f.Path) == 0 {
+		return nil, fmt.Errorf("invalid no path in repo set")
+	}
+
+	ref := f.Ref
+	if len(ref) == 0 {
+		ref = "HEAD"
+	}
+
+	var (
+		stdout = &bytes.Buffer{}
+		stderr = &bytes.Buffer{}
+	)
+
+	cmd := exec.CommandContext(ctx,
+		"git", "-C", repoPath, "cat-file", "blob", ref+":"+strings.TrimPrefix(f.Path, "/"),
+	)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf(
+			"read %q at %q from local repo %q: %w: %s",
+			f.Path, ref, repoPath, err, strings.TrimSpace(stderr.String()),
+		)
+	}
+
+	return io.NopCloser(stdout), nil
+}
@@ -0,0 +1,320 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1" // nolint:gosec
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type objectType byte
+
+// nolint:revive
+const (
+	objectCommit   objectType = 1
+	objectTree     objectType = 2
+	objectBlob     objectType = 3
+	objectTag      objectType = 4
+	objectOfsDelta objectType = 6
+	objectRefDelta objectType = 7
+)
+
+func (t objectType) String() string {
+	switch t {
+	case objectCommit:
+		return "commit"
+	case objectTree:
+		return "tree"
+	case objectBlob:
+		return "blob"
+	case objectTag:
+		return "tag"
+	default:
+		return "<unknown>"
+	}
+}
+
+type gitObject struct {
+	typ  objectType
+	data []byte
+}
+
+// packedObject is an object read from packfile, delta not applied
+type packedObject struct {
+	typ  objectType
+	data []byte
+
+	// baseOffset is the offset of base object when typ is objectOfsDelta
+	baseOffset int64
+	// baseID is the id of base object when typ is objectRefDelta
+	baseID string
+
+	resolved *gitObject
+}
+
+// parsePackfile parses all objects in packfile, deltified objects are resolved
+//
+// returns objects by hex encoded object id
+func parsePackfile(pack []byte) (map[string]*gitObject, error) {
+	if len(pack) < 12 || string(pack[:4]) != "PACK" {
+		return nil, fmt.Errorf("invalid packfile signature")
+	}
+
+	version := binary.BigEndian.Uint32(pack[4:])
+	if version != 2 && version != 3 {
+		return nil, fmt.Errorf("unsupported packfile version %d", version)
+	}
+
+	count := binary.BigEndian.Uint32(pack[8:])
+
+	var (
+		r        = bytes.NewReader(pack)
+		byOffset = make(map[int64]*packedObject, count)
+		ordered  = make([]*packedObject, 0, count)
+	)
+
+	_, _ = r.Seek(12, io.SeekStart)
+	for i := uint32(0); i < count; i++ {
+		offset := r.Size() - int64(r.Len())
+		obj, err := readPackedObject(r, offset)
+		if err != nil {
+			return nil, fmt.Errorf("reading object #%d: %w", i, err)
+		}
+
+		byOffset[offset] = obj
+		ordered = append(ordered, obj)
+	}
+
+	ret := make(map[string]*gitObject, count)
+	var pending []*packedObject
+	for _, obj := range ordered {
+		if obj.typ == objectOfsDelta || obj.typ == objectRefDelta {
+			pending = append(pending, obj)
+			continue
+		}
+
+		obj.resolved = &gitObject{typ: obj.typ, data: obj.data}
+		ret[objectID(obj.typ, obj.data)] = obj.resolved
+	}
+
+	// resolve deltified objects whose base is resolved until all resolved
+	for len(pending) != 0 {
+		var next []*packedObject
+		for _, obj := range pending {
+			var base *gitObject
+			switch obj.typ {
+			case objectOfsDelta:
+				baseObj, ok := byOffset[obj.baseOffset]
+				if !ok {
+					return nil, fmt.Errorf("base object at offset %d not found", obj.baseOffset)
+				}
+
+				base = baseObj.resolved
+			case objectRefDelta:
+				base = ret[obj.baseID]
+			}
+
+			if base == nil {
+				next = append(next, obj)
+				continue
+			}
+
+			data, err := applyDelta(base.data, obj.data)
+			if err != nil {
+				return nil, err
+			}
+
+			obj.resolved = &gitObject{typ: base.typ, data: data}
+			ret[objectID(base.typ, data)] = obj.resolved
+		}
+
+		if len(next) == len(pending) {
+			return nil, fmt.Errorf("%d deltified objects without base", len(next))
+		}
+
+		pending = next
+	}
+
+	return ret, nil
+}
+
+func readPackedObject(r *bytes.Reader, offset int64) (*packedObject, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	obj := &packedObject{typ: objectType((b >> 4) & 0x07)}
+	size := uint64(b & 0x0f)
+	for shift := 4; b&0x80 != 0; shift += 7 {
+		b, err = r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		size |= uint64(b&0x7f) << shift
+	}
+
+	switch obj.typ {
+	case objectCommit, objectTree, objectBlob, objectTag:
+	case objectOfsDelta:
+		b, err = r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		rel := int64(b & 0x7f)
+		for b&0x80 != 0 {
+			b, err = r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+
+			rel = ((rel + 1) << 7) | int64(b&0x7f)
+		}
+
+		obj.baseOffset = offset - rel
+	case objectRefDelta:
+		id := make([]byte, sha1.Size)
+		_, err = io.ReadFull(r, id)
+		if err != nil {
+			return nil, err
+		}
+
+		obj.baseID = hex.EncodeToString(id)
+	default:
+		return nil, fmt.Errorf("invalid object type %d", obj.typ)
+	}
+
+	// bytes.Reader implements io.ByteReader, so zlib reader will not read ahead
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	obj.data = make([]byte, 0, size)
+	obj.data, err = readAllTo(zr, obj.data)
+	if err != nil {
+		return nil, err
+	}
+
+	if uint64(len(obj.data)) != size {
+		return nil, fmt.Errorf("object size mismatch: want %d, got %d", size, len(obj.data))
+	}
+
+	return obj, zr.Close()
+}
+
+func readAllTo(r io.Reader, buf []byte) ([]byte, error) {
+	w := bytes.NewBuffer(buf)
+	_, err := io.Copy(w, r)
+	return w.Bytes(), err
+}
+
+// applyDelta applies git delta instructions to base
+func applyDelta(base, delta []byte) ([]byte, error) {
+	srcSize, delta, err := readDeltaSize(delta)
+	if err != nil {
+		return nil, err
+	}
+
+	if srcSize != uint64(len(base)) {
+		return nil, fmt.Errorf("delta base size mismatch")
+	}
+
+	dstSize, delta, err := readDeltaSize(delta)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]byte, 0, dstSize)
+	for len(delta) != 0 {
+		cmd := delta[0]
+		delta = delta[1:]
+
+		switch {
+		case cmd&0x80 != 0:
+			// copy from base
+			var offset, size uint64
+			for i := uint(0); i < 4; i++ {
+				if cmd&(1<<i) == 0 {
+					continue
+				}
+
+				if len(delta) == 0 {
+					return nil, fmt.Errorf("invalid delta copy instruction")
+				}
+
+				offset |= uint64(delta[0]) << (8 * i)
+				delta = delta[1:]
+			}
+
+			for i := uint(0); i < 3; i++ {
+				if cmd&(0x10<<i) == 0 {
+					continue
+				}
+
+				if len(delta) == 0 {
+					return nil, fmt.Errorf("invalid delta copy instruction")
+				}
+
+				size |= uint64(delta[0]) << (8 * i)
+				delta = delta[1:]
+			}
+
+			if size == 0 {
+				size = 0x10000
+			}
+
+			if offset+size > uint64(len(base)) {
+				return nil, fmt.Errorf("delta copy out of range")
+			}
+
+			ret = append(ret, base[offset:offset+size]...)
+		case cmd != 0:
+			// insert
+			if int(cmd) > len(delta) {
+				return nil, fmt.Errorf("invalid delta insert instruction")
+			}
+
+			ret = append(ret, delta[:cmd]...)
+			delta = delta[cmd:]
+		default:
+			return nil, fmt.Errorf("invalid delta instruction 0")
+		}
+	}
+
+	if uint64(len(ret)) != dstSize {
+		return nil, fmt.Errorf("delta result size mismatch")
+	}
+
+	return ret, nil
+}
+
+func readDeltaSize(delta []byte) (uint64, []byte, error) {
+	var size uint64
+	for shift := uint(0); ; shift += 7 {
+		if len(delta) == 0 {
+			return 0, nil, fmt.Errorf("invalid delta size")
+		}
+
+		b := delta[0]
+		delta = delta[1:]
+
+		size |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return size, delta, nil
+		}
+	}
+}
+
+func objectID(typ objectType, data []byte) string {
+	h := sha1.New() // nolint:gosec
+	_, _ = h.Write([]byte(typ.String() + " " + strconv.Itoa(len(data)) + "\x00"))
+	_, _ = h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -36,6 +36,12 @@ func readPktSize(r io.Reader, buf []byte) (int, pktStatus, error) {
 		return 0, pktInvalid, err
 	}
 
+	for _, c := range buf[:4] {
+		if !isHexDigit(c) {
+			return 0, pktInvalid, fmt.Errorf("invalid pkt-line size %q", buf[:4])
+		}
+	}
+
 	size := parsePktSize(buf)
 	switch size {
 	case 0:
@@ -77,6 +83,10 @@ func parsePktSize(sizeBuf []byte) int {
 		parseHexDigit(sizeBuf[0])<<12
 }
 
+func isHexDigit(x byte) bool {
+	return (x >= 'A' && x <= 'F') || (x >= 'a' && x <= 'f') || (x >= '0' && x <= '9')
+}
+
 func parseHexDigit(x byte) int {
 	switch {
 	case x >= 'A' && x <= 'F':
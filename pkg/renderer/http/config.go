@@ -45,6 +45,67 @@ type rendererHTTPConfig struct {
 
 	// BaseURL
 	BaseURL string `yaml:"base_url"`
+
+	// ExpectedStatus is the list of status codes of a valid response
+	//
+	// Defaults to any 2xx status code
+	ExpectedStatus []int `yaml:"expected_status"`
+
+	// Retry failed requests
+	Retry retryConfig `yaml:"retry"`
+
+	// CacheKeyHeaders are names of request headers taken into account
+	// when caching response, in addition to method, url, body and credentials
+	//
+	// credentials (basic auth, userinfo in url and `Authorization`,
+	// `Proxy-Authorization` and `Cookie` headers) are always taken into
+	// account by their sha256 hash, no matter listed or not
+	//
+	// Defaults to all headers in config
+	CacheKeyHeaders []string `yaml:"cache_key_headers"`
+}
+
+type retryConfig struct {
+	rs.BaseField `yaml:"-"`
+
+	// MaxAttempts is the maximum count of requests sent, including the
+	// first one, requests are retried on network error and status codes
+	// 408, 429 and 5xx
+	//
+	// Defaults to `1` (no retry)
+	MaxAttempts int `yaml:"max_attempts"`
+
+	// Delay before the first retry, doubled for each following retry
+	//
+	// Defaults to `1s`
+	Delay time.Duration `yaml:"delay"`
+
+	// MaxDelay limits the delay between retries
+	//
+	// Defaults to `30s`
+	MaxDelay time.Duration `yaml:"max_delay"`
+}
+
+// delay returns time to wait before retrying the nth (starting from 1) time
+func (c *retryConfig) delay(n int) time.Duration {
+	delay, maxDelay := c.Delay, c.MaxDelay
+	if delay <= 0 {
+		delay = time.Second
+	}
+
+	if maxDelay <= 0 {
+		maxDelay = 30 * time.Second
+	}
+
+	for i := 1; i < n && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > maxDelay {
+		return maxDelay
+	}
+
+	return delay
 }
 
 // inputHTTPSpec for renderer value
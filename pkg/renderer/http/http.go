@@ -4,9 +4,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
-	"path"
-	"strings"
 
 	"arhat.dev/pkg/fshelper"
 	"arhat.dev/pkg/rshelper"
@@ -43,6 +40,9 @@ type Driver struct {
 	defaultClient *http.Client
 }
 
+// ConcurrencySafe implements dukkha.ConcurrentRenderer
+func (d *Driver) ConcurrencySafe() bool { return true }
+
 func (d *Driver) Init(cacheFS *fshelper.OSFS) error {
 	err := d.BaseTwoTierCachedRenderer.Init(cacheFS)
 	if err != nil {
@@ -119,13 +119,24 @@ func (d *Driver) RenderYaml(
 		config = &spec.Config
 	}
 
+	req, err := newRequest(reqURL, config)
+	if err != nil {
+		return nil, fmt.Errorf("renderer.%s: %w", d.name, err)
+	}
+
 	data, err := renderer.HandleRenderingRequestWithRemoteFetch(
 		d.Cache,
-		cache.IdentifiableString(reqURL),
-		func(_ cache.IdentifiableObject) (io.ReadCloser, error) {
-			return d.fetchRemote(client, reqURL, config)
+		req.cacheKey(config.CacheKeyHeaders),
+		func(obj cache.IdentifiableObject) (io.ReadCloser, error) {
+			ret, err2 := d.fetchRemote(rc, client, req, config, obj)
+			if err2 != nil || !req.hasCredentials() {
+				return ret, err2
+			}
+
+			return cache.PrivateContent(ret), nil
 		},
 		d.Attributes(attributes),
+		renderer.FetchOptions{Resource: req.resource()},
 	)
 
 	if err != nil {
@@ -137,62 +148,3 @@ func (d *Driver) RenderYaml(
 
 	return data, err
 }
-
-func (d *Driver) fetchRemote(
-	client *http.Client,
-	targetURL string,
-	config *rendererHTTPConfig,
-) (io.ReadCloser, error) {
-	var (
-		req *http.Request
-		err error
-	)
-
-	var body io.Reader
-	if config.Body != nil {
-		body = strings.NewReader(*config.Body)
-	}
-
-	method := strings.ToUpper(config.Method)
-	if len(method) == 0 {
-		method = http.MethodGet
-	}
-
-	if len(config.BaseURL) != 0 {
-		var baseURL *url.URL
-		baseURL, err = url.Parse(config.BaseURL)
-		if err != nil {
-			return nil, err
-		}
-
-		baseURL.Path = path.Join(baseURL.Path, targetURL)
-		targetURL = baseURL.String()
-	}
-
-	req, err = http.NewRequest(method, targetURL, body)
-	if err != nil {
-		return nil, fmt.Errorf("creating http request: %w", err)
-	}
-
-	if len(config.User) != 0 {
-		req.SetBasicAuth(config.User, config.Password)
-	}
-
-	seen := make(map[string]struct{})
-	for _, h := range config.Headers {
-		_, ok := seen[h.Name]
-		if !ok {
-			seen[h.Name] = struct{}{}
-			req.Header.Set(h.Name, h.Value)
-		} else {
-			req.Header.Add(h.Name, h.Value)
-		}
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	return resp.Body, nil
-}
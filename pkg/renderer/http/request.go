@@ -0,0 +1,412 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"arhat.dev/pkg/log"
+
+	"arhat.dev/dukkha/pkg/cache"
+)
+
+// request is the http request resolved from config
+type request struct {
+	method string
+	url    string
+	body   *string
+	header http.Header
+
+	user     string
+	password string
+}
+
+func newRequest(targetURL string, config *rendererHTTPConfig) (*request, error) {
+	method := strings.ToUpper(config.Method)
+	if len(method) == 0 {
+		method = http.MethodGet
+	}
+
+	if len(config.BaseURL) != 0 {
+		baseURL, err := url.Parse(config.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base url: %w", err)
+		}
+
+		baseURL.Path = path.Join(baseURL.Path, targetURL)
+		targetURL = baseURL.String()
+	}
+
+	header := make(http.Header)
+	seen := make(map[string]struct{})
+	for _, h := range config.Headers {
+		_, ok := seen[h.Name]
+		if !ok {
+			seen[h.Name] = struct{}{}
+			header.Set(h.Name, h.Value)
+		} else {
+			header.Add(h.Name, h.Value)
+		}
+	}
+
+	return &request{
+		method: method,
+		url:    targetURL,
+		body:   config.Body,
+		header: header,
+
+		user:     config.User,
+		password: config.Password,
+	}, nil
+}
+
+// credentialHeaders are always taken into account by the hash of their
+// values when caching response, so credentials are never stored in cache key
+var credentialHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+func isCredentialHeader(name string) bool {
+	for _, h := range credentialHeaders {
+		if h == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cacheKey identifies response of the request by method, url, body,
+// credentials and headers with name in keyHeaders (all other headers when
+// keyHeaders is empty)
+func (r *request) cacheKey(keyHeaders []string) cache.IdentifiableObject {
+	var names []string
+	if len(keyHeaders) == 0 {
+		for name := range r.header {
+			if !isCredentialHeader(name) {
+				names = append(names, name)
+			}
+		}
+	} else {
+		for _, name := range keyHeaders {
+			name = http.CanonicalHeaderKey(name)
+			if _, ok := r.header[name]; ok && !isCredentialHeader(name) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	reqURL, ext := r.url, cache.IdentifiableString(r.url).Ext()
+	var userinfo *url.Userinfo
+	if u, err := url.Parse(r.url); err == nil {
+		ext = cache.IdentifiableString(u.Path).Ext()
+
+		userinfo, u.User = u.User, nil
+		reqURL = u.String()
+	}
+
+	// credentials are always taken into account, so response fetched with
+	// credentials is not shared with others
+	credentials := sha256.New()
+	hasCredentials := false
+	if userinfo != nil {
+		hasCredentials = true
+		_, _ = credentials.Write([]byte("userinfo: " + userinfo.String() + "\n"))
+	}
+
+	if len(r.user)+len(r.password) != 0 {
+		hasCredentials = true
+		_, _ = credentials.Write([]byte("basic-auth: " + r.user + ":" + r.password + "\n"))
+	}
+
+	for _, name := range credentialHeaders {
+		if values, ok := r.header[name]; ok {
+			hasCredentials = true
+			_, _ = credentials.Write([]byte(name + ": " + strings.Join(values, ", ") + "\n"))
+		}
+	}
+
+	// keep plain url as the key of simple GET request
+	if r.method == http.MethodGet && r.body == nil && len(names) == 0 && !hasCredentials {
+		return &requestCacheKey{id: reqURL, ext: ext}
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString(r.method + " " + reqURL)
+	for _, name := range names {
+		sb.WriteString("\n" + name + ": " + strings.Join(r.header[name], ", "))
+	}
+
+	if r.body != nil {
+		sum := sha256.Sum256([]byte(*r.body))
+		sb.WriteString("\nbody-sha256: " + hex.EncodeToString(sum[:]))
+	}
+
+	if hasCredentials {
+		sb.WriteString("\ncredentials-sha256: " + hex.EncodeToString(credentials.Sum(nil)))
+	}
+
+	return &requestCacheKey{id: sb.String(), ext: ext}
+}
+
+// hasCredentials returns true when the request is sent with credentials
+// resource is the human readable name of the request without credentials
+func (r *request) resource() string {
+	reqURL := r.url
+	if u, err := url.Parse(r.url); err == nil {
+		u.User = nil
+		reqURL = u.String()
+	}
+
+	return r.method + " " + reqURL
+}
+
+func (r *request) hasCredentials() bool {
+	if len(r.user)+len(r.password) != 0 {
+		return true
+	}
+
+	if u, err := url.Parse(r.url); err == nil && u.User != nil {
+		return true
+	}
+
+	for _, name := range credentialHeaders {
+		if _, ok := r.header[name]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+type requestCacheKey struct {
+	id  string
+	ext string
+}
+
+func (k *requestCacheKey) ScopeUniqueID() string { return k.id }
+func (k *requestCacheKey) Ext() string           { return k.ext }
+
+func (r *request) do(ctx context.Context, client *http.Client, cond *validators) (*http.Response, error) {
+	var body io.Reader
+	if r.body != nil {
+		body = strings.NewReader(*r.body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, r.url, body)
+	if err != nil {
+		return nil, fmt.Errorf("creating http request: %w", err)
+	}
+
+	req.Header = r.header.Clone()
+	if len(r.user) != 0 {
+		req.SetBasicAuth(r.user, r.password)
+	}
+
+	if cond != nil {
+		if len(cond.ETag) != 0 {
+			req.Header.Set("If-None-Match", cond.ETag)
+		}
+
+		if len(cond.LastModified) != 0 {
+			req.Header.Set("If-Modified-Since", cond.LastModified)
+		}
+	}
+
+	return client.Do(req)
+}
+
+// fetchRemote sends the request with retries, when there is expired
+// cache of the response, conditional request is made using validators
+// of the cached response, and cache.ErrNotModified is returned on
+// status 304
+func (d *Driver) fetchRemote(
+	ctx context.Context,
+	client *http.Client,
+	r *request,
+	config *rendererHTTPConfig,
+	obj cache.IdentifiableObject,
+) (io.ReadCloser, error) {
+	var cond *validators
+	if _, ok := obj.(cache.ExpiredObject); ok && r.method == http.MethodGet {
+		cond = d.loadValidators(obj)
+	}
+
+	maxAttempts := config.Retry.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		var (
+			retryable  bool
+			retryAfter time.Duration
+		)
+
+		resp, err := r.do(ctx, client, cond)
+		if err != nil {
+			retryable = ctx.Err() == nil
+		} else {
+			switch {
+			case resp.StatusCode == http.StatusNotModified && cond != nil:
+				_ = resp.Body.Close()
+				return nil, cache.ErrNotModified
+			case isExpectedStatus(resp.StatusCode, config.ExpectedStatus):
+				return d.recordValidators(obj, r.method, resp), nil
+			}
+
+			err = newUnexpectedStatusError(resp, config.ExpectedStatus)
+			retryable = isRetryableStatus(resp.StatusCode)
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		}
+
+		if !retryable || attempt >= maxAttempts {
+			return nil, err
+		}
+
+		delay := config.Retry.delay(attempt)
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+
+		log.Log.I("retrying http request",
+			log.String("method", r.method),
+			log.String("url", r.url),
+			log.Int("attempt", attempt),
+			log.Duration("delay", delay),
+			log.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func isExpectedStatus(code int, expected []int) bool {
+	if len(expected) == 0 {
+		return code >= 200 && code < 300
+	}
+
+	for _, c := range expected {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusRequestTimeout ||
+		code == http.StatusTooManyRequests ||
+		code >= 500
+}
+
+// parseRetryAfter parses Retry-After header value in seconds
+func parseRetryAfter(v string) time.Duration {
+	seconds, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+func newUnexpectedStatusError(resp *http.Response, expected []int) error {
+	defer func() { _ = resp.Body.Close() }()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	expecting := "2xx"
+	if len(expected) != 0 {
+		expecting = strings.Trim(fmt.Sprint(expected), "[]")
+	}
+
+	return fmt.Errorf("unexpected response status %q (expecting %s) from %s %q: %s",
+		resp.Status, expecting, resp.Request.Method, resp.Request.URL.String(),
+		strings.TrimSpace(string(msg)),
+	)
+}
+
+// validators of cached response for conditional requests
+type validators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+func (d *Driver) loadValidators(obj cache.IdentifiableObject) *validators {
+	data, err := d.Cache.ReadMetadata(obj)
+	if err != nil {
+		return nil
+	}
+
+	ret := &validators{}
+	if json.Unmarshal(data, ret) != nil || (len(ret.ETag) == 0 && len(ret.LastModified) == 0) {
+		return nil
+	}
+
+	return ret
+}
+
+// recordValidators saves validators of the response as metadata of the
+// cached response after its body is fully read, so they always match the
+// cached content
+func (d *Driver) recordValidators(obj cache.IdentifiableObject, method string, resp *http.Response) io.ReadCloser {
+	v := &validators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+
+	return &eofHookReader{
+		ReadCloser: resp.Body,
+		onEOF: func() {
+			if method != http.MethodGet || (len(v.ETag) == 0 && len(v.LastModified) == 0) {
+				err := d.Cache.RemoveMetadata(obj)
+				if err != nil {
+					log.Log.I("removing stale http validators", log.Error(err))
+				}
+
+				return
+			}
+
+			data, err := json.Marshal(v)
+			if err == nil {
+				err = d.Cache.WriteMetadata(obj, data)
+			}
+
+			if err != nil {
+				log.Log.I("saving http validators", log.Error(err))
+			}
+		},
+	}
+}
+
+// eofHookReader calls onEOF once when underlying reader reaches EOF
+type eofHookReader struct {
+	io.ReadCloser
+
+	onEOF func()
+	done  bool
+}
+
+func (r *eofHookReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if err == io.EOF && !r.done {
+		r.done = true
+		r.onEOF()
+	}
+
+	return n, err
+}
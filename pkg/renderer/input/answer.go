@@ -0,0 +1,279 @@
+package input
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/templateutils"
+)
+
+// errInvalidAnswer is returned when answer can be corrected by user
+var errInvalidAnswer = errors.New("invalid answer")
+
+// handleInput gets answer from env, terminal or default value
+func handleInput(rc dukkha.RenderingContext, spec *inputSpec, c *console) ([]byte, error) {
+	validate, err := newValidator(rc, spec.Validate)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(spec.Key) != 0 {
+		envName := constant.GetInputEnvName(spec.Key)
+		if v := rc.Get(envName); v.IsSet() {
+			// answers from env are usually secrets set by ci
+			ret, err2 := spec.parseAnswer(v.String(), validate, true)
+			if err2 != nil {
+				return nil, fmt.Errorf("answer from %s: %w", envName, err2)
+			}
+
+			return ret, nil
+		}
+	}
+
+	hide := spec.Config.HideInput != nil && *spec.Config.HideInput
+	if !c.interactive {
+		if spec.Default != nil {
+			return spec.parseAnswer(*spec.Default, validate, hide)
+		}
+
+		if len(spec.Key) == 0 {
+			return nil, fmt.Errorf(
+				"stdin is not a terminal, please set key and default value of the input",
+			)
+		}
+
+		return nil, fmt.Errorf(
+			"stdin is not a terminal, please answer %q with env %s or flag `--input %s=<value>`",
+			spec.Key, constant.GetInputEnvName(spec.Key), spec.Key,
+		)
+	}
+
+	for {
+		_, _ = fmt.Fprint(c.out, spec.formatPrompt(hide))
+
+		answer, err := c.readLine(hide)
+		if err != nil {
+			return nil, fmt.Errorf("reading input: %w", err)
+		}
+
+		if len(answer) == 0 && spec.Default != nil {
+			answer = *spec.Default
+		}
+
+		ret, err := spec.parseAnswer(answer, validate, hide)
+		if err == nil {
+			return ret, nil
+		}
+
+		if !errors.Is(err, errInvalidAnswer) {
+			return nil, err
+		}
+
+		_, _ = fmt.Fprintln(c.out, err.Error())
+	}
+}
+
+// formatPrompt generates prompt text with choices and default value
+func (s *inputSpec) formatPrompt(hide bool) string {
+	sb := &strings.Builder{}
+	for i, choice := range s.Choices {
+		sb.WriteString(fmt.Sprintf("  %d) %s\n", i+1, choice))
+	}
+
+	sb.WriteString(s.Config.Prompt)
+
+	switch {
+	case s.Confirm:
+		hint := "[y/n] "
+		if s.Default != nil {
+			yes, err := parseYesNo(*s.Default)
+			if err == nil && yes {
+				hint = "[Y/n] "
+			} else if err == nil {
+				hint = "[y/N] "
+			}
+		}
+
+		sb.WriteString(hint)
+	case s.Default != nil && !hide:
+		sb.WriteString("[" + *s.Default + "] ")
+	}
+
+	return sb.String()
+}
+
+// parseAnswer checks answer and converts it to yaml value, the answer is
+// left out of error messages when redact is true
+func (s *inputSpec) parseAnswer(answer string, validate validator, redact bool) ([]byte, error) {
+	switch {
+	case s.Confirm:
+		yes, err := parseYesNo(answer)
+		if err != nil {
+			return nil, err
+		}
+
+		err = validate(yes, redact)
+		if err != nil {
+			return nil, err
+		}
+
+		return []byte(strconv.FormatBool(yes)), nil
+	case s.MultiSelect:
+		var selected []string
+		for _, part := range strings.Split(answer, ",") {
+			part = strings.TrimSpace(part)
+			if len(part) == 0 {
+				continue
+			}
+
+			v, err := s.selectChoice(part, redact)
+			if err != nil {
+				return nil, err
+			}
+
+			selected = append(selected, v)
+		}
+
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("%w: nothing selected", errInvalidAnswer)
+		}
+
+		err := validate(selected, redact)
+		if err != nil {
+			return nil, err
+		}
+
+		return yaml.Marshal(selected)
+	default:
+		v, err := s.selectChoice(answer, redact)
+		if err != nil {
+			return nil, err
+		}
+
+		err = validate(v, redact)
+		if err != nil {
+			return nil, err
+		}
+
+		return []byte(v), nil
+	}
+}
+
+// selectChoice finds choice by value or index (starting from 1)
+func (s *inputSpec) selectChoice(answer string, redact bool) (string, error) {
+	if len(s.Choices) == 0 {
+		return answer, nil
+	}
+
+	answer = strings.TrimSpace(answer)
+	for _, c := range s.Choices {
+		if c == answer {
+			return c, nil
+		}
+	}
+
+	idx, err := strconv.ParseInt(answer, 10, 64)
+	if err == nil && idx >= 1 && int(idx) <= len(s.Choices) {
+		return s.Choices[idx-1], nil
+	}
+
+	if redact {
+		return "", fmt.Errorf("%w: answer is not one of [%s]",
+			errInvalidAnswer, strings.Join(s.Choices, ", "),
+		)
+	}
+
+	return "", fmt.Errorf("%w: %q is not one of [%s]",
+		errInvalidAnswer, answer, strings.Join(s.Choices, ", "),
+	)
+}
+
+func parseYesNo(answer string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes", "true":
+		return true, nil
+	case "n", "no", "false":
+		return false, nil
+	default:
+		return false, fmt.Errorf("%w: please answer y or n", errInvalidAnswer)
+	}
+}
+
+// validator checks parsed answer (string, []string or bool), the answer is
+// left out of the error message when redact is true
+type validator func(value interface{}, redact bool) error
+
+func newValidator(rc dukkha.RenderingContext, expr string) (validator, error) {
+	switch {
+	case len(expr) == 0:
+		return func(interface{}, bool) error { return nil }, nil
+	case strings.Contains(expr, "{{"):
+		tpl, err := templateutils.CreateTemplate(rc).Parse(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid validate template: %w", err)
+		}
+
+		return func(value interface{}, redact bool) error {
+			buf := &bytes.Buffer{}
+			err2 := tpl.Execute(buf, value)
+			if err2 != nil {
+				return fmt.Errorf("executing validate template: %w", err2)
+			}
+
+			if result := strings.TrimSpace(buf.String()); result != "true" {
+				if redact {
+					return fmt.Errorf("%w: answer does not pass validation %s",
+						errInvalidAnswer, expr,
+					)
+				}
+
+				return fmt.Errorf("%w: %v does not pass validation %s",
+					errInvalidAnswer, value, expr,
+				)
+			}
+
+			return nil
+		}, nil
+	default:
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid validate regex: %w", err)
+		}
+
+		return func(value interface{}, redact bool) error {
+			var values []string
+			switch t := value.(type) {
+			case string:
+				values = []string{t}
+			case []string:
+				values = t
+			default:
+				values = []string{fmt.Sprint(t)}
+			}
+
+			for _, v := range values {
+				if !re.MatchString(v) {
+					if redact {
+						return fmt.Errorf("%w: answer does not match %s",
+							errInvalidAnswer, expr,
+						)
+					}
+
+					return fmt.Errorf("%w: %q does not match %s",
+						errInvalidAnswer, v, expr,
+					)
+				}
+			}
+
+			return nil
+		}, nil
+	}
+}
@@ -2,9 +2,11 @@ package input
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"arhat.dev/pkg/iohelper"
+	"arhat.dev/pkg/rshelper"
 	"arhat.dev/pkg/yamlhelper"
 	"arhat.dev/rs"
 	"golang.org/x/term"
@@ -35,7 +37,7 @@ type Driver struct {
 }
 
 func (d *Driver) RenderYaml(
-	_ dukkha.RenderingContext, rawData interface{}, attributes []dukkha.RendererAttribute,
+	rc dukkha.RenderingContext, rawData interface{}, attributes []dukkha.RendererAttribute,
 ) ([]byte, error) {
 	rawData, err := rs.NormalizeRawData(rawData)
 	if err != nil {
@@ -56,37 +58,71 @@ func (d *Driver) RenderYaml(
 		}
 	}
 
-	var (
-		prompt = d.Config.Prompt
-		hide   = d.Config.HideInput
-	)
+	spec := &inputSpec{Config: d.Config}
 	if useSpec {
-		spec := rs.Init(&inputSpec{}, nil).(*inputSpec)
+		spec = rshelper.InitAll(&inputSpec{}, &rs.Options{
+			InterfaceTypeHandler: rc,
+		}).(*inputSpec)
 		err = yaml.Unmarshal(promptBytes, spec)
 		if err != nil {
 			return nil, fmt.Errorf("renderer.%s: invalid input spec %w", d.name, err)
 		}
 
-		if spec.Config.HideInput != nil {
-			hide = spec.Config.HideInput
+		err = spec.ResolveFields(rc, -1)
+		if err != nil {
+			return nil, fmt.Errorf("renderer.%s: resolving input spec: %w", d.name, err)
+		}
+
+		if spec.Config.HideInput == nil {
+			spec.Config.HideInput = d.Config.HideInput
 		}
 
-		if len(spec.Config.Prompt) != 0 {
-			prompt = spec.Config.Prompt
+		if len(spec.Config.Prompt) == 0 {
+			spec.Config.Prompt = d.Config.Prompt
 		}
 	} else if len(promptBytes) != 0 {
-		prompt = string(promptBytes)
+		spec.Config.Prompt = string(promptBytes)
+	}
+
+	fd := int(os.Stdin.Fd())
+	ret, err := handleInput(rc, spec, &console{
+		in:          os.Stdin,
+		out:         os.Stdout,
+		interactive: term.IsTerminal(fd),
+		readPassword: func() ([]byte, error) {
+			return term.ReadPassword(fd)
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("renderer.%s: %w", d.name, err)
 	}
 
-	fmt.Print(prompt)
+	return ret, nil
+}
+
+// console for user interaction
+type console struct {
+	in  io.Reader
+	out io.Writer
+
+	// interactive is true when stdin is a terminal
+	interactive bool
+
+	readPassword func() ([]byte, error)
+}
+
+func (c *console) readLine(hide bool) (string, error) {
+	var (
+		ret []byte
+		err error
+	)
 
-	if hide != nil && *hide {
-		ret, err2 := term.ReadPassword(int(os.Stdin.Fd()))
-		fmt.Println()
-		return ret, err2
+	if hide {
+		ret, err = c.readPassword()
+	} else {
+		ret, err = iohelper.ReadInputLine(c.in)
 	}
 
-	ret, err := iohelper.ReadInputLine(os.Stdin)
-	fmt.Println()
-	return ret, err
+	_, _ = fmt.Fprintln(c.out)
+	return string(ret), err
 }
@@ -0,0 +1,187 @@
+package input
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"arhat.dev/pkg/iohelper"
+	"github.com/stretchr/testify/assert"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+)
+
+func TestHandleInput(t *testing.T) {
+	strPtr := func(s string) *string { return &s }
+	boolPtr := func(b bool) *bool { return &b }
+
+	for _, test := range []struct {
+		name        string
+		spec        inputSpec
+		env         map[string]string
+		interactive bool
+		input       string
+
+		expected string
+		err      bool
+
+		// secret must not appear in error and prompt output
+		secret string
+	}{
+		{
+			name:        "Plain",
+			spec:        inputSpec{},
+			interactive: true,
+			input:       "foo\n",
+			expected:    "foo",
+		},
+		{
+			name:        "Default",
+			spec:        inputSpec{Default: strPtr("bar")},
+			interactive: true,
+			input:       "\n",
+			expected:    "bar",
+		},
+		{
+			name:        "Choice By Index",
+			spec:        inputSpec{Choices: []string{"patch", "minor", "major"}},
+			interactive: true,
+			input:       "2\n",
+			expected:    "minor",
+		},
+		{
+			name:        "Retry Invalid Choice",
+			spec:        inputSpec{Choices: []string{"patch", "minor", "major"}},
+			interactive: true,
+			input:       "none\nmajor\n",
+			expected:    "major",
+		},
+		{
+			name:        "Multi Select",
+			spec:        inputSpec{Choices: []string{"a", "b", "c"}, MultiSelect: true},
+			interactive: true,
+			input:       "1, c\n",
+			expected:    "- a\n- c\n",
+		},
+		{
+			name:        "Confirm",
+			spec:        inputSpec{Confirm: true},
+			interactive: true,
+			input:       "maybe\nY\n",
+			expected:    "true",
+		},
+		{
+			name:        "Validate Regex",
+			spec:        inputSpec{Validate: `^v\d+$`},
+			interactive: true,
+			input:       "1\nv1\n",
+			expected:    "v1",
+		},
+		{
+			name:        "Validate Template",
+			spec:        inputSpec{Validate: `{{ gt (len .) 3 }}`},
+			interactive: true,
+			input:       "foo\nfoobar\n",
+			expected:    "foobar",
+		},
+		{
+			name:     "Env Answer",
+			spec:     inputSpec{Key: "bump-type", Choices: []string{"patch", "minor"}},
+			env:      map[string]string{"DUKKHA_INPUT_BUMP_TYPE": "minor"},
+			expected: "minor",
+		},
+		{
+			name: "Invalid Env Answer",
+			spec: inputSpec{Key: "bump-type", Choices: []string{"patch", "minor"}},
+			env:  map[string]string{"DUKKHA_INPUT_BUMP_TYPE": "major"},
+			err:  true,
+		},
+		{
+			name:   "Invalid Env Answer Redacted",
+			spec:   inputSpec{Key: "token", Validate: `^ghp_`},
+			env:    map[string]string{"DUKKHA_INPUT_TOKEN": "secret-token"},
+			err:    true,
+			secret: "secret-token",
+		},
+		{
+			name:   "Invalid Env Choice Redacted",
+			spec:   inputSpec{Key: "bump-type", Choices: []string{"patch", "minor"}},
+			env:    map[string]string{"DUKKHA_INPUT_BUMP_TYPE": "secret-choice"},
+			err:    true,
+			secret: "secret-choice",
+		},
+		{
+			name: "Hidden Input Redacted",
+			spec: inputSpec{
+				Validate: `{{ gt (len .) 12 }}`,
+				Config:   configSpec{HideInput: boolPtr(true)},
+			},
+			interactive: true,
+			input:       "short\nlong-enough-answer\n",
+			expected:    "long-enough-answer",
+			secret:      "short",
+		},
+		{
+			name: "Hidden Input Redacted EOF",
+			spec: inputSpec{
+				Validate: `^v\d+$`,
+				Config:   configSpec{HideInput: boolPtr(true)},
+			},
+			interactive: true,
+			input:       "secret\n",
+			err:         true,
+			secret:      "secret",
+		},
+		{
+			name:     "Non Interactive Default",
+			spec:     inputSpec{Key: "bump-type", Default: strPtr("patch")},
+			expected: "patch",
+		},
+		{
+			name: "Non Interactive No Answer",
+			spec: inputSpec{Key: "bump-type"},
+			err:  true,
+		},
+		{
+			name:        "EOF",
+			spec:        inputSpec{Validate: "^v"},
+			interactive: true,
+			input:       "1\n",
+			err:         true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			rc := dt.NewTestContext(context.TODO())
+			for k, v := range test.env {
+				rc.AddEnv(true, &dukkha.EnvEntry{Name: k, Value: v})
+			}
+
+			in, out := strings.NewReader(test.input), &bytes.Buffer{}
+			ret, err := handleInput(rc, &test.spec, &console{
+				in:          in,
+				out:         out,
+				interactive: test.interactive,
+				readPassword: func() ([]byte, error) {
+					return iohelper.ReadInputLine(in)
+				},
+			})
+
+			if len(test.secret) != 0 {
+				assert.NotContains(t, out.String(), test.secret)
+				if err != nil {
+					assert.NotContains(t, err.Error(), test.secret)
+				}
+			}
+
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, string(ret))
+		})
+	}
+}
@@ -20,4 +20,27 @@ type inputSpec struct {
 	rs.BaseField
 
 	Config configSpec `yaml:",inline"`
+
+	// Key to answer this input non-interactively, using env
+	// DUKKHA_INPUT_<KEY> or cli flag `--input key=value`
+	Key string `yaml:"key"`
+
+	// Choices to select from, user can answer with the value or
+	// the index (starting from 1) of the choice
+	Choices []string `yaml:"choices"`
+
+	// MultiSelect allows selecting multiple choices separated by comma,
+	// result is a yaml list
+	MultiSelect bool `yaml:"multi_select"`
+
+	// Default value when answer is empty or there is no terminal
+	Default *string `yaml:"default"`
+
+	// Validate answer using regular expression, or golang template
+	// expression when containing `{{` (the answer is passed as `.`),
+	// answer is valid when the template is rendered as `true`
+	Validate string `yaml:"validate"`
+
+	// Confirm asks a yes/no question, result is `true` or `false`
+	Confirm bool `yaml:"confirm"`
 }
@@ -0,0 +1,55 @@
+package jsonnet
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"arhat.dev/pkg/fshelper"
+	"github.com/google/go-jsonnet"
+)
+
+var _ jsonnet.Importer = (*fsImporter)(nil)
+
+// fsImporter imports files relative to the importing file, then searches
+// jpaths in order, relative paths are relative to the working dir of fs
+type fsImporter struct {
+	jpaths []string
+	fs     *fshelper.OSFS
+
+	// cache of file contents by path, jsonnet requires the same contents
+	// for the same path
+	cache map[string]jsonnet.Contents
+}
+
+// Import implements jsonnet.Importer
+func (fi *fsImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	var candidates []string
+	if filepath.IsAbs(importedPath) {
+		candidates = []string{importedPath}
+	} else {
+		candidates = append(candidates, filepath.Join(filepath.Dir(importedFrom), importedPath))
+		for _, p := range fi.jpaths {
+			candidates = append(candidates, filepath.Join(p, importedPath))
+		}
+	}
+
+	var firstErr error
+	for _, c := range candidates {
+		if contents, ok := fi.cache[c]; ok {
+			return contents, c, nil
+		}
+
+		data, err := fi.fs.ReadFile(c)
+		if err == nil {
+			contents := jsonnet.MakeContents(string(data))
+			fi.cache[c] = contents
+			return contents, c, nil
+		}
+
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return jsonnet.Contents{}, "", fmt.Errorf("couldn't open import %q: %w", importedPath, firstErr)
+}
@@ -0,0 +1,231 @@
+package jsonnet
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"arhat.dev/pkg/yamlhelper"
+	"arhat.dev/rs"
+	"github.com/google/go-jsonnet"
+	"github.com/google/go-jsonnet/ast"
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/renderer"
+)
+
+const (
+	DefaultName = "jsonnet"
+)
+
+// inlineFilename is the name of inline jsonnet code, it's in the current
+// dir so relative imports are resolved from DUKKHA_WORKDIR
+const inlineFilename = "<inline>"
+
+func init() { dukkha.RegisterRenderer(DefaultName, NewDefault) }
+
+func NewDefault(name string) dukkha.Renderer {
+	return &Driver{
+		name: name,
+	}
+}
+
+var _ dukkha.Renderer = (*Driver)(nil)
+
+// Driver evaluates jsonnet code
+type Driver struct {
+	rs.BaseField `yaml:"-"`
+
+	renderer.BaseRenderer `yaml:",inline"`
+
+	name string
+
+	Options configSpec `yaml:",inline"`
+}
+
+func (d *Driver) RenderYaml(
+	rc dukkha.RenderingContext, rawData interface{},
+	attributes []dukkha.RendererAttribute,
+) ([]byte, error) {
+	rawData, err := rs.NormalizeRawData(rawData)
+	if err != nil {
+		return nil, err
+	}
+
+	inputBytes, err := yamlhelper.ToYamlBytes(rawData)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"renderer.%s: unsupported input type %T: %w",
+			d.name, rawData, err,
+		)
+	}
+
+	var (
+		useSpec bool
+	)
+	for _, attr := range d.Attributes(attributes) {
+		switch attr {
+		case renderer.AttrUseSpec:
+			useSpec = true
+		default:
+		}
+	}
+
+	var (
+		config = &d.Options
+		code   = string(inputBytes)
+		file   string
+	)
+
+	if useSpec {
+		var spec *inputSpec
+		spec, err = resolveInputSpec(rc, inputBytes)
+		if err != nil {
+			return nil, fmt.Errorf("renderer.%s: %w", d.name, err)
+		}
+
+		config = &spec.Config
+		code, file = spec.Code, spec.File
+	}
+
+	if len(code) == 0 && len(file) != 0 {
+		var data []byte
+		data, err = rc.FS().ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("renderer.%s: reading jsonnet file: %w", d.name, err)
+		}
+
+		code = string(data)
+	} else {
+		file = inlineFilename
+	}
+
+	node, err := jsonnet.SnippetToAST(file, code)
+	if err != nil {
+		return nil, fmt.Errorf("renderer.%s: %w", d.name, err)
+	}
+
+	vm, err := newVM(rc, config, topLevelParams(node))
+	if err != nil {
+		return nil, fmt.Errorf("renderer.%s: %w", d.name, err)
+	}
+
+	ret, err := vm.Evaluate(node)
+	if err != nil {
+		return nil, fmt.Errorf("renderer.%s: %w", d.name, err)
+	}
+
+	return []byte(ret), nil
+}
+
+func resolveInputSpec(rc dukkha.RenderingContext, inputBytes []byte) (*inputSpec, error) {
+	spec := rs.Init(&inputSpec{}, nil).(*inputSpec)
+
+	err := yaml.Unmarshal(inputBytes, spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input spec: %w", err)
+	}
+
+	err = spec.ResolveFields(rc, -1)
+	if err != nil {
+		return nil, fmt.Errorf("resolving input spec: %w", err)
+	}
+
+	return spec, nil
+}
+
+// newVM creates a jsonnet vm reading files from DUKKHA_WORKDIR with
+// `env`, `values` and `matrix` set as ext vars, they are also set as
+// top-level args when listed in params
+func newVM(
+	rc dukkha.RenderingContext, config *configSpec, params map[string]struct{},
+) (*jsonnet.VM, error) {
+	vm := jsonnet.MakeVM()
+	vm.Importer(&fsImporter{
+		jpaths: config.ImportPaths,
+		fs:     rc.FS(),
+		cache:  make(map[string]jsonnet.Contents),
+	})
+
+	env := make(map[string]interface{})
+	for k, v := range rc.Env() {
+		env[k] = v.Get()
+	}
+
+	matrix := make(map[string]interface{})
+	for k, v := range rc.MatrixFilter().AsEntry() {
+		matrix[k] = v
+	}
+
+	for k, v := range map[string]interface{}{
+		"env":    env,
+		"values": rc.Values(),
+		"matrix": matrix,
+	} {
+		code, err := toCode(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", k, err)
+		}
+
+		vm.ExtCode(k, code)
+		if _, ok := params[k]; ok {
+			vm.TLACode(k, code)
+		}
+	}
+
+	for k, v := range config.ExtVars.NormalizedValue() {
+		code, err := toCode(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ext var %q: %w", k, err)
+		}
+
+		vm.ExtCode(k, code)
+	}
+
+	for k, v := range config.TLAs.NormalizedValue() {
+		code, err := toCode(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tla %q: %w", k, err)
+		}
+
+		vm.TLACode(k, code)
+	}
+
+	return vm, nil
+}
+
+// toCode converts go value to jsonnet code, json is valid jsonnet
+func toCode(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// topLevelParams returns parameter names of the top-level function, the
+// top-level function is the function literal with optional local bindings
+// before it
+//
+// jsonnet fails on top-level args not accepted by the function, so values
+// from dukkha are only passed as top-level args when requested
+func topLevelParams(node ast.Node) map[string]struct{} {
+	for {
+		switch n := node.(type) {
+		case *ast.Local:
+			node = n.Body
+		case *ast.Parens:
+			node = n.Inner
+		case *ast.Function:
+			ret := make(map[string]struct{}, len(n.Parameters))
+			for _, p := range n.Parameters {
+				ret[string(p.Name)] = struct{}{}
+			}
+
+			return ret
+		default:
+			return nil
+		}
+	}
+}
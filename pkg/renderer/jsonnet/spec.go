@@ -0,0 +1,33 @@
+package jsonnet
+
+import "arhat.dev/rs"
+
+type configSpec struct {
+	rs.BaseField
+
+	// ImportPaths are dirs to search for imported files when not found
+	// relative to the importing file
+	//
+	// relative paths are relative to DUKKHA_WORKDIR
+	ImportPaths []string `yaml:"import_paths"`
+
+	// ExtVars are additional external variables accessible by
+	// `std.extVar("<key>")`
+	ExtVars rs.AnyObjectMap `yaml:"ext_vars"`
+
+	// TLAs are top-level arguments passed to the jsonnet code when it
+	// evaluates to a function
+	TLAs rs.AnyObjectMap `yaml:"tlas"`
+}
+
+type inputSpec struct {
+	rs.BaseField
+
+	// Code is the inline jsonnet code
+	Code string `yaml:"code"`
+
+	// File is the path to jsonnet file, ignored when Code is set
+	File string `yaml:"file"`
+
+	Config configSpec `yaml:",inline"`
+}
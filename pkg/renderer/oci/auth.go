@@ -0,0 +1,179 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// credential for registry auth
+type credential struct {
+	Username string
+	Password string
+
+	// IdentityToken is the oauth2 refresh token
+	IdentityToken string
+}
+
+type dockerConfigFile struct {
+	Auths       map[string]dockerAuthEntry `json:"auths"`
+	CredHelpers map[string]string          `json:"credHelpers"`
+	CredsStore  string                     `json:"credsStore"`
+}
+
+type dockerAuthEntry struct {
+	Auth          string `json:"auth"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	IdentityToken string `json:"identitytoken"`
+}
+
+// defaultDockerConfigPath returns path to docker config used by docker cli
+func defaultDockerConfigPath() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); len(dir) != 0 {
+		return filepath.Join(dir, "config.json")
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+// normalizeRegistry converts server address in docker config to registry
+// name (e.g. `https://index.docker.io/v1/` to `docker.io`)
+func normalizeRegistry(server string) string {
+	if i := strings.Index(server, "://"); i != -1 {
+		server = server[i+3:]
+	}
+
+	if i := strings.IndexByte(server, '/'); i != -1 {
+		server = server[:i]
+	}
+
+	switch server {
+	case "index.docker.io", dockerHubHost:
+		return dockerHubRegistry
+	}
+
+	return server
+}
+
+// loadDockerCredential finds credential for registry in docker config file,
+// nil if not found
+func loadDockerCredential(
+	ctx context.Context, configFile string, readFile func(string) ([]byte, error), registry string,
+) (*credential, error) {
+	if len(configFile) == 0 {
+		return nil, nil
+	}
+
+	data, err := readFile(configFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("reading docker config: %w", err)
+	}
+
+	cfg := &dockerConfigFile{}
+	err = json.Unmarshal(data, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("decoding docker config: %w", err)
+	}
+
+	for server, helper := range cfg.CredHelpers {
+		if normalizeRegistry(server) == registry {
+			return runCredentialHelper(ctx, helper, server)
+		}
+	}
+
+	for server, entry := range cfg.Auths {
+		if normalizeRegistry(server) != registry {
+			continue
+		}
+
+		cred := &credential{
+			Username:      entry.Username,
+			Password:      entry.Password,
+			IdentityToken: entry.IdentityToken,
+		}
+
+		if len(entry.Auth) != 0 {
+			var auth []byte
+			auth, err = base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("invalid auth of %q in docker config: %w", server, err)
+			}
+
+			parts := strings.SplitN(string(auth), ":", 2)
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("invalid auth of %q in docker config", server)
+			}
+
+			cred.Username, cred.Password = parts[0], parts[1]
+		}
+
+		if len(cred.Username) != 0 || len(cred.IdentityToken) != 0 {
+			return cred, nil
+		}
+
+		// auth entry created by credsStore
+		break
+	}
+
+	if len(cfg.CredsStore) != 0 {
+		server := registry
+		if registry == dockerHubRegistry {
+			server = "https://index.docker.io/v1/"
+		}
+
+		return runCredentialHelper(ctx, cfg.CredsStore, server)
+	}
+
+	return nil, nil
+}
+
+// runCredentialHelper gets credential using docker credential helper
+// (`docker-credential-<helper> get`)
+func runCredentialHelper(ctx context.Context, helper, server string) (*credential, error) {
+	stdout := &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, "docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(server)
+	cmd.Stdout = stdout
+
+	err := cmd.Run()
+	if err != nil {
+		if strings.Contains(stdout.String(), "credentials not found") {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("running docker credential helper %q: %w", helper, err)
+	}
+
+	resp := &struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}{}
+	err = json.Unmarshal(stdout.Bytes(), resp)
+	if err != nil {
+		return nil, fmt.Errorf("decoding output of docker credential helper %q: %w", helper, err)
+	}
+
+	if resp.Username == "<token>" {
+		return &credential{IdentityToken: resp.Secret}, nil
+	}
+
+	return &credential{Username: resp.Username, Password: resp.Secret}, nil
+}
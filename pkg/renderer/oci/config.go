@@ -0,0 +1,117 @@
+package oci
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"arhat.dev/pkg/tlshelper"
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+// Config of registry access
+type Config struct {
+	rs.BaseField `yaml:"-"`
+
+	// Username and Password for registry auth, override auth in docker config
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	// DockerConfig is the path to docker config file for registry auth
+	//
+	// defaults to `${DOCKER_CONFIG}/config.json` or `~/.docker/config.json`
+	DockerConfig string `yaml:"docker_config"`
+
+	// PlainHTTP to access registry using http instead of https
+	PlainHTTP bool `yaml:"plain_http"`
+
+	TLS tlshelper.TLSConfig `yaml:"tls"`
+
+	// Platform to select from multi-platform images
+	//
+	// defaults to matrix kernel and arch, then host kernel and arch
+	Platform platformSpec `yaml:"platform"`
+}
+
+// platformSpec uses dukkha kernel and arch values
+type platformSpec struct {
+	rs.BaseField `yaml:"-"`
+
+	Kernel string `yaml:"kernel"`
+	Arch   string `yaml:"arch"`
+}
+
+// resolve converts platform to oci os, arch and variant
+func (p platformSpec) resolve(rc dukkha.RenderingContext) (os, arch, variant string) {
+	kernel, mArch := p.Kernel, p.Arch
+	if len(kernel) == 0 {
+		kernel = rc.MatrixKernel()
+	}
+	if len(kernel) == 0 {
+		kernel = rc.HostKernel()
+	}
+
+	if len(mArch) == 0 {
+		mArch = rc.MatrixArch()
+	}
+	if len(mArch) == 0 {
+		mArch = rc.HostArch()
+	}
+
+	os, ok := constant.GetOciOS(kernel)
+	if !ok {
+		os = kernel
+	}
+
+	arch, ok = constant.GetOciArch(mArch)
+	if !ok {
+		arch = mArch
+	}
+
+	variant, _ = constant.GetOciArchVariant(mArch)
+	return
+}
+
+// inputOCISpec for renderer value
+type inputOCISpec struct {
+	rs.BaseField `yaml:"-"`
+
+	// Ref is the image reference (e.g. `ghcr.io/org/bundle:v1`,
+	// `ghcr.io/org/bundle@sha256:...`)
+	Ref string `yaml:"ref"`
+
+	// Path of the file to fetch, matched against layer title annotation
+	// (`org.opencontainers.image.title`) first, then files in tar layers
+	Path string `yaml:"path"`
+
+	// MediaType of the layer to fetch when Path is not set
+	MediaType string `yaml:"media_type"`
+
+	Config Config `yaml:",inline"`
+}
+
+func (c *Config) createHTTPClient() (*http.Client, error) {
+	tlsConfig, err := c.TLS.GetTLSConfig(false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+			TLSClientConfig:       tlsConfig,
+		},
+	}, nil
+}
@@ -0,0 +1,152 @@
+package oci
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"arhat.dev/dukkha/pkg/compression"
+	"arhat.dev/dukkha/pkg/constant"
+)
+
+// fetchContent fetches file or layer content from image
+//
+// when filePath is set, it is matched against layer titles, then files in
+// tar layers (top layer first), when only mediaType is set, the first layer
+// (or config) with the media type is fetched, otherwise the image must have
+// exactly one layer
+func (c *registryClient) fetchContent(m *manifest, filePath, mediaType string) (io.ReadCloser, error) {
+	layers := m.Layers
+	if len(mediaType) != 0 {
+		layers = nil
+		for _, l := range m.Layers {
+			if l.MediaType == mediaType {
+				layers = append(layers, l)
+			}
+		}
+
+		if m.Config.MediaType == mediaType {
+			layers = append(layers, m.Config)
+		}
+
+		if len(layers) == 0 {
+			return nil, fmt.Errorf("no layer with media type %q", mediaType)
+		}
+	}
+
+	if len(filePath) == 0 {
+		if len(mediaType) == 0 && len(layers) != 1 {
+			return nil, fmt.Errorf(
+				"image has %d layers, please set path or media type", len(layers),
+			)
+		}
+
+		return c.fetchBlob(&layers[0])
+	}
+
+	filePath = cleanPath(filePath)
+	for i, l := range layers {
+		if title, ok := l.Annotations[annotationTitle]; ok && cleanPath(title) == filePath {
+			return c.fetchBlob(&layers[i])
+		}
+	}
+
+	for i := len(layers) - 1; i >= 0; i-- {
+		method, ok := tarLayerCompression(layers[i].MediaType)
+		if !ok {
+			continue
+		}
+
+		rc, err := c.extractFile(&layers[i], method, filePath)
+		if err == nil {
+			return rc, nil
+		}
+
+		if !errors.Is(err, errFileNotFound) {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("file %q not found in image", filePath)
+}
+
+var (
+	errFileNotFound = errors.New("file not found")
+	errFileDeleted  = errors.New("file deleted")
+)
+
+// extractFile finds file in tar layer
+func (c *registryClient) extractFile(layer *descriptor, method, filePath string) (io.ReadCloser, error) {
+	blob, err := c.fetchBlob(layer)
+	if err != nil {
+		return nil, err
+	}
+
+	var r io.Reader = blob
+	if len(method) != 0 {
+		var dr io.ReadCloser
+		dr, err = compression.NewReader(blob, method)
+		if err != nil {
+			_ = blob.Close()
+			return nil, fmt.Errorf("decompressing layer %q: %w", layer.Digest, err)
+		}
+
+		r = dr
+	}
+
+	whiteout := path.Join(path.Dir(filePath), ".wh."+path.Base(filePath))
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			_ = blob.Close()
+			if err == io.EOF {
+				return nil, errFileNotFound
+			}
+
+			return nil, fmt.Errorf("reading layer %q: %w", layer.Digest, err)
+		}
+
+		switch cleanPath(hdr.Name) {
+		case whiteout:
+			_ = blob.Close()
+			return nil, fmt.Errorf("%q: %w", filePath, errFileDeleted)
+		case filePath:
+		default:
+			continue
+		}
+
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			_ = blob.Close()
+			return nil, fmt.Errorf("%q is not a regular file", filePath)
+		}
+
+		return &struct {
+			io.Reader
+			io.Closer
+		}{Reader: tr, Closer: blob}, nil
+	}
+}
+
+// tarLayerCompression returns compression method of tar layer
+func tarLayerCompression(mediaType string) (method string, isTar bool) {
+	if !strings.Contains(mediaType, ".tar") {
+		return "", false
+	}
+
+	switch {
+	case strings.HasSuffix(mediaType, "+gzip"), strings.HasSuffix(mediaType, ".gzip"):
+		return constant.CompressionMethod_Gzip, true
+	case strings.HasSuffix(mediaType, "+zstd"):
+		return constant.CompressionMethod_ZSTD, true
+	default:
+		return "", true
+	}
+}
+
+func cleanPath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
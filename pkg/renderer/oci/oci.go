@@ -0,0 +1,203 @@
+package oci
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"arhat.dev/pkg/fshelper"
+	"arhat.dev/pkg/rshelper"
+	"arhat.dev/pkg/yamlhelper"
+	"arhat.dev/rs"
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/cache"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/renderer"
+)
+
+const (
+	DefaultName = "oci"
+)
+
+func init() { dukkha.RegisterRenderer(DefaultName, NewDefault) }
+
+func NewDefault(name string) dukkha.Renderer {
+	return &Driver{name: name}
+}
+
+var _ dukkha.Renderer = (*Driver)(nil)
+
+// Driver fetches files and layers from oci images and artifacts
+type Driver struct {
+	rs.BaseField `yaml:"-"`
+
+	renderer.BaseTwoTierCachedRenderer `yaml:",inline"`
+
+	name string
+
+	DefaultConfig Config `yaml:",inline"`
+
+	defaultClient *http.Client
+}
+
+// ConcurrencySafe implements dukkha.ConcurrentRenderer
+func (d *Driver) ConcurrencySafe() bool { return true }
+
+func (d *Driver) Init(cacheFS *fshelper.OSFS) error {
+	err := d.BaseTwoTierCachedRenderer.Init(cacheFS)
+	if err != nil {
+		return err
+	}
+
+	d.defaultClient, err = d.DefaultConfig.createHTTPClient()
+	return err
+}
+
+func (d *Driver) RenderYaml(
+	rc dukkha.RenderingContext, rawData interface{}, attributes []dukkha.RendererAttribute,
+) ([]byte, error) {
+	var (
+		spec   *inputOCISpec
+		client *http.Client
+	)
+
+	rawData, err := rs.NormalizeRawData(rawData)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := rawData.(type) {
+	case string:
+		spec = &inputOCISpec{Config: d.DefaultConfig}
+		spec.Ref, spec.Path = splitPath(t)
+		client = d.defaultClient
+	case []byte:
+		spec = &inputOCISpec{Config: d.DefaultConfig}
+		spec.Ref, spec.Path = splitPath(string(t))
+		client = d.defaultClient
+	default:
+		var rawBytes []byte
+		rawBytes, err = yamlhelper.ToYamlBytes(rawData)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"renderer.%s: unexpected non yaml input: %w",
+				d.name, err,
+			)
+		}
+
+		spec = rshelper.InitAll(&inputOCISpec{}, &rs.Options{
+			InterfaceTypeHandler: rc,
+		}).(*inputOCISpec)
+		err = yaml.Unmarshal(rawBytes, spec)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"renderer.%s: unmarshal input spec: %w",
+				d.name, err,
+			)
+		}
+
+		err = spec.ResolveFields(rc, -1)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"renderer.%s: resolving input spec: %w",
+				d.name, err,
+			)
+		}
+
+		// config resolved
+
+		client, err = spec.Config.createHTTPClient()
+		if err != nil {
+			return nil, fmt.Errorf(
+				"renderer.%s: creating http client for spec: %w",
+				d.name, err,
+			)
+		}
+	}
+
+	ref, err := parseReference(spec.Ref)
+	if err != nil {
+		return nil, fmt.Errorf("renderer.%s: %w", d.name, err)
+	}
+
+	os, arch, variant := spec.Config.Platform.resolve(rc)
+
+	// same content for the same reference, file and platform
+	cacheKey := ref.String() + "//" + cleanPath(spec.Path) +
+		"|" + spec.MediaType + "|" + os + "/" + arch + "/" + variant
+
+	data, err := renderer.HandleRenderingRequestWithRemoteFetch(
+		d.Cache,
+		cache.IdentifiableString(cacheKey),
+		func(_ cache.IdentifiableObject) (io.ReadCloser, error) {
+			rClient, err2 := d.newRegistryClient(rc, client, &spec.Config, ref)
+			if err2 != nil {
+				return nil, err2
+			}
+
+			m, err2 := rClient.resolveManifest(os, arch, variant)
+			if err2 != nil {
+				return nil, err2
+			}
+
+			ret, err2 := rClient.fetchContent(m, spec.Path, spec.MediaType)
+			if err2 != nil || rClient.cred == nil || len(rClient.authorization) == 0 {
+				return ret, err2
+			}
+
+			// fetched with credentials
+			return cache.PrivateContent(ret), nil
+		},
+		d.Attributes(attributes),
+		renderer.FetchOptions{Resource: ref.String() + "//" + cleanPath(spec.Path)},
+	)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"renderer.%s: fetching %q: %w",
+			d.name, ref.String(), err,
+		)
+	}
+
+	return data, nil
+}
+
+func (d *Driver) newRegistryClient(
+	rc dukkha.RenderingContext,
+	client *http.Client,
+	config *Config,
+	ref *reference,
+) (*registryClient, error) {
+	ret := &registryClient{
+		ctx:    rc,
+		http:   client,
+		ref:    ref,
+		scheme: "https",
+	}
+
+	if config.PlainHTTP {
+		ret.scheme = "http"
+	}
+
+	if len(config.Username) != 0 {
+		ret.cred = &credential{
+			Username: config.Username,
+			Password: config.Password,
+		}
+
+		return ret, nil
+	}
+
+	dockerConfig := config.DockerConfig
+	if len(dockerConfig) == 0 {
+		dockerConfig = defaultDockerConfigPath()
+	}
+
+	var err error
+	ret.cred, err = loadDockerCredential(rc, dockerConfig, rc.FS().ReadFile, ref.Registry)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,336 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"arhat.dev/rs"
+	"github.com/stretchr/testify/assert"
+
+	di "arhat.dev/dukkha/internal"
+	"arhat.dev/dukkha/pkg/dukkha"
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/renderer"
+)
+
+// testRegistry is an in-process registry stand-in serving a single
+// repository, requiring bearer token auth
+type testRegistry struct {
+	*httptest.Server
+
+	blobs     map[string][]byte
+	manifests map[string]*testManifest
+}
+
+type testManifest struct {
+	mediaType string
+	data      []byte
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	r := &testRegistry{
+		blobs:     make(map[string][]byte),
+		manifests: make(map[string]*testManifest),
+	}
+
+	const repoPrefix = "/v2/org/bundle/"
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			user, password, ok := req.BasicAuth()
+			if !ok || user != "foo" || password != "bar" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			assert.Equal(t, "repository:org/bundle:pull", req.URL.Query().Get("scope"))
+			assert.Equal(t, "test-registry", req.URL.Query().Get("service"))
+			_, _ = w.Write([]byte(`{"token":"test-token"}`))
+			return
+		}
+
+		if req.Header.Get("Authorization") != "Bearer test-token" {
+			w.Header().Set("WWW-Authenticate",
+				`Bearer realm="`+r.URL+`/token",service="test-registry",scope="repository:org/bundle:pull"`,
+			)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch p := strings.TrimPrefix(req.URL.Path, repoPrefix); {
+		case strings.HasPrefix(p, "manifests/"):
+			m, ok := r.manifests[strings.TrimPrefix(p, "manifests/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Content-Type", m.mediaType)
+			_, _ = w.Write(m.data)
+		case strings.HasPrefix(p, "blobs/"):
+			data, ok := r.blobs[strings.TrimPrefix(p, "blobs/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			_, _ = w.Write(data)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return r
+}
+
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (r *testRegistry) addBlob(mediaType string, data []byte, annotations map[string]string) descriptor {
+	d := descriptor{
+		MediaType:   mediaType,
+		Digest:      digestOf(data),
+		Size:        int64(len(data)),
+		Annotations: annotations,
+	}
+
+	r.blobs[d.Digest] = data
+	return d
+}
+
+func (r *testRegistry) addManifest(t *testing.T, tag, mediaType string, v interface{}) descriptor {
+	data, err := json.Marshal(v)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	d := descriptor{MediaType: mediaType, Digest: digestOf(data), Size: int64(len(data))}
+	r.manifests[d.Digest] = &testManifest{mediaType: mediaType, data: data}
+	if len(tag) != 0 {
+		r.manifests[tag] = r.manifests[d.Digest]
+	}
+
+	return d
+}
+
+func createTar(t *testing.T, compress bool, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+
+	var (
+		gw *gzip.Writer
+		tw *tar.Writer
+	)
+	if compress {
+		gw = gzip.NewWriter(buf)
+		tw = tar.NewWriter(gw)
+	} else {
+		tw = tar.NewWriter(buf)
+	}
+
+	for name, content := range files {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+
+		_, err := tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, tw.Close())
+	if gw != nil {
+		assert.NoError(t, gw.Close())
+	}
+
+	return buf.Bytes()
+}
+
+func TestDriver_RenderYaml(t *testing.T) {
+	reg := newTestRegistry(t)
+	defer reg.Close()
+
+	base := reg.addBlob("application/vnd.oci.image.layer.v1.tar+gzip", createTar(t, true, map[string]string{
+		"etc/app.yaml": "name: base\n",
+		"etc/old.txt":  "old",
+	}), nil)
+	top := reg.addBlob("application/vnd.oci.image.layer.v1.tar", createTar(t, false, map[string]string{
+		"./etc/app.yaml":   "name: override\n",
+		"etc/.wh.old.txt":  "",
+		"etc/unchanged.md": "unchanged",
+	}), nil)
+	bundle := reg.addBlob("application/vnd.dukkha.bundle.v1+yaml", []byte("bundle: true\n"), map[string]string{
+		annotationTitle: "config/bundle.yaml",
+	})
+	config := reg.addBlob("application/vnd.oci.image.config.v1+json", []byte("{}"), nil)
+
+	amd64 := reg.addManifest(t, "", mediaTypeOCIManifest, &manifest{
+		MediaType: mediaTypeOCIManifest,
+		Config:    config,
+		Layers:    []descriptor{base, top, bundle},
+	})
+	amd64.Platform = &platform{OS: "linux", Architecture: "amd64"}
+
+	arm64 := reg.addManifest(t, "", mediaTypeDockerManifest, &manifest{
+		MediaType: mediaTypeDockerManifest,
+		Config:    config,
+		Layers: []descriptor{
+			reg.addBlob("application/octet-stream", []byte("arm64 toolchain"), nil),
+		},
+	})
+	arm64.Platform = &platform{OS: "linux", Architecture: "arm64"}
+
+	index := reg.addManifest(t, "v1", mediaTypeOCIIndex, &manifest{
+		MediaType: mediaTypeOCIIndex,
+		Manifests: []descriptor{amd64, arm64},
+	})
+
+	// registry returning content not matching the digest
+	reg.manifests[digestOf([]byte("x"))] = reg.manifests["v1"]
+
+	host := strings.TrimPrefix(reg.URL, "http://")
+	image := host + "/org/bundle:v1"
+
+	tmpDir := t.TempDir()
+	dockerConfig := filepath.Join(tmpDir, "config.json")
+	assert.NoError(t, os.WriteFile(dockerConfig, []byte(`{"auths":{"http://`+host+`":{"auth":"`+
+		base64.StdEncoding.EncodeToString([]byte("foo:bar"))+`"}}}`), 0600))
+
+	newDriver := func(t *testing.T, arch string) (*Driver, dukkha.Context) {
+		d := NewDefault("oci").(*Driver)
+		d.DefaultConfig = Config{
+			DockerConfig: dockerConfig,
+			PlainHTTP:    true,
+			Platform:     platformSpec{Kernel: "linux", Arch: arch},
+		}
+
+		rc := dt.NewTestContext(context.TODO())
+		rc.(di.CacheDirSetter).SetCacheDir(t.TempDir())
+		assert.NoError(t, d.Init(rc.RendererCacheFS("oci")))
+
+		return d, rc
+	}
+
+	for _, test := range []struct {
+		name     string
+		arch     string
+		input    interface{}
+		expected string
+		err      bool
+	}{
+		{name: "File In Top Layer", arch: "amd64", input: image + "//etc/app.yaml", expected: "name: override\n"},
+		{name: "File In Base Layer", arch: "amd64", input: image + "//etc/unchanged.md", expected: "unchanged"},
+		{name: "Layer Title", arch: "amd64", input: image + "//config/bundle.yaml", expected: "bundle: true\n"},
+		{name: "Deleted File", arch: "amd64", input: image + "//etc/old.txt", err: true},
+		{name: "Missing File", arch: "amd64", input: image + "//etc/none", err: true},
+		{name: "Multiple Layers", arch: "amd64", input: image, err: true},
+		{name: "Platform Selection", arch: "arm64", input: image, expected: "arm64 toolchain"},
+		{name: "Unknown Platform", arch: "s390x", input: image, err: true},
+		{name: "Digest Pinning", arch: "amd64", input: host + "/org/bundle@" + index.Digest + "//etc/app.yaml", expected: "name: override\n"},
+		{name: "Digest Mismatch", arch: "amd64", input: host + "/org/bundle@" + digestOf([]byte("x")) + "//etc/app.yaml", err: true},
+		{
+			name: "Media Type",
+			arch: "amd64",
+			input: rs.Init(&inputOCISpec{
+				Ref:       image,
+				MediaType: "application/vnd.dukkha.bundle.v1+yaml",
+				Config: Config{
+					Username:  "foo",
+					Password:  "bar",
+					PlainHTTP: true,
+					Platform:  platformSpec{Kernel: "linux", Arch: "amd64"},
+				},
+			}, nil),
+			expected: "bundle: true\n",
+		},
+		{
+			name: "Wrong Credential",
+			arch: "amd64",
+			input: rs.Init(&inputOCISpec{
+				Ref:  image,
+				Path: "etc/app.yaml",
+				Config: Config{
+					Username:  "foo",
+					Password:  "wrong",
+					PlainHTTP: true,
+				},
+			}, nil),
+			err: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			d, rc := newDriver(t, test.arch)
+
+			ret, err := d.RenderYaml(rc, test.input, nil)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, string(ret))
+		})
+	}
+
+	t.Run("Cached File", func(t *testing.T) {
+		d, rc := newDriver(t, "amd64")
+
+		path, err := d.RenderYaml(rc, image+"//etc/app.yaml", []dukkha.RendererAttribute{renderer.AttrCachedFile})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		data, err := os.ReadFile(string(path))
+		assert.NoError(t, err)
+		assert.Equal(t, "name: override\n", string(data))
+	})
+}
+
+func TestParseReference(t *testing.T) {
+	for _, test := range []struct {
+		ref      string
+		expected string
+		host     string
+	}{
+		{"alpine", "docker.io/library/alpine:latest", "registry-1.docker.io"},
+		{"org/app:v1", "docker.io/org/app:v1", "registry-1.docker.io"},
+		{"ghcr.io/org/app", "ghcr.io/org/app:latest", "ghcr.io"},
+		{"localhost:5000/app:v1", "localhost:5000/app:v1", "localhost:5000"},
+		{
+			"ghcr.io/org/app:v1@sha256:" + strings.Repeat("a", 64),
+			"ghcr.io/org/app:v1@sha256:" + strings.Repeat("a", 64),
+			"ghcr.io",
+		},
+	} {
+		ref, err := parseReference(test.ref)
+		if !assert.NoError(t, err, test.ref) {
+			continue
+		}
+
+		assert.Equal(t, test.expected, ref.String())
+		assert.Equal(t, test.host, ref.Host())
+	}
+
+	for _, invalid := range []string{"", "ghcr.io/Org/app", "app@sha256:abc", "app@md5:" + strings.Repeat("a", 32)} {
+		_, err := parseReference(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	ref, path := splitPath("ghcr.io/org/app:v1//etc/app.yaml")
+	assert.Equal(t, "ghcr.io/org/app:v1", ref)
+	assert.Equal(t, "etc/app.yaml", path)
+}
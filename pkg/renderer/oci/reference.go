@@ -0,0 +1,115 @@
+package oci
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	dockerHubRegistry = "docker.io"
+	dockerHubHost     = "registry-1.docker.io"
+
+	// pathSeparator separates image reference and file path in string input
+	pathSeparator = "//"
+)
+
+// reference of an image or artifact in registry
+type reference struct {
+	// Registry is the registry name in reference (e.g. `docker.io`, `ghcr.io`)
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// parseReference parses image reference in format
+// `[registry/]repo[:tag][@digest]`
+func parseReference(ref string) (*reference, error) {
+	ref = strings.TrimSpace(ref)
+	if len(ref) == 0 {
+		return nil, fmt.Errorf("empty image reference")
+	}
+
+	ret := &reference{}
+	if i := strings.IndexByte(ref, '@'); i != -1 {
+		ref, ret.Digest = ref[:i], ref[i+1:]
+		if !strings.HasPrefix(ret.Digest, "sha256:") || len(ret.Digest) != len("sha256:")+64 {
+			return nil, fmt.Errorf("invalid digest %q, only sha256 digest supported", ret.Digest)
+		}
+	}
+
+	// tag is after the last colon not followed by `/` (registry port)
+	if i := strings.LastIndexByte(ref, ':'); i != -1 && !strings.Contains(ref[i:], "/") {
+		ref, ret.Tag = ref[:i], ref[i+1:]
+	}
+
+	name := ref
+	if i := strings.IndexByte(ref, '/'); i != -1 {
+		first := ref[:i]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			ret.Registry, name = first, ref[i+1:]
+		}
+	}
+
+	if len(ret.Registry) == 0 {
+		ret.Registry = dockerHubRegistry
+	}
+
+	if ret.Registry == dockerHubRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+
+	if len(name) == 0 || name != strings.ToLower(name) {
+		return nil, fmt.Errorf("invalid repository name %q", name)
+	}
+	ret.Repository = name
+
+	if len(ret.Tag) == 0 && len(ret.Digest) == 0 {
+		ret.Tag = "latest"
+	}
+
+	return ret, nil
+}
+
+// Host returns the host to access registry api
+func (r *reference) Host() string {
+	if r.Registry == dockerHubRegistry {
+		return dockerHubHost
+	}
+
+	return r.Registry
+}
+
+// ManifestRef returns digest if pinned, otherwise the tag
+func (r *reference) ManifestRef() string {
+	if len(r.Digest) != 0 {
+		return r.Digest
+	}
+
+	return r.Tag
+}
+
+func (r *reference) String() string {
+	ret := r.Registry + "/" + r.Repository
+	if len(r.Tag) != 0 {
+		ret += ":" + r.Tag
+	}
+
+	if len(r.Digest) != 0 {
+		ret += "@" + r.Digest
+	}
+
+	return ret
+}
+
+// splitPath splits string input `<ref>//<path>` into ref and path
+func splitPath(input string) (ref, path string) {
+	input = strings.TrimSpace(input)
+
+	i := strings.Index(input, pathSeparator)
+	if i == -1 {
+		return input, ""
+	}
+
+	return input[:i], input[i+len(pathSeparator):]
+}
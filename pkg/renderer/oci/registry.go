@@ -0,0 +1,421 @@
+package oci
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+
+	// annotationTitle is the file name of layer (used by oras and alike)
+	annotationTitle = "org.opencontainers.image.title"
+
+	maxManifestSize = 4 << 20
+)
+
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *platform         `json:"platform,omitempty"`
+}
+
+type platform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+func (p *platform) String() string {
+	ret := p.OS + "/" + p.Architecture
+	if len(p.Variant) != 0 {
+		ret += "/" + p.Variant
+	}
+
+	return ret
+}
+
+// manifest is either image manifest or image index (manifest list)
+type manifest struct {
+	MediaType string `json:"mediaType"`
+
+	// image manifest
+	Config descriptor   `json:"config"`
+	Layers []descriptor `json:"layers"`
+
+	// image index
+	Manifests []descriptor `json:"manifests"`
+}
+
+func (m *manifest) isIndex() bool {
+	switch m.MediaType {
+	case mediaTypeOCIIndex, mediaTypeDockerManifestList:
+		return true
+	case "":
+		// media type is optional in oci spec
+		return len(m.Manifests) != 0
+	default:
+		return false
+	}
+}
+
+// registryClient accesses a single repository using the registry http api
+type registryClient struct {
+	ctx  context.Context
+	http *http.Client
+	ref  *reference
+
+	scheme string
+	cred   *credential
+
+	// authorization header value after auth challenge
+	authorization string
+}
+
+// do sends request to `/v2/<repo>/<subPath>`, handling auth challenge,
+// response with non 2xx status code is converted to error
+func (c *registryClient) do(subPath string, accept ...string) (*http.Response, error) {
+	reqURL := c.scheme + "://" + c.ref.Host() + "/v2/" + c.ref.Repository + "/" + subPath
+
+	for retried := false; ; retried = true {
+		req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, reqURL, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, a := range accept {
+			req.Header.Add("Accept", a)
+		}
+
+		if len(c.authorization) != 0 {
+			req.Header.Set("Authorization", c.authorization)
+		}
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && !retried {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			_ = resp.Body.Close()
+
+			err = c.authorize(challenge)
+			if err != nil {
+				return nil, fmt.Errorf("authorizing registry access: %w", err)
+			}
+
+			continue
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			defer func() { _ = resp.Body.Close() }()
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+			return nil, fmt.Errorf("unexpected response status %q for %q: %s",
+				resp.Status, subPath, strings.TrimSpace(string(msg)),
+			)
+		}
+
+		return resp, nil
+	}
+}
+
+// authorize handles auth challenge in WWW-Authenticate header
+func (c *registryClient) authorize(challenge string) error {
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if c.cred == nil || len(c.cred.Username) == 0 {
+			return fmt.Errorf("no credential for registry %q", c.ref.Registry)
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(c.cred.Username, c.cred.Password)
+		c.authorization = req.Header.Get("Authorization")
+
+		return nil
+	case "bearer":
+		token, err := c.fetchToken(params["realm"], params["service"],
+			"repository:"+c.ref.Repository+":pull",
+		)
+		if err != nil {
+			return err
+		}
+
+		c.authorization = "Bearer " + token
+		return nil
+	default:
+		return fmt.Errorf("unsupported auth challenge %q", challenge)
+	}
+}
+
+// fetchToken requests bearer token from token server, anonymous access is
+// used when there is no credential
+func (c *registryClient) fetchToken(realm, service, scope string) (string, error) {
+	if len(realm) == 0 {
+		return "", fmt.Errorf("no realm in bearer auth challenge")
+	}
+
+	var (
+		req *http.Request
+		err error
+	)
+
+	if c.cred != nil && len(c.cred.IdentityToken) != 0 {
+		form := url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {c.cred.IdentityToken},
+			"service":       {service},
+			"scope":         {scope},
+			"client_id":     {"dukkha"},
+		}
+
+		req, err = http.NewRequestWithContext(c.ctx, http.MethodPost, realm, strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", err
+		}
+
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		var tokenURL *url.URL
+		tokenURL, err = url.Parse(realm)
+		if err != nil {
+			return "", fmt.Errorf("invalid token realm %q: %w", realm, err)
+		}
+
+		query := tokenURL.Query()
+		if len(service) != 0 {
+			query.Set("service", service)
+		}
+		query.Set("scope", scope)
+		tokenURL.RawQuery = query.Encode()
+
+		req, err = http.NewRequestWithContext(c.ctx, http.MethodGet, tokenURL.String(), nil)
+		if err != nil {
+			return "", err
+		}
+
+		if c.cred != nil && len(c.cred.Username) != 0 {
+			req.SetBasicAuth(c.cred.Username, c.cred.Password)
+		}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("requesting token: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("requesting token: unexpected response status %q", resp.Status)
+	}
+
+	result := &struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	err = json.NewDecoder(resp.Body).Decode(result)
+	if err != nil {
+		return "", fmt.Errorf("decoding token response: %w", err)
+	}
+
+	if len(result.Token) != 0 {
+		return result.Token, nil
+	}
+
+	if len(result.AccessToken) != 0 {
+		return result.AccessToken, nil
+	}
+
+	return "", fmt.Errorf("no token in token response")
+}
+
+// parseChallenge parses WWW-Authenticate header value
+// (e.g. `Bearer realm="https://auth.docker.io/token",service="registry.docker.io"`)
+func parseChallenge(challenge string) (scheme string, params map[string]string) {
+	params = make(map[string]string)
+
+	challenge = strings.TrimSpace(challenge)
+	i := strings.IndexByte(challenge, ' ')
+	if i == -1 {
+		return challenge, params
+	}
+
+	scheme, rest := challenge[:i], challenge[i+1:]
+	for len(rest) != 0 {
+		rest = strings.TrimLeft(rest, " ,")
+		eq := strings.IndexByte(rest, '=')
+		if eq == -1 {
+			break
+		}
+
+		key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = rest[eq+1:]
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end == -1 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.IndexByte(rest, ',')
+			if end == -1 {
+				value, rest = rest, ""
+			} else {
+				value, rest = rest[:end], rest[end:]
+			}
+		}
+
+		params[key] = strings.TrimSpace(value)
+	}
+
+	return scheme, params
+}
+
+// fetchManifest fetches manifest by tag or digest, the content is verified
+// when digest is not empty
+func (c *registryClient) fetchManifest(ref, digest string) (*manifest, error) {
+	resp, err := c.do("manifests/"+ref,
+		mediaTypeOCIIndex, mediaTypeOCIManifest,
+		mediaTypeDockerManifestList, mediaTypeDockerManifest,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize))
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+
+	if len(digest) != 0 {
+		sum := sha256.Sum256(data)
+		if actual := "sha256:" + hex.EncodeToString(sum[:]); actual != digest {
+			return nil, fmt.Errorf("manifest digest mismatch: expecting %q, got %q", digest, actual)
+		}
+	}
+
+	m := &manifest{}
+	err = json.Unmarshal(data, m)
+	if err != nil {
+		return nil, fmt.Errorf("decoding manifest: %w", err)
+	}
+
+	if len(m.MediaType) == 0 {
+		m.MediaType = resp.Header.Get("Content-Type")
+	}
+
+	return m, nil
+}
+
+// resolveManifest fetches image manifest, for image index, the manifest
+// of the platform is selected
+func (c *registryClient) resolveManifest(os, arch, variant string) (*manifest, error) {
+	m, err := c.fetchManifest(c.ref.ManifestRef(), c.ref.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	if !m.isIndex() {
+		return m, nil
+	}
+
+	d, err := selectPlatform(m.Manifests, os, arch, variant)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.fetchManifest(d.Digest, d.Digest)
+}
+
+// selectPlatform selects manifest matching os and arch, manifests with
+// matching variant are preferred
+func selectPlatform(manifests []descriptor, os, arch, variant string) (*descriptor, error) {
+	var (
+		candidate *descriptor
+		available []string
+	)
+
+	for i, d := range manifests {
+		if d.Platform == nil {
+			continue
+		}
+
+		available = append(available, d.Platform.String())
+		if d.Platform.OS != os || d.Platform.Architecture != arch {
+			continue
+		}
+
+		switch {
+		case d.Platform.Variant == variant:
+			return &manifests[i], nil
+		case candidate == nil, len(d.Platform.Variant) == 0:
+			candidate = &manifests[i]
+		}
+	}
+
+	if candidate != nil {
+		return candidate, nil
+	}
+
+	return nil, fmt.Errorf("no manifest for platform %s/%s, available platforms: %s",
+		os, arch, strings.Join(available, ", "),
+	)
+}
+
+// fetchBlob fetches blob content, the content is verified when read to EOF
+func (c *registryClient) fetchBlob(d *descriptor) (io.ReadCloser, error) {
+	if !strings.HasPrefix(d.Digest, "sha256:") {
+		return nil, fmt.Errorf("unsupported digest %q", d.Digest)
+	}
+
+	resp, err := c.do("blobs/" + d.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &verifyingReader{
+		ReadCloser: resp.Body,
+		h:          sha256.New(),
+		digest:     d.Digest,
+	}, nil
+}
+
+// verifyingReader checks sha256 digest of all data read when reaching EOF
+type verifyingReader struct {
+	io.ReadCloser
+
+	h      hash.Hash
+	digest string
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	_, _ = r.h.Write(p[:n])
+
+	if err == io.EOF {
+		if actual := "sha256:" + hex.EncodeToString(r.h.Sum(nil)); actual != r.digest {
+			return n, fmt.Errorf("blob digest mismatch: expecting %q, got %q", r.digest, actual)
+		}
+	}
+
+	return n, err
+}
@@ -1,6 +1,9 @@
 package renderer
 
 import (
+	"fmt"
+	"path/filepath"
+
 	"arhat.dev/pkg/fshelper"
 	"arhat.dev/rs"
 
@@ -32,6 +35,12 @@ func (r *BaseRenderer) Attributes(override []dukkha.RendererAttribute) []dukkha.
 	return override
 }
 
+// ConcurrencySafe implements dukkha.ConcurrentRenderer, renderers are not
+// used concurrently unless they override it
+func (r *BaseRenderer) ConcurrencySafe() bool {
+	return false
+}
+
 type BaseInMemCachedRenderer struct {
 	rs.BaseField `yaml:"-"`
 
@@ -82,9 +91,34 @@ func (d *BaseTwoTierCachedRenderer) Init(cacheFS *fshelper.OSFS) error {
 			int64(d.CacheConfig.Size),
 			int64(d.CacheConfig.Timeout.Seconds()),
 		)
+
+		d.Cache.LimitLocalCache(d.CacheConfig.diskLimits())
+
+		if remoteCache != nil && !IsOffline() {
+			dir, err := cacheFS.Abs(".")
+			if err != nil {
+				return fmt.Errorf("get renderer cache dir: %w", err)
+			}
+
+			// renderer cache dir is `renderer/<name>`
+			d.Cache.SetRemote(remoteCache, "renderer/"+filepath.Base(dir), remoteCacheReadOnly)
+		}
 	} else {
 		d.Cache = cache.NewTwoTierCache(cacheFS, 0, 0, -1)
 	}
 
 	return nil
 }
+
+var (
+	remoteCache         cache.RemoteStore
+	remoteCacheReadOnly bool
+)
+
+// SetRemoteCache sets the remote tier shared by two tier cache of all
+// renderers with caching enabled, MUST be called before renderers are
+// initialized
+func SetRemoteCache(store cache.RemoteStore, readOnly bool) {
+	remoteCache = store
+	remoteCacheReadOnly = readOnly
+}
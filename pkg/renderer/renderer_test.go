@@ -0,0 +1,84 @@
+package renderer
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"arhat.dev/pkg/fshelper"
+	"github.com/stretchr/testify/assert"
+
+	"arhat.dev/dukkha/pkg/cache"
+)
+
+func TestBaseTwoTierCachedRenderer_Init_DiskLimits(t *testing.T) {
+	fetch := cache.RemoteCacheRefreshFunc(func(_ cache.IdentifiableObject) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("test-data")), nil
+	})
+
+	newRenderer := func(t *testing.T, config CacheConfig) (*BaseTwoTierCachedRenderer, string) {
+		cacheDir := t.TempDir()
+		config.Enabled = true
+		config.Timeout = 100 * time.Second
+
+		d := &BaseTwoTierCachedRenderer{CacheConfig: config}
+		assert.NoError(t, d.Init(fshelper.NewOSFS(false, func() (string, error) {
+			return cacheDir, nil
+		})))
+
+		return d, cacheDir
+	}
+
+	countFiles := func(t *testing.T, dir string) int {
+		entries, err := os.ReadDir(dir)
+		assert.NoError(t, err)
+		return len(entries)
+	}
+
+	t.Run("No Limits", func(t *testing.T) {
+		d, dir := newRenderer(t, CacheConfig{})
+
+		for _, obj := range []string{"foo", "bar"} {
+			_, _, err := d.Cache.GetPath(cache.IdentifiableString(obj), 1111111111, true, fetch)
+			assert.NoError(t, err)
+		}
+
+		assert.Equal(t, 2, countFiles(t, dir))
+	})
+
+	t.Run("Memory Limits Applied", func(t *testing.T) {
+		d, dir := newRenderer(t, CacheConfig{MaxItemSize: 5})
+
+		for _, obj := range []string{"foo", "bar"} {
+			_, _, err := d.Cache.GetPath(cache.IdentifiableString(obj), 1111111111, true, fetch)
+			assert.NoError(t, err)
+		}
+
+		assert.Equal(t, 1, countFiles(t, dir))
+	})
+
+	t.Run("Disk Limits Override", func(t *testing.T) {
+		d, dir := newRenderer(t, CacheConfig{MaxItemSize: 5, MaxDiskItemSize: 100})
+
+		for _, obj := range []string{"foo", "bar"} {
+			_, _, err := d.Cache.GetPath(cache.IdentifiableString(obj), 1111111111, true, fetch)
+			assert.NoError(t, err)
+		}
+
+		assert.Equal(t, 2, countFiles(t, dir))
+	})
+
+	t.Run("Disk Limits", func(t *testing.T) {
+		d, dir := newRenderer(t, CacheConfig{MaxDiskItemSize: 5})
+
+		for _, obj := range []string{"foo", "bar"} {
+			_, _, err := d.Cache.GetPath(cache.IdentifiableString(obj), 1111111111, true, fetch)
+			assert.NoError(t, err)
+		}
+
+		assert.Equal(t, 1, countFiles(t, dir))
+	})
+}
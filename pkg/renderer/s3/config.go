@@ -17,10 +17,11 @@ type inputS3Sepc struct {
 
 	Path string `yaml:"path"`
 
-	Config rendererS3Config `yaml:"config"`
+	Config Config `yaml:"config"`
 }
 
-type rendererS3Config struct {
+// Config of s3 service, shared by s3 renderer and s3 tool
+type Config struct {
 	rs.BaseField `yaml:"-"`
 
 	EndpointURL string `yaml:"endpoint_url"`
@@ -33,15 +34,24 @@ type rendererS3Config struct {
 	AccessKeySecret string `yaml:"access_key_secret"`
 }
 
-type s3Client struct {
+// Client operates objects in the bucket under base path
+type Client struct {
 	client *minio.Client
 
 	bucket   string
 	region   string
 	basePath string
+
+	// withCredentials is true when access key is set
+	withCredentials bool
+}
+
+// objectURL is the human readable name of the object at objPath
+func (c *Client) objectURL(objPath string) string {
+	return "s3://" + path.Join(c.bucket, c.basePath, objPath)
 }
 
-func (c *s3Client) download(ctx context.Context, objPath string) (io.ReadCloser, error) {
+func (c *Client) download(ctx context.Context, objPath string) (io.ReadCloser, error) {
 	obj, err := c.client.GetObject(
 		ctx,
 		c.bucket,
@@ -55,7 +65,58 @@ func (c *s3Client) download(ctx context.Context, objPath string) (io.ReadCloser,
 	return obj, err
 }
 
-func (c *rendererS3Config) createClient() (*s3Client, error) {
+// UploadOptions are options for object uploading
+type UploadOptions struct {
+	// ContentType of the object
+	ContentType string
+
+	// Metadata is the user metadata of the object
+	Metadata map[string]string
+
+	// DisableMultipart disables multipart uploading
+	DisableMultipart bool
+
+	// PartSize of multipart uploading, zero value means default size (16MiB)
+	PartSize uint64
+
+	// Concurrency of multipart uploading, zero value means default concurrency (4)
+	Concurrency uint
+}
+
+// Upload object at objPath (relative to base path) with content read from r
+//
+// when r implements io.ReaderAt (e.g. *os.File) and size is greater than
+// part size, parts are uploaded in parallel
+func (c *Client) Upload(
+	ctx context.Context,
+	objPath string,
+	r io.Reader,
+	size int64,
+	opts *UploadOptions,
+) error {
+	_, err := c.client.PutObject(
+		ctx,
+		c.bucket,
+		path.Join(c.basePath, objPath),
+		r,
+		size,
+		minio.PutObjectOptions{
+			UserMetadata:     opts.Metadata,
+			ContentType:      opts.ContentType,
+			DisableMultipart: opts.DisableMultipart,
+			PartSize:         opts.PartSize,
+			NumThreads:       opts.Concurrency,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("upload object %q: %w", objPath, err)
+	}
+
+	return nil
+}
+
+// CreateClient creates a s3 client using this config
+func (c *Config) CreateClient() (*Client, error) {
 	eURL, err := url.Parse(c.EndpointURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid endpoint url: %w", err)
@@ -70,11 +131,13 @@ func (c *rendererS3Config) createClient() (*s3Client, error) {
 		return nil, fmt.Errorf("creating s3 client: %w", err)
 	}
 
-	return &s3Client{
+	return &Client{
 		client: client,
 
 		bucket:   c.Bucket,
 		region:   c.Region,
 		basePath: c.BasePath,
+
+		withCredentials: len(c.AccessKeyID)+len(c.AccessKeySecret) != 0,
 	}, nil
 }
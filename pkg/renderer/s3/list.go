@@ -0,0 +1,79 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"gopkg.in/yaml.v3"
+)
+
+// AttrList makes s3 renderer list objects under the prefix instead of downloading the object
+const AttrList = "list"
+
+// objectInfo is the listing entry of an object
+type objectInfo struct {
+	// Key of the object, relative to base path
+	Key string `yaml:"key"`
+
+	Size         int64  `yaml:"size"`
+	ETag         string `yaml:"etag"`
+	LastModified string `yaml:"last_modified"`
+}
+
+// list objects under prefix recursively
+//
+// prefix is relative to base path, when prefix is empty or ends with `/`,
+// it is treated as a directory
+func (c *Client) list(ctx context.Context, prefix string) ([]*objectInfo, error) {
+	basePath := strings.Trim(path.Clean("/"+c.basePath), "/")
+
+	fullPrefix := strings.TrimPrefix(path.Join(basePath, prefix), "/")
+	if len(fullPrefix) != 0 && (len(prefix) == 0 || strings.HasSuffix(prefix, "/")) {
+		fullPrefix += "/"
+	}
+
+	ret := make([]*objectInfo, 0)
+	for obj := range c.client.ListObjects(ctx, c.bucket, minio.ListObjectsOptions{
+		Prefix:    fullPrefix,
+		Recursive: true,
+	}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("list objects with prefix %q: %w", fullPrefix, obj.Err)
+		}
+
+		key := obj.Key
+		if len(basePath) != 0 {
+			key = strings.TrimPrefix(strings.TrimPrefix(key, basePath), "/")
+		}
+
+		ret = append(ret, &objectInfo{
+			Key:          key,
+			Size:         obj.Size,
+			ETag:         obj.ETag,
+			LastModified: obj.LastModified.UTC().Format(time.RFC3339),
+		})
+	}
+
+	return ret, nil
+}
+
+// listYaml lists objects under prefix as yaml array
+func (c *Client) listYaml(ctx context.Context, prefix string) (io.ReadCloser, error) {
+	objects, err := c.list(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := yaml.Marshal(objects)
+	if err != nil {
+		return nil, fmt.Errorf("marshal object list: %w", err)
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
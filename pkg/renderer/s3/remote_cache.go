@@ -0,0 +1,51 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+
+	"arhat.dev/dukkha/pkg/cache"
+)
+
+// NewRemoteCacheStore creates a cache.RemoteStore storing objects
+// under base path of the client
+func NewRemoteCacheStore(client *Client) cache.RemoteStore {
+	return &remoteCacheStore{client: client}
+}
+
+type remoteCacheStore struct {
+	client *Client
+}
+
+func (s *remoteCacheStore) Get(key string) (io.ReadCloser, time.Time, error) {
+	r, err := s.client.download(context.TODO(), key)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	// object is not requested until the first call to it
+	info, err := r.(*minio.Object).Stat()
+	if err != nil {
+		_ = r.Close()
+
+		if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+			return nil, time.Time{}, fmt.Errorf("remote cache %q: %w", key, fs.ErrNotExist)
+		}
+
+		return nil, time.Time{}, fmt.Errorf("remote cache %q: %w", key, err)
+	}
+
+	return r, info.LastModified, nil
+}
+
+func (s *remoteCacheStore) Put(key string, r io.Reader, size int64) error {
+	return s.client.Upload(context.TODO(), key, r, size, &UploadOptions{
+		ContentType: "application/octet-stream",
+	})
+}
@@ -0,0 +1,50 @@
+package s3
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	st "arhat.dev/dukkha/pkg/renderer/s3/test"
+)
+
+func TestRemoteCacheStore(t *testing.T) {
+	srv := st.NewServer(t, "test")
+
+	client, err := (&Config{
+		EndpointURL: srv.URL,
+		Region:      "us-east-1",
+		Bucket:      "test",
+		BasePath:    "cache",
+	}).CreateClient()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	store := NewRemoteCacheStore(client)
+
+	_, _, err = store.Get("renderer/http/foo.yaml")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	assert.NoError(t, store.Put("renderer/http/foo.yaml", bytes.NewReader([]byte("foo")), 3))
+
+	obj, ok := srv.Get("cache/renderer/http/foo.yaml")
+	if assert.True(t, ok) {
+		assert.Equal(t, "foo", string(obj.Data))
+	}
+
+	r, storedAt, err := store.Get("renderer/http/foo.yaml")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer func() { _ = r.Close() }()
+
+	assert.Equal(t, obj.LastModified.Unix(), storedAt.Unix())
+
+	data, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(data))
+}
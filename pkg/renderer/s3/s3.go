@@ -36,18 +36,21 @@ type Driver struct {
 
 	name string
 
-	DefaultConfig rendererS3Config `yaml:",inline"`
+	DefaultConfig Config `yaml:",inline"`
 
-	defaultClient *s3Client
+	defaultClient *Client
 }
 
+// ConcurrencySafe implements dukkha.ConcurrentRenderer
+func (d *Driver) ConcurrencySafe() bool { return true }
+
 func (d *Driver) Init(cacheFS *fshelper.OSFS) error {
 	err := d.BaseTwoTierCachedRenderer.Init(cacheFS)
 	if err != nil {
 		return err
 	}
 
-	d.defaultClient, err = d.DefaultConfig.createClient()
+	d.defaultClient, err = d.DefaultConfig.CreateClient()
 	return err
 }
 
@@ -56,7 +59,7 @@ func (d *Driver) RenderYaml(
 ) ([]byte, error) {
 	var (
 		path   string
-		client *s3Client
+		client *Client
 	)
 
 	rawData, err := rs.NormalizeRawData(rawData)
@@ -103,7 +106,7 @@ func (d *Driver) RenderYaml(
 		// config resolved
 
 		path = spec.Path
-		client, err = spec.Config.createClient()
+		client, err = spec.Config.CreateClient()
 		if err != nil {
 			return nil, fmt.Errorf(
 				"renderer.%s: creating s3 client for spec: %w",
@@ -112,13 +115,36 @@ func (d *Driver) RenderYaml(
 		}
 	}
 
+	var (
+		cacheKey = path
+		fetch    = func(key cache.IdentifiableObject) (io.ReadCloser, error) {
+			return client.download(rc, key.ScopeUniqueID())
+		}
+	)
+
+	for _, attr := range d.Attributes(attributes) {
+		if attr == AttrList {
+			cacheKey = "list:" + path
+			fetch = func(key cache.IdentifiableObject) (io.ReadCloser, error) {
+				return client.listYaml(rc, path)
+			}
+		}
+	}
+
 	data, err := renderer.HandleRenderingRequestWithRemoteFetch(
 		d.Cache,
-		cache.IdentifiableString(path),
+		cache.IdentifiableString(cacheKey),
 		func(key cache.IdentifiableObject) (io.ReadCloser, error) {
-			return client.download(rc, key.ScopeUniqueID())
+			ret, err2 := fetch(key)
+			if err2 != nil || !client.withCredentials {
+				return ret, err2
+			}
+
+			// fetched with credentials
+			return cache.PrivateContent(ret), nil
 		},
 		d.Attributes(attributes),
+		renderer.FetchOptions{Resource: client.objectURL(path)},
 	)
 
 	if err != nil {
@@ -0,0 +1,167 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"arhat.dev/rs"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	di "arhat.dev/dukkha/internal"
+	"arhat.dev/dukkha/pkg/dukkha"
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+	st "arhat.dev/dukkha/pkg/renderer/s3/test"
+)
+
+func newTestDriver(t *testing.T, config Config) (dukkha.ConfigResolvingContext, *Driver) {
+	rc := dt.NewTestContext(context.TODO())
+	rc.(di.CacheDirSetter).SetCacheDir(t.TempDir())
+
+	d := &Driver{name: "s3", DefaultConfig: config}
+	if !assert.NoError(t, d.Init(rc.RendererCacheFS("s3"))) {
+		t.FailNow()
+	}
+
+	return rc, d
+}
+
+func TestDriver_RenderYaml(t *testing.T) {
+	srv := st.NewServer(t, "test")
+	srv.Put("base/foo.yaml", []byte("foo: bar\n"))
+	srv.Put("base/dir/a.txt", []byte("a"))
+	srv.Put("base/dir/b.txt", []byte("bb"))
+	srv.Put("base-other/c.txt", []byte("c"))
+	srv.Put("other.yaml", []byte("other: true\n"))
+
+	config := Config{
+		EndpointURL: srv.URL,
+		Region:      "us-east-1",
+		Bucket:      "test",
+		BasePath:    "base",
+	}
+
+	t.Run("Download", func(t *testing.T) {
+		rc, d := newTestDriver(t, config)
+
+		ret, err := d.RenderYaml(rc, "foo.yaml", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "foo: bar\n", string(ret))
+
+		_, err = d.RenderYaml(rc, "not-found.yaml", nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Spec", func(t *testing.T) {
+		rc, d := newTestDriver(t, config)
+
+		specConfig := config
+		specConfig.BasePath = ""
+		ret, err := d.RenderYaml(rc, rs.Init(&inputS3Sepc{
+			Path:   "other.yaml",
+			Config: specConfig,
+		}, nil), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "other: true\n", string(ret))
+	})
+
+	t.Run("List", func(t *testing.T) {
+		rc, d := newTestDriver(t, config)
+
+		for _, test := range []struct {
+			prefix   string
+			expected []string
+		}{
+			{prefix: "", expected: []string{"dir/a.txt", "dir/b.txt", "foo.yaml"}},
+			{prefix: "dir/", expected: []string{"dir/a.txt", "dir/b.txt"}},
+			{prefix: "dir/a", expected: []string{"dir/a.txt"}},
+			{prefix: "not-found/", expected: []string{}},
+		} {
+			ret, err := d.RenderYaml(rc, test.prefix, []dukkha.RendererAttribute{AttrList})
+			if !assert.NoError(t, err, test.prefix) {
+				continue
+			}
+
+			var objects []*objectInfo
+			assert.NoError(t, yaml.Unmarshal(ret, &objects))
+
+			keys := make([]string, 0)
+			for _, o := range objects {
+				keys = append(keys, o.Key)
+				assert.NotEmpty(t, o.ETag)
+				assert.NotEmpty(t, o.LastModified)
+			}
+
+			assert.EqualValues(t, test.expected, keys, test.prefix)
+		}
+
+		// download and list do not share cache
+		ret, err := d.RenderYaml(rc, "foo.yaml", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "foo: bar\n", string(ret))
+	})
+}
+
+func TestClient_Upload(t *testing.T) {
+	srv := st.NewServer(t, "test")
+	config := &Config{
+		EndpointURL: srv.URL,
+		Region:      "us-east-1",
+		Bucket:      "test",
+		BasePath:    "artifacts",
+	}
+
+	client, err := config.CreateClient()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	t.Run("Single", func(t *testing.T) {
+		data := []byte("hello")
+		assert.NoError(t, client.Upload(context.TODO(), "hello.txt", bytes.NewReader(data), int64(len(data)), &UploadOptions{
+			ContentType: "text/plain",
+			Metadata:    map[string]string{"Foo": "bar"},
+		}))
+
+		obj, ok := srv.Get("artifacts/hello.txt")
+		if !assert.True(t, ok) {
+			return
+		}
+
+		assert.Equal(t, data, obj.Data)
+		assert.Equal(t, "text/plain", obj.ContentType)
+		assert.Equal(t, "bar", obj.Metadata["foo"])
+		assert.Equal(t, 0, obj.Parts)
+	})
+
+	t.Run("Multipart", func(t *testing.T) {
+		data := bytes.Repeat([]byte("0123456789abcdef"), 11*1024*1024/16)
+		file := filepath.Join(t.TempDir(), "large")
+		assert.NoError(t, os.WriteFile(file, data, 0644))
+
+		f, err := os.Open(file)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer func() { _ = f.Close() }()
+
+		assert.NoError(t, client.Upload(context.TODO(), "large", f, int64(len(data)), &UploadOptions{
+			ContentType: "application/octet-stream",
+			Metadata:    map[string]string{"Foo": "bar"},
+			PartSize:    5 * 1024 * 1024,
+			Concurrency: 2,
+		}))
+
+		obj, ok := srv.Get("artifacts/large")
+		if !assert.True(t, ok) {
+			return
+		}
+
+		assert.True(t, bytes.Equal(data, obj.Data))
+		assert.Equal(t, "bar", obj.Metadata["foo"])
+		assert.Equal(t, 3, obj.Parts)
+	})
+}
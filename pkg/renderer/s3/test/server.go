@@ -0,0 +1,392 @@
+package s3_test
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5" // nolint:gosec
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Object stored in the test server
+type Object struct {
+	Data []byte
+
+	ContentType string
+
+	// Metadata is the user metadata, keys are lower cased without `x-amz-meta-` prefix
+	Metadata map[string]string
+
+	ETag         string
+	LastModified time.Time
+
+	// Parts is the count of parts if uploaded with multipart upload
+	Parts int
+}
+
+type multipartUpload struct {
+	key         string
+	contentType string
+	metadata    map[string]string
+
+	parts map[int][]byte
+}
+
+// Server is a minimal in memory s3 service with only one bucket,
+// supports path style requests for object get/put/list and multipart upload
+//
+// requests are not authenticated
+type Server struct {
+	*httptest.Server
+
+	Bucket string
+
+	mu        sync.Mutex
+	objects   map[string]*Object
+	uploads   map[string]*multipartUpload
+	uploadSeq int
+}
+
+// NewServer creates a started test server, closed when test finished
+func NewServer(t *testing.T, bucket string) *Server {
+	s := &Server{
+		Bucket: bucket,
+
+		objects: make(map[string]*Object),
+		uploads: make(map[string]*multipartUpload),
+	}
+
+	s.Server = httptest.NewServer(s)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Put object with data as content
+func (s *Server) Put(key string, data []byte) {
+	s.put(key, &Object{Data: data, ContentType: "application/octet-stream"})
+}
+
+// Get object by key
+func (s *Server) Get(key string) (*Object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	obj, ok := s.objects[key]
+	return obj, ok
+}
+
+func (s *Server) put(key string, obj *Object) {
+	if len(obj.ETag) == 0 {
+		obj.ETag = md5Hex(obj.Data)
+	}
+	obj.LastModified = time.Now().UTC().Truncate(time.Second)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.objects[key] = obj
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	if parts[0] != s.Bucket {
+		writeError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	var key string
+	if len(parts) == 2 {
+		key = parts[1]
+	}
+
+	q := r.URL.Query()
+	_, isLocation := q["location"]
+	_, isInitiate := q["uploads"]
+	uploadID := q.Get("uploadId")
+
+	switch {
+	case len(key) == 0 && r.Method == http.MethodGet && isLocation:
+		writeXML(w, &struct {
+			XMLName xml.Name `xml:"LocationConstraint"`
+		}{})
+	case len(key) == 0 && r.Method == http.MethodGet:
+		s.handleList(w, q.Get("prefix"))
+	case len(key) == 0:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	case r.Method == http.MethodPost && isInitiate:
+		s.handleInitiate(w, r, key)
+	case r.Method == http.MethodPut && len(uploadID) != 0:
+		s.handleUploadPart(w, r, uploadID, q.Get("partNumber"))
+	case r.Method == http.MethodPost && len(uploadID) != 0:
+		s.handleComplete(w, r, key, uploadID)
+	case r.Method == http.MethodPut:
+		data, err := readBody(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+
+		obj := &Object{
+			Data:        data,
+			ContentType: r.Header.Get("Content-Type"),
+			Metadata:    userMetadata(r.Header),
+		}
+		s.put(key, obj)
+
+		w.Header().Set("ETag", strconv.Quote(obj.ETag))
+	case r.Method == http.MethodGet, r.Method == http.MethodHead:
+		obj, ok := s.Get(key)
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+
+		h := w.Header()
+		h.Set("Content-Length", strconv.Itoa(len(obj.Data)))
+		h.Set("Content-Type", obj.ContentType)
+		h.Set("ETag", strconv.Quote(obj.ETag))
+		h.Set("Last-Modified", obj.LastModified.Format(http.TimeFormat))
+		for k, v := range obj.Metadata {
+			h.Set("X-Amz-Meta-"+k, v)
+		}
+
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(obj.Data)
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func (s *Server) handleList(w http.ResponseWriter, prefix string) {
+	type content struct {
+		Key          string
+		LastModified string
+		ETag         string
+		Size         int
+		StorageClass string
+	}
+
+	s.mu.Lock()
+	var contents []content
+	for k, obj := range s.objects {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+
+		contents = append(contents, content{
+			Key:          k,
+			LastModified: obj.LastModified.Format(time.RFC3339),
+			ETag:         strconv.Quote(obj.ETag),
+			Size:         len(obj.Data),
+			StorageClass: "STANDARD",
+		})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(contents, func(i, j int) bool {
+		return contents[i].Key < contents[j].Key
+	})
+
+	writeXML(w, &struct {
+		XMLName     xml.Name `xml:"ListBucketResult"`
+		Name        string
+		Prefix      string
+		KeyCount    int
+		MaxKeys     int
+		IsTruncated bool
+		Contents    []content
+	}{
+		Name:     s.Bucket,
+		Prefix:   prefix,
+		KeyCount: len(contents),
+		MaxKeys:  1000,
+		Contents: contents,
+	})
+}
+
+func (s *Server) handleInitiate(w http.ResponseWriter, r *http.Request, key string) {
+	s.mu.Lock()
+	s.uploadSeq++
+	uploadID := fmt.Sprintf("upload-%d", s.uploadSeq)
+	s.uploads[uploadID] = &multipartUpload{
+		key:         key,
+		contentType: r.Header.Get("Content-Type"),
+		metadata:    userMetadata(r.Header),
+		parts:       make(map[int][]byte),
+	}
+	s.mu.Unlock()
+
+	writeXML(w, &struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+		Bucket   string
+		Key      string
+		UploadID string `xml:"UploadId"`
+	}{Bucket: s.Bucket, Key: key, UploadID: uploadID})
+}
+
+func (s *Server) handleUploadPart(w http.ResponseWriter, r *http.Request, uploadID, partNumber string) {
+	n, err := strconv.Atoi(partNumber)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidArgument")
+		return
+	}
+
+	data, err := readBody(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody")
+		return
+	}
+
+	s.mu.Lock()
+	upload, ok := s.uploads[uploadID]
+	if ok {
+		upload.parts[n] = data
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+
+	w.Header().Set("ETag", strconv.Quote(md5Hex(data)))
+}
+
+func (s *Server) handleComplete(w http.ResponseWriter, r *http.Request, key, uploadID string) {
+	req := &struct {
+		Parts []struct {
+			PartNumber int
+		} `xml:"Part"`
+	}{}
+
+	err := xml.NewDecoder(r.Body).Decode(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "MalformedXML")
+		return
+	}
+
+	s.mu.Lock()
+	upload, ok := s.uploads[uploadID]
+	delete(s.uploads, uploadID)
+	s.mu.Unlock()
+
+	if !ok || upload.key != key {
+		writeError(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+
+	var (
+		data bytes.Buffer
+		sums []byte
+	)
+	for _, p := range req.Parts {
+		part, ok := upload.parts[p.PartNumber]
+		if !ok {
+			writeError(w, http.StatusBadRequest, "InvalidPart")
+			return
+		}
+
+		data.Write(part)
+		sum := md5.Sum(part) // nolint:gosec
+		sums = append(sums, sum[:]...)
+	}
+
+	obj := &Object{
+		Data:        data.Bytes(),
+		ContentType: upload.contentType,
+		Metadata:    upload.metadata,
+		ETag:        fmt.Sprintf("%s-%d", md5Hex(sums), len(req.Parts)),
+		Parts:       len(req.Parts),
+	}
+	s.put(key, obj)
+
+	writeXML(w, &struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		Bucket  string
+		Key     string
+		ETag    string
+	}{Bucket: s.Bucket, Key: key, ETag: strconv.Quote(obj.ETag)})
+}
+
+// readBody reads request body, decodes aws-chunked payload if used
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Header.Get("X-Amz-Content-Sha256") != "STREAMING-AWS4-HMAC-SHA256-PAYLOAD" {
+		return io.ReadAll(r.Body)
+	}
+
+	// <hex-size>;chunk-signature=<signature>\r\n<data>\r\n
+	var (
+		br  = bufio.NewReader(r.Body)
+		buf bytes.Buffer
+	)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		size, err := strconv.ParseInt(strings.TrimSpace(strings.SplitN(line, ";", 2)[0]), 16, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		if size == 0 {
+			return buf.Bytes(), nil
+		}
+
+		_, err = io.CopyN(&buf, br, size)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = br.Discard(2)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func userMetadata(h http.Header) map[string]string {
+	ret := make(map[string]string)
+	for k := range h {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, "x-amz-meta-") {
+			ret[strings.TrimPrefix(lk, "x-amz-meta-")] = h.Get(k)
+		}
+	}
+
+	return ret
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_ = xml.NewEncoder(w).Encode(&struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string
+		Message string
+	}{Code: code, Message: code})
+}
+
+func md5Hex(data []byte) string {
+	sum := md5.Sum(data) // nolint:gosec
+	return hex.EncodeToString(sum[:])
+}
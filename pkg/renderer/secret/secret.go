@@ -0,0 +1,116 @@
+package secret
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/output"
+	"arhat.dev/dukkha/pkg/renderer"
+	"arhat.dev/dukkha/pkg/secret"
+)
+
+const (
+	DefaultName = "secret"
+)
+
+func init() { dukkha.RegisterRenderer(DefaultName, NewDefault) }
+
+func NewDefault(name string) dukkha.Renderer {
+	return &Driver{
+		name: name,
+	}
+}
+
+var _ dukkha.Renderer = (*Driver)(nil)
+
+// Driver decrypts secret values and files
+//
+// decrypted values are registered for output masking, and never cached
+type Driver struct {
+	rs.BaseField `yaml:"-"`
+
+	renderer.BaseRenderer `yaml:",inline"`
+
+	name string
+
+	Options configSpec `yaml:",inline"`
+
+	// keyring is created on first use, so keys are only required when
+	// there is something to decrypt
+	mu      sync.Mutex
+	keyring *secret.Keyring
+}
+
+func (d *Driver) RenderYaml(
+	rc dukkha.RenderingContext, rawData interface{}, _ []dukkha.RendererAttribute,
+) ([]byte, error) {
+	rawData, err := rs.NormalizeRawData(rawData)
+	if err != nil {
+		return nil, err
+	}
+
+	var input string
+	switch t := rawData.(type) {
+	case string:
+		input = t
+	case []byte:
+		input = string(t)
+	default:
+		return nil, fmt.Errorf(
+			"renderer.%s: unexpected non string input %T",
+			d.name, rawData,
+		)
+	}
+
+	kr, err := d.getKeyring(rc)
+	if err != nil {
+		return nil, fmt.Errorf("renderer.%s: loading keys: %w", d.name, err)
+	}
+
+	if secret.IsEncryptedValue(input) {
+		// value type is not enforced here, use type hint (e.g. `@secret?str`)
+		// for string values looking like other types
+		_, plaintext, err2 := kr.DecryptValue("", input)
+		if err2 != nil {
+			return nil, fmt.Errorf("renderer.%s: %w", d.name, err2)
+		}
+
+		output.AddSecrets(string(plaintext))
+		return plaintext, nil
+	}
+
+	data, err := rc.FS().ReadFile(strings.TrimSpace(input))
+	if err != nil {
+		return nil, fmt.Errorf("renderer.%s: reading secret file: %w", d.name, err)
+	}
+
+	plaintext, secrets, err := kr.Decrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("renderer.%s: %w", d.name, err)
+	}
+
+	output.AddSecrets(secrets...)
+
+	return plaintext, nil
+}
+
+func (d *Driver) getKeyring(rc dukkha.RenderingContext) (*secret.Keyring, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.keyring != nil {
+		return d.keyring, nil
+	}
+
+	kr, err := d.Options.createKeyring(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	d.keyring = kr
+	return kr, nil
+}
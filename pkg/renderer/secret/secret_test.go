@@ -0,0 +1,39 @@
+package secret
+
+import (
+	"testing"
+
+	"arhat.dev/rs"
+	"github.com/stretchr/testify/assert"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/output"
+)
+
+func TestNewDriver(t *testing.T) {
+	assert.NotNil(t, NewDefault(""))
+}
+
+func TestDriver_RenderYaml(t *testing.T) {
+	d := NewDefault("secret").(*Driver)
+	d.Options.AgeIdentityFile = "testdata/age.key"
+	d.Options.AESKeyFile = "testdata/aes.key"
+
+	dt.TestFixturesUsingRenderingSuffix(t, "./fixtures",
+		map[string]dukkha.Renderer{
+			"secret": d,
+		},
+		func() rs.Field { return &rs.AnyObjectMap{} },
+		func() rs.Field { return &rs.AnyObjectMap{} },
+		func(t *testing.T, ctx dukkha.Context, ts, cs rs.Field) {
+			actual, expected := ts.(*rs.AnyObjectMap), cs.(*rs.AnyObjectMap)
+
+			assert.EqualValues(t, expected.NormalizedValue(), actual.NormalizedValue())
+		},
+	)
+
+	// decrypted values are masked in output
+	assert.Equal(t, "token: ******", output.MaskSecrets("token: s3cr3t-t0ken"))
+	assert.Equal(t, "******", output.MaskSecrets("inline-secret"))
+}
@@ -0,0 +1,86 @@
+package secret
+
+import (
+	"fmt"
+
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/secret"
+)
+
+type configSpec struct {
+	rs.BaseField `yaml:"-"`
+
+	// AgeIdentities are age identities (`AGE-SECRET-KEY-1...`), one per line
+	AgeIdentities string `yaml:"age_identities"`
+
+	// AgeIdentityFile is the path to age identity file
+	AgeIdentityFile string `yaml:"age_identity_file"`
+
+	// AESKey is the base64 or hex encoded 256-bit aes key
+	AESKey string `yaml:"aes_key"`
+
+	// AESKeyFile is the path to file containing aes key
+	AESKeyFile string `yaml:"aes_key_file"`
+}
+
+// createKeyring loads keys in config, then keys in env DUKKHA_SECRET_*
+func (c *configSpec) createKeyring(rc dukkha.RenderingContext) (*secret.Keyring, error) {
+	kr := &secret.Keyring{}
+
+	if len(c.AgeIdentities) != 0 {
+		err := kr.AddAgeIdentities(c.AgeIdentities)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(c.AgeIdentityFile) != 0 {
+		data, err := rc.FS().ReadFile(c.AgeIdentityFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading age identity file: %w", err)
+		}
+
+		err = kr.AddAgeIdentities(string(data))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(c.AESKey) != 0 {
+		err := kr.SetAESKey(c.AESKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(c.AESKeyFile) != 0 {
+		data, err := rc.FS().ReadFile(c.AESKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading aes key file: %w", err)
+		}
+
+		err = kr.SetAESKey(string(data))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	env := rc.Env()
+	err := kr.LoadEnv(
+		func(name string) string {
+			if v, ok := env[name]; ok {
+				return v.Get()
+			}
+
+			return ""
+		},
+		rc.FS().ReadFile,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return kr, nil
+}
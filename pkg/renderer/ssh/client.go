@@ -3,31 +3,45 @@ package ssh
 import (
 	"crypto/rand"
 	"fmt"
+	"io"
 	"net"
+	"os"
 	"strconv"
+	"strings"
 
 	"arhat.dev/rs"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
 )
 
 type Spec struct {
 	rs.BaseField `yaml:"-"`
 
-	// User for git ssh service, defaults to `git`
+	// User for ssh login, defaults to `git` for git renderer and the current
+	// os user for ssh renderer
 	User string `yaml:"user"`
 
-	// Host of git ssh server e.g. gitlab.com
+	// Host of ssh server e.g. gitlab.com
 	Host string `yaml:"host"`
 
 	// Port of ssh service, defaults to `22`
 	Port int `yaml:"port"`
 
-	// HostKey is the public key to verify remote host
+	// HostKey is the public key to verify remote host, in authorized_keys
+	// format (e.g. `ssh-ed25519 AAAA...`) or its SHA256 fingerprint
+	// (e.g. `SHA256:...`)
+	//
+	// will skip host verification if not set
 	HostKey string `yaml:"host_key"`
 
 	// authentication
+
 	PrivateKey string `yaml:"private_key"`
 	Password   string `yaml:"password"`
+
+	// UseAgent enables public key authentication using keys in ssh-agent
+	// listening on unix socket SSH_AUTH_SOCK
+	UseAgent bool `yaml:"use_agent"`
 }
 
 func (s *Spec) Clone() *Spec {
@@ -35,51 +49,61 @@ func (s *Spec) Clone() *Spec {
 		User:       s.User,
 		Host:       s.Host,
 		Port:       s.Port,
-		HostKey:    s.Host,
+		HostKey:    s.HostKey,
 		PrivateKey: s.PrivateKey,
 		Password:   s.Password,
+		UseAgent:   s.UseAgent,
 	}
 }
 
 func NewClient(f *Spec) (*ssh.Client, error) {
-	var authMethod ssh.AuthMethod
+	var authMethods []ssh.AuthMethod
 
-	switch {
-	case len(f.PrivateKey) != 0:
+	if len(f.PrivateKey) != 0 {
 		signer, err := ssh.ParsePrivateKey([]byte(f.PrivateKey))
 		if err != nil {
 			return nil, fmt.Errorf("invalid private key: %w", err)
 		}
 
-		authMethod = ssh.PublicKeys(signer)
-	case len(f.Password) != 0:
-		authMethod = ssh.Password(f.Password)
-	default:
-		// TBD: allow user interaction or just return error?
-		authMethod = ssh.KeyboardInteractive(
-			func(
-				user, instruction string,
-				questions []string,
-				echos []bool,
-			) (answers []string, err error) {
-				return
-			},
-		)
-		_ = authMethod
+		authMethods = append(authMethods, ssh.PublicKeys(signer))
+	}
 
-		return nil, fmt.Errorf("no password or private key provided")
+	if f.UseAgent {
+		var agentConn io.Closer
+		defer func() {
+			if agentConn != nil {
+				_ = agentConn.Close()
+			}
+		}()
+
+		authMethods = append(authMethods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			socket := os.Getenv("SSH_AUTH_SOCK")
+			if len(socket) == 0 {
+				return nil, fmt.Errorf("no ssh-agent socket: SSH_AUTH_SOCK not set")
+			}
+
+			conn, err := net.Dial("unix", socket)
+			if err != nil {
+				return nil, fmt.Errorf("connect ssh-agent: %w", err)
+			}
+
+			agentConn = conn
+			return agent.NewClient(conn).Signers()
+		}))
 	}
 
-	var hostKeyCallback ssh.HostKeyCallback
-	if len(f.HostKey) != 0 {
-		hostKey, err := ssh.ParsePublicKey([]byte(f.HostKey))
-		if err != nil {
-			return nil, fmt.Errorf("invalid host key: %w", err)
-		}
+	if len(f.Password) != 0 {
+		authMethods = append(authMethods, ssh.Password(f.Password))
+	}
 
-		hostKeyCallback = ssh.FixedHostKey(hostKey)
-	} else {
-		hostKeyCallback = ssh.InsecureIgnoreHostKey()
+	if len(authMethods) == 0 {
+		// TBD: allow user interaction or just return error?
+		return nil, fmt.Errorf("no password, private key or ssh-agent provided")
+	}
+
+	hostKeyCallback, err := createHostKeyCallback(f.HostKey)
+	if err != nil {
+		return nil, err
 	}
 
 	user := f.User
@@ -99,7 +123,7 @@ func NewClient(f *Spec) (*ssh.Client, error) {
 			Config: ssh.Config{
 				Rand: rand.Reader,
 			},
-			Auth: []ssh.AuthMethod{authMethod},
+			Auth: authMethods,
 			BannerCallback: func(message string) error {
 				// TBD: what to do?
 				return nil
@@ -111,8 +135,35 @@ func NewClient(f *Spec) (*ssh.Client, error) {
 		},
 	)
 	if err != nil {
-		return nil, fmt.Errorf("dial remote git ssh server: %w", err)
+		return nil, fmt.Errorf("dial remote ssh server: %w", err)
 	}
 
 	return client, nil
 }
+
+func createHostKeyCallback(hostKey string) (ssh.HostKeyCallback, error) {
+	hostKey = strings.TrimSpace(hostKey)
+	switch {
+	case len(hostKey) == 0:
+		return ssh.InsecureIgnoreHostKey(), nil
+	case strings.HasPrefix(hostKey, "SHA256:"):
+		return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if fp := ssh.FingerprintSHA256(key); fp != hostKey {
+				return fmt.Errorf("host key mismatch: got %q", fp)
+			}
+
+			return nil
+		}, nil
+	}
+
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostKey))
+	if err != nil {
+		// wire format
+		key, err = ssh.ParsePublicKey([]byte(hostKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid host key: %w", err)
+		}
+	}
+
+	return ssh.FixedHostKey(key), nil
+}
@@ -0,0 +1,112 @@
+package ssh
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"regexp"
+	"strings"
+
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+var envNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+type rendererSSHConfig struct {
+	rs.BaseField `yaml:"-"`
+
+	// SSH connection config
+	SSH Spec `yaml:",inline"`
+
+	// Env to set before running the script in remote host
+	Env dukkha.Env `yaml:"env"`
+
+	// ForwardEnv is the list of names of local environment variables
+	// to set in remote host
+	ForwardEnv []string `yaml:"forward_env"`
+
+	// Shell used to run the script in remote host
+	//
+	// Defaults to `"sh"`
+	Shell string `yaml:"shell"`
+}
+
+// inputSSHSpec for renderer value
+type inputSSHSpec struct {
+	rs.BaseField `yaml:"-"`
+
+	// Script to run in remote host
+	Script string `yaml:"script"`
+
+	// Config of the renderer input
+	Config rendererSSHConfig `yaml:",inline"`
+}
+
+func (c *rendererSSHConfig) spec() *Spec {
+	ret := c.SSH.Clone()
+	if len(ret.User) == 0 {
+		// unlike git, use current user name by default
+		if u, err := user.Current(); err == nil {
+			ret.User = u.Username
+		} else {
+			ret.User = os.Getenv("USER")
+		}
+	}
+
+	return ret
+}
+
+// command generates the command to run script in remote host with env set
+func (c *rendererSSHConfig) command(
+	rc dukkha.RenderingContext, script string,
+) (string, error) {
+	sb := &strings.Builder{}
+
+	writeExport := func(name, value string) error {
+		if !envNameRegexp.MatchString(name) {
+			return fmt.Errorf("invalid env name %q", name)
+		}
+
+		sb.WriteString("export ")
+		sb.WriteString(name)
+		sb.WriteString("=")
+		sb.WriteString(shellQuote(value))
+		sb.WriteString("\n")
+		return nil
+	}
+
+	localEnv := rc.Env()
+	for _, name := range c.ForwardEnv {
+		v, ok := localEnv[name]
+		if !ok {
+			continue
+		}
+
+		err := writeExport(name, v.Get())
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for _, e := range c.Env {
+		err := writeExport(e.Name, e.Value)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	sb.WriteString(script)
+
+	shell := c.Shell
+	if len(shell) == 0 {
+		shell = "sh"
+	}
+
+	return shell + " -c " + shellQuote(sb.String()), nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
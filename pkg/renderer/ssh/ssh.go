@@ -1,4 +1,167 @@
 package ssh
 
-// TODO: implement ssh renderer to execute command on remote host
-// 		 then use output from stdout as result
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+
+	"arhat.dev/pkg/rshelper"
+	"arhat.dev/pkg/yamlhelper"
+	"arhat.dev/rs"
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/cache"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/renderer"
+)
+
+const (
+	DefaultName = "ssh"
+)
+
+func init() { dukkha.RegisterRenderer(DefaultName, NewDefault) }
+
+func NewDefault(name string) dukkha.Renderer {
+	return &Driver{name: name}
+}
+
+var _ dukkha.Renderer = (*Driver)(nil)
+
+// Driver is the ssh renderer implementation, it executes script
+// in remote host and uses output from stdout as result
+type Driver struct {
+	rs.BaseField `yaml:"-"`
+
+	renderer.BaseTwoTierCachedRenderer `yaml:",inline"`
+
+	name string
+
+	DefaultConfig rendererSSHConfig `yaml:",inline"`
+}
+
+func (d *Driver) RenderYaml(
+	rc dukkha.RenderingContext, rawData interface{}, attributes []dukkha.RendererAttribute,
+) ([]byte, error) {
+	var (
+		script string
+		config *rendererSSHConfig
+	)
+
+	rawData, err := rs.NormalizeRawData(rawData)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := rawData.(type) {
+	case string:
+		script = t
+		config = &d.DefaultConfig
+	case []byte:
+		script = string(t)
+		config = &d.DefaultConfig
+	default:
+		var rawBytes []byte
+		rawBytes, err = yamlhelper.ToYamlBytes(rawData)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"renderer.%s: unexpected non yaml input: %w",
+				d.name, err,
+			)
+		}
+
+		spec := rshelper.InitAll(&inputSSHSpec{}, &rs.Options{
+			InterfaceTypeHandler: rc,
+		}).(*inputSSHSpec)
+		err = yaml.Unmarshal(rawBytes, spec)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"renderer.%s: unmarshal input spec: %w",
+				d.name, err,
+			)
+		}
+
+		err = spec.ResolveFields(rc, -1)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"renderer.%s: resolving input spec: %w",
+				d.name, err,
+			)
+		}
+
+		script = spec.Script
+		config = &spec.Config
+	}
+
+	sshConfig := config.spec()
+	cmd, err := config.command(rc, script)
+	if err != nil {
+		return nil, fmt.Errorf("renderer.%s: %w", d.name, err)
+	}
+
+	data, err := renderer.HandleRenderingRequestWithRemoteFetch(
+		d.Cache,
+		cache.IdentifiableString(cacheKey(sshConfig, cmd)),
+		func(_ cache.IdentifiableObject) (io.ReadCloser, error) {
+			ret, err2 := run(sshConfig, cmd)
+			if err2 != nil {
+				return nil, err2
+			}
+
+			// ssh access always requires credentials
+			return cache.PrivateContent(ret), nil
+		},
+		d.Attributes(attributes),
+		renderer.FetchOptions{
+			Resource: "ssh://" + sshConfig.User + "@" + sshConfig.Host + ":" + strconv.Itoa(sshConfig.Port),
+		},
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf(
+			"renderer.%s: running remote script: %w",
+			d.name, err,
+		)
+	}
+
+	return data, nil
+}
+
+// cacheKey identifies the command run in remote host
+func cacheKey(sshConfig *Spec, cmd string) string {
+	h := sha256.New()
+	_, _ = h.Write([]byte(sshConfig.User + "@" + sshConfig.Host + ":" + strconv.Itoa(sshConfig.Port) + "\n"))
+	_, _ = h.Write([]byte(cmd))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func run(sshConfig *Spec, cmd string) (io.ReadCloser, error) {
+	client, err := NewClient(sshConfig)
+	if err != nil {
+		return nil, fmt.Errorf("create ssh client: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("open ssh session: %w", err)
+	}
+	defer func() { _ = session.Close() }()
+
+	var (
+		stdout = &bytes.Buffer{}
+		stderr = &bytes.Buffer{}
+	)
+
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	err = session.Run(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, stderr.String())
+	}
+
+	return io.NopCloser(stdout), nil
+}
@@ -0,0 +1,318 @@
+package ssh
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"arhat.dev/rs"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	di "arhat.dev/dukkha/internal"
+	"arhat.dev/dukkha/pkg/dukkha"
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/renderer"
+)
+
+func newSigner(t *testing.T) ssh.Signer {
+	signer, _ := newKey(t)
+	return signer
+}
+
+// newKey generates a ed25519 key, returns its signer and pem encoded private key
+func newKey(t *testing.T) (ssh.Signer, string) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	signer, err := ssh.NewSignerFromKey(key)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return signer, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+// testServer runs `exec` requests using local shell
+type testServer struct {
+	addr *net.TCPAddr
+
+	execCount int32
+}
+
+func newTestServer(t *testing.T, hostKey ssh.Signer, clientKey ssh.PublicKey) *testServer {
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if c.User() == "foo" && string(pass) == "bar" {
+				return nil, nil
+			}
+
+			return nil, fmt.Errorf("password rejected")
+		},
+		PublicKeyCallback: func(c ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(clientKey.Marshal()) {
+				return nil, nil
+			}
+
+			return nil, fmt.Errorf("key rejected")
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { _ = l.Close() })
+
+	srv := &testServer{addr: l.Addr().(*net.TCPAddr)}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go srv.handleConn(conn, config)
+		}
+	}()
+
+	return srv
+}
+
+func (s *testServer) handleConn(conn net.Conn, config *ssh.ServerConfig) {
+	defer func() { _ = conn.Close() }()
+
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newCh := range chans {
+		if newCh.ChannelType() != "session" {
+			_ = newCh.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+
+		ch, chReqs, err := newCh.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer func() { _ = ch.Close() }()
+
+			for req := range chReqs {
+				if req.Type != "exec" {
+					_ = req.Reply(false, nil)
+					continue
+				}
+
+				_ = req.Reply(true, nil)
+				atomic.AddInt32(&s.execCount, 1)
+
+				var payload struct{ Command string }
+				_ = ssh.Unmarshal(req.Payload, &payload)
+
+				cmd := exec.Command("sh", "-c", payload.Command)
+				cmd.Stdout = ch
+				cmd.Stderr = ch.Stderr()
+
+				status := uint32(0)
+				if err := cmd.Run(); err != nil {
+					status = 1
+				}
+
+				_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+				return
+			}
+		}()
+	}
+}
+
+// serveTestAgent serves ssh-agent protocol on unix socket with the pem encoded
+// private key as the only identity
+func serveTestAgent(t *testing.T, keyPEM string) string {
+	key, err := ssh.ParseRawPrivateKey([]byte(keyPEM))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	keyring := agent.NewKeyring()
+	if !assert.NoError(t, keyring.Add(agent.AddedKey{PrivateKey: key, Comment: "test"})) {
+		t.FailNow()
+	}
+
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", socket)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	t.Cleanup(func() { _ = l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer func() { _ = conn.Close() }()
+				_ = agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+
+	return socket
+}
+
+func TestDriver_RenderYaml(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test server requires sh")
+	}
+
+	hostKey := newSigner(t)
+	clientKey, clientKeyPEM := newKey(t)
+
+	srv := newTestServer(t, hostKey, clientKey.PublicKey())
+
+	baseSpec := Spec{
+		User:       "foo",
+		Host:       srv.addr.IP.String(),
+		Port:       srv.addr.Port,
+		HostKey:    string(ssh.MarshalAuthorizedKey(hostKey.PublicKey())),
+		PrivateKey: clientKeyPEM,
+	}
+
+	newRC := func(t *testing.T) dukkha.ConfigResolvingContext {
+		rc := dt.NewTestContext(context.TODO())
+		rc.(di.CacheDirSetter).SetCacheDir(t.TempDir())
+		rc.AddEnv(true, &dukkha.EnvEntry{Name: "LOCAL_FOO", Value: "it's local"})
+		return rc
+	}
+
+	t.Run("Env", func(t *testing.T) {
+		rc := newRC(t)
+		d := &Driver{
+			name: "ssh",
+			DefaultConfig: rendererSSHConfig{
+				SSH:        baseSpec,
+				Env:        dukkha.Env{{Name: "REMOTE_BAR", Value: "bar"}},
+				ForwardEnv: []string{"LOCAL_FOO", "NOT_SET"},
+			},
+		}
+		assert.NoError(t, d.Init(rc.RendererCacheFS("ssh")))
+
+		ret, err := d.RenderYaml(rc, `printf "%s|%s" "$LOCAL_FOO" "$REMOTE_BAR"`, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "it's local|bar", string(ret))
+	})
+
+	t.Run("Spec", func(t *testing.T) {
+		rc := newRC(t)
+		d := &Driver{name: "ssh"}
+		assert.NoError(t, d.Init(rc.RendererCacheFS("ssh")))
+
+		spec := baseSpec.Clone()
+		spec.PrivateKey = ""
+		spec.Password = "bar"
+		spec.HostKey = ssh.FingerprintSHA256(hostKey.PublicKey())
+
+		ret, err := d.RenderYaml(rc, rs.Init(&inputSSHSpec{
+			Script: "echo -n spec",
+			Config: rendererSSHConfig{SSH: *spec},
+		}, nil), nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "spec", string(ret))
+	})
+
+	t.Run("Agent", func(t *testing.T) {
+		t.Setenv("SSH_AUTH_SOCK", serveTestAgent(t, clientKeyPEM))
+
+		rc := newRC(t)
+		spec := baseSpec.Clone()
+		spec.PrivateKey = ""
+		spec.UseAgent = true
+
+		d := &Driver{name: "ssh", DefaultConfig: rendererSSHConfig{SSH: *spec}}
+		assert.NoError(t, d.Init(rc.RendererCacheFS("ssh")))
+
+		ret, err := d.RenderYaml(rc, "echo -n agent", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "agent", string(ret))
+	})
+
+	t.Run("Host Key Mismatch", func(t *testing.T) {
+		rc := newRC(t)
+		spec := baseSpec.Clone()
+		spec.HostKey = string(ssh.MarshalAuthorizedKey(newSigner(t).PublicKey()))
+
+		d := &Driver{name: "ssh", DefaultConfig: rendererSSHConfig{SSH: *spec}}
+		assert.NoError(t, d.Init(rc.RendererCacheFS("ssh")))
+
+		_, err := d.RenderYaml(rc, "echo -n mismatch", nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Script Failure", func(t *testing.T) {
+		rc := newRC(t)
+		d := &Driver{name: "ssh", DefaultConfig: rendererSSHConfig{SSH: baseSpec}}
+		assert.NoError(t, d.Init(rc.RendererCacheFS("ssh")))
+
+		_, err := d.RenderYaml(rc, "echo failed >&2; exit 1", nil)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "failed")
+		}
+	})
+
+	t.Run("Cache", func(t *testing.T) {
+		rc := newRC(t)
+		d := &Driver{name: "ssh", DefaultConfig: rendererSSHConfig{SSH: baseSpec}}
+		d.CacheConfig = renderer.CacheConfig{Enabled: true, Timeout: time.Hour}
+		assert.NoError(t, d.Init(rc.RendererCacheFS("ssh")))
+
+		before := atomic.LoadInt32(&srv.execCount)
+		script := "date +%s%N"
+		first, err := d.RenderYaml(rc, script, nil)
+		assert.NoError(t, err)
+
+		second, err := d.RenderYaml(rc, script, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, string(first), string(second))
+		assert.EqualValues(t, 1, atomic.LoadInt32(&srv.execCount)-before)
+
+		path, err := d.RenderYaml(rc, script, []dukkha.RendererAttribute{renderer.AttrCachedFile})
+		assert.NoError(t, err)
+		assert.FileExists(t, string(path))
+		assert.EqualValues(t, 1, atomic.LoadInt32(&srv.execCount)-before)
+	})
+}
+
+func TestSpec_Clone(t *testing.T) {
+	spec := &Spec{
+		User: "foo", Host: "example.com", Port: 22,
+		HostKey: "SHA256:foo", PrivateKey: "key", Password: "bar", UseAgent: true,
+	}
+
+	assert.EqualValues(t, spec, spec.Clone())
+}
@@ -0,0 +1,111 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/compression"
+)
+
+type encodingKind string
+
+// nolint:revive
+const (
+	encoding_Base64 encodingKind = "base64"
+	encoding_Hex    encodingKind = "hex"
+)
+
+func encode(kind encodingKind, data []byte) ([]byte, error) {
+	switch kind {
+	case encoding_Base64:
+		ret := make([]byte, base64.StdEncoding.EncodedLen(len(data)))
+		base64.StdEncoding.Encode(ret, data)
+		return ret, nil
+	case encoding_Hex:
+		ret := make([]byte, hex.EncodedLen(len(data)))
+		hex.Encode(ret, data)
+		return ret, nil
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", kind)
+	}
+}
+
+// decode decodes data with surrounding spaces trimmed
+func decode(kind encodingKind, data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+
+	var (
+		ret []byte
+		n   int
+		err error
+	)
+	switch kind {
+	case encoding_Base64:
+		ret = make([]byte, base64.StdEncoding.DecodedLen(len(data)))
+		n, err = base64.StdEncoding.Decode(ret, data)
+	case encoding_Hex:
+		ret = make([]byte, hex.DecodedLen(len(data)))
+		n, err = hex.Decode(ret, data)
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", kind)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s data: %w", kind, err)
+	}
+
+	return ret[:n], nil
+}
+
+// Compression compresses the value
+type Compression struct {
+	rs.BaseField `yaml:"-" json:"-"`
+
+	// Method of compression, one of [gzip, zstd, xz, bzip2, lzma, deflate]
+	Method string `yaml:"method"`
+
+	// Level of compression, method specific, defaults to the default level
+	// of the method
+	Level string `yaml:"level"`
+}
+
+func (c *Compression) Compress(data []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w, err := compression.NewWriter(buf, c.Method, c.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = w.Write(data)
+	if err != nil {
+		_ = w.Close()
+		return nil, fmt.Errorf("compressing data: %w", err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, fmt.Errorf("finalizing compression: %w", err)
+	}
+
+	return buf.Next(buf.Len()), nil
+}
+
+func decompress(method string, data []byte) ([]byte, error) {
+	r, err := compression.NewReader(bytes.NewReader(data), method)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+
+	ret, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing %s data: %w", method, err)
+	}
+
+	return ret, nil
+}
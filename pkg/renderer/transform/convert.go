@@ -0,0 +1,122 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"arhat.dev/rs"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/pkg/ini"
+)
+
+type dataFormat string
+
+// nolint:revive
+const (
+	format_JSON dataFormat = "json"
+	format_YAML dataFormat = "yaml"
+	format_TOML dataFormat = "toml"
+	format_INI  dataFormat = "ini"
+)
+
+// Conversion converts value between data formats
+type Conversion struct {
+	rs.BaseField `yaml:"-" json:"-"`
+
+	// From is the format of the input value, one of [json, yaml, toml, ini]
+	From dataFormat `yaml:"from"`
+
+	// To is the format of the output value, one of [json, yaml, toml, ini]
+	To dataFormat `yaml:"to"`
+}
+
+func (c *Conversion) Convert(data []byte) ([]byte, error) {
+	v, err := unmarshalFormat(c.From, data)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s data: %w", c.From, err)
+	}
+
+	ret, err := marshalFormat(c.To, v)
+	if err != nil {
+		return nil, fmt.Errorf("encoding data as %s: %w", c.To, err)
+	}
+
+	return ret, nil
+}
+
+func unmarshalFormat(format dataFormat, data []byte) (interface{}, error) {
+	switch format {
+	case format_JSON, format_YAML:
+		// json is a subset of yaml
+		var v interface{}
+		err := yaml.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+
+		return normalize(v), nil
+	case format_TOML:
+		var v map[string]interface{}
+		err := toml.Unmarshal(data, &v)
+		if err != nil {
+			return nil, err
+		}
+
+		return v, nil
+	case format_INI:
+		return ini.Unmarshal(data)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+func marshalFormat(format dataFormat, v interface{}) ([]byte, error) {
+	switch format {
+	case format_JSON:
+		return json.Marshal(v)
+	case format_YAML:
+		return yaml.Marshal(v)
+	case format_TOML:
+		buf := &bytes.Buffer{}
+		enc := toml.NewEncoder(buf)
+		enc.Indent = ""
+		err := enc.Encode(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	case format_INI:
+		return ini.Marshal(v)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// normalize converts map[interface{}]interface{} decoded from yaml to
+// map[string]interface{} recursively, so it can be encoded as json
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		ret := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			ret[fmt.Sprint(k)] = normalize(e)
+		}
+		return ret
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = normalize(e)
+		}
+		return t
+	case []interface{}:
+		for i, e := range t {
+			t[i] = normalize(e)
+		}
+		return t
+	default:
+		return v
+	}
+}
@@ -0,0 +1,107 @@
+package transform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"arhat.dev/pkg/fshelper"
+	"arhat.dev/rs"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// JSONSchema validates the value (in json or yaml) against json schema
+type JSONSchema struct {
+	rs.BaseField `yaml:"-" json:"-"`
+
+	// Schema is the inline json schema
+	//
+	// Schema and File are mutually exclusive
+	Schema rs.AnyObject `yaml:"schema"`
+
+	// File is the local path to the json schema file (in json or yaml)
+	//
+	// Schema and File are mutually exclusive
+	File *string `yaml:"file"`
+}
+
+// Validate data against the schema, data is returned as is
+//
+// json schema draft-07 is used when `$schema` is not set
+func (s *JSONSchema) Validate(ofs *fshelper.OSFS, data []byte) error {
+	schemaData := s.Schema.NormalizedValue()
+	schemaURL := inlineSchemaURL
+	if s.File != nil {
+		schemaBytes, err := ofs.ReadFile(*s.File)
+		if err != nil {
+			return fmt.Errorf("reading json schema: %w", err)
+		}
+
+		err = yaml.Unmarshal(schemaBytes, &schemaData)
+		if err != nil {
+			return fmt.Errorf("decoding json schema %q: %w", *s.File, err)
+		}
+
+		// keep the file path so relative $ref resolves to sibling files
+		schemaURL, err = ofs.Abs(*s.File)
+		if err != nil {
+			return fmt.Errorf("resolving json schema path %q: %w", *s.File, err)
+		}
+	}
+
+	if schemaData == nil {
+		return fmt.Errorf("no json schema provided")
+	}
+
+	schemaJSON, err := json.Marshal(schemaData)
+	if err != nil {
+		return fmt.Errorf("encoding json schema: %w", err)
+	}
+
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft7
+	err = c.AddResource(schemaURL, bytes.NewReader(schemaJSON))
+	if err != nil {
+		return err
+	}
+
+	schema, err := c.Compile(schemaURL)
+	if err != nil {
+		return err
+	}
+
+	value, err := toJSONValue(data)
+	if err != nil {
+		return fmt.Errorf("decoding value: %w", err)
+	}
+
+	return schema.Validate(value)
+}
+
+// inlineSchemaURL is the url of inline json schema
+const inlineSchemaURL = "inline-schema.json"
+
+// toJSONValue decodes data (in json or yaml) as raw json value accepted by
+// jsonschema
+func toJSONValue(data []byte) (interface{}, error) {
+	var value interface{}
+	err := yaml.Unmarshal(data, &value)
+	if err != nil {
+		return nil, err
+	}
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(valueJSON))
+	dec.UseNumber()
+	err = dec.Decode(&value)
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
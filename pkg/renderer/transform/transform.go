@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"arhat.dev/pkg/textquery"
 	"arhat.dev/pkg/yamlhelper"
 	"arhat.dev/rs"
 	"gopkg.in/yaml.v3"
@@ -98,6 +99,21 @@ type Operation struct {
 	Template *string   `yaml:"template,omitempty"`
 	Shell    *string   `yaml:"shell,omitempty"`
 	Checksum *Checksum `yaml:"checksum,omitempty"`
+
+	// JQ runs jq query over json value
+	JQ *string `yaml:"jq,omitempty"`
+	// YQ runs jq query over yaml value
+	YQ *string `yaml:"yq,omitempty"`
+
+	Encode *encodingKind `yaml:"encode,omitempty"`
+	Decode *encodingKind `yaml:"decode,omitempty"`
+
+	Compress   *Compression `yaml:"compress,omitempty"`
+	Decompress *string      `yaml:"decompress,omitempty"`
+
+	Convert *Conversion `yaml:"convert,omitempty"`
+
+	ValidateJSONSchema *JSONSchema `yaml:"validate_json_schema,omitempty"`
 }
 
 func (op *Operation) Do(_rc dukkha.RenderingContext, valueBytes []byte) ([]byte, error) {
@@ -168,6 +184,34 @@ func (op *Operation) Do(_rc dukkha.RenderingContext, valueBytes []byte) ([]byte,
 		return buf.Next(buf.Len()), nil
 	case op.Checksum != nil:
 		return valueBytes, op.Checksum.Verify(rc.FS())
+	case op.JQ != nil:
+		var ret string
+		ret, err = textquery.JQBytes(*op.JQ, valueBytes)
+		if err != nil {
+			return nil, fmt.Errorf("running jq query %q: %w", *op.JQ, err)
+		}
+
+		return []byte(ret), nil
+	case op.YQ != nil:
+		var ret string
+		ret, err = textquery.YQBytes(*op.YQ, valueBytes)
+		if err != nil {
+			return nil, fmt.Errorf("running yq query %q: %w", *op.YQ, err)
+		}
+
+		return []byte(ret), nil
+	case op.Encode != nil:
+		return encode(*op.Encode, valueBytes)
+	case op.Decode != nil:
+		return decode(*op.Decode, valueBytes)
+	case op.Compress != nil:
+		return op.Compress.Compress(valueBytes)
+	case op.Decompress != nil:
+		return decompress(*op.Decompress, valueBytes)
+	case op.Convert != nil:
+		return op.Convert.Convert(valueBytes)
+	case op.ValidateJSONSchema != nil:
+		return valueBytes, op.ValidateJSONSchema.Validate(rc.FS(), valueBytes)
 	default:
 		return valueBytes, nil
 	}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"testing"
 
+	"arhat.dev/pkg/fshelper"
 	"arhat.dev/pkg/testhelper"
 	"arhat.dev/rs"
 	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
 
 	di "arhat.dev/dukkha/internal"
 	"arhat.dev/dukkha/pkg/dukkha"
@@ -51,3 +53,66 @@ func TestDriver_RenderYaml(t *testing.T) {
 		},
 	)
 }
+
+func TestJSONSchema_Validate(t *testing.T) {
+	ofs := fshelper.NewOSFS(false, func() (string, error) { return ".", nil })
+	file := "testdata/schema.yaml"
+
+	for _, test := range []struct {
+		name   string
+		schema *JSONSchema
+		data   string
+		errMsg string
+	}{
+		{
+			name:   "File Valid",
+			schema: &JSONSchema{File: &file},
+			data:   "name: app\nreplicas: 1",
+		},
+		{
+			name:   "File Missing Required",
+			schema: &JSONSchema{File: &file},
+			data:   `{"name": "app"}`,
+			errMsg: "replicas",
+		},
+		{
+			name:   "File Invalid Type",
+			schema: &JSONSchema{File: &file},
+			data:   "name: app\nreplicas: 1.5",
+			errMsg: "expected integer",
+		},
+		{
+			name:   "Inline Invalid",
+			schema: inlineSchema(t, "{ properties: { name: { pattern: '^[a-z]+$' } } }"),
+			data:   "name: App",
+			errMsg: "does not match pattern",
+		},
+		{
+			name:   "No Schema",
+			schema: &JSONSchema{},
+			data:   "name: app",
+			errMsg: "no json schema",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.schema.Validate(ofs, []byte(test.data))
+			if len(test.errMsg) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), test.errMsg)
+			}
+		})
+	}
+}
+
+func inlineSchema(t *testing.T, schema string) *JSONSchema {
+	s := rs.Init(&JSONSchema{}, nil).(*JSONSchema)
+	if !assert.NoError(t, yaml.Unmarshal([]byte("schema: "+schema), s)) {
+		t.FailNow()
+	}
+
+	return s
+}
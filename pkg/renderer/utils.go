@@ -1,8 +1,14 @@
 package renderer
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"arhat.dev/pkg/log"
 	"arhat.dev/rs"
 	"gopkg.in/yaml.v3"
 
@@ -25,11 +31,55 @@ func ResolveInputSpec(rc rs.RenderingHandler, rawBytes []byte, out rs.Field) (rs
 	return out, nil
 }
 
+// ErrNotCached is returned in offline mode when remote content is not cached
+var ErrNotCached = errors.New("not cached")
+
+var offline uint32
+
+// SetOffline enables or disables offline mode, in offline mode, remote
+// content is always served from local cache (expired cache allowed) and
+// never fetched
+func SetOffline(v bool) {
+	if v {
+		atomic.StoreUint32(&offline, 1)
+	} else {
+		atomic.StoreUint32(&offline, 0)
+	}
+}
+
+// IsOffline returns true when offline mode is enabled
+func IsOffline() bool { return atomic.LoadUint32(&offline) == 1 }
+
+// FetchOptions customizes HandleRenderingRequestWithRemoteFetch
+type FetchOptions struct {
+	// Resource is the human readable name of the content (e.g. url),
+	// used in error messages, defaults to the first line of the cache key
+	Resource string
+
+	// FetchWhenOffline calls fetchRemote even in offline mode, for content
+	// produced locally (e.g. output of local commands)
+	FetchWhenOffline bool
+}
+
+// fetchNotCached creates the remote fetch func used in offline mode
+func fetchNotCached(resource string) cache.RemoteCacheRefreshFunc {
+	return func(obj cache.IdentifiableObject) (io.ReadCloser, error) {
+		if len(resource) == 0 {
+			// only the first line of cache key, which never contains header
+			// values (see http renderer)
+			resource = strings.SplitN(obj.ScopeUniqueID(), "\n", 2)[0]
+		}
+
+		return nil, fmt.Errorf("offline mode: %q %w", resource, ErrNotCached)
+	}
+}
+
 func HandleRenderingRequestWithRemoteFetch(
 	cache *cache.TwoTierCache,
 	obj cache.IdentifiableObject,
 	fetchRemote cache.RemoteCacheRefreshFunc,
 	attributes []dukkha.RendererAttribute,
+	opts FetchOptions,
 ) ([]byte, error) {
 	var (
 		allowExpired         = false
@@ -45,14 +95,20 @@ func HandleRenderingRequestWithRemoteFetch(
 		}
 	}
 
+	if IsOffline() && !opts.FetchWhenOffline {
+		allowExpired = true
+		fetchRemote = fetchNotCached(opts.Resource)
+	}
+
 	var (
-		data []byte
-		err  error
+		data    []byte
+		expired bool
+		err     error
 	)
 
 	if returnCachedFilePath {
 		var path string
-		path, _, err = cache.GetPath(
+		path, expired, err = cache.GetPath(
 			obj,
 			time.Now().Unix(),
 			allowExpired,
@@ -60,7 +116,7 @@ func HandleRenderingRequestWithRemoteFetch(
 		)
 		data = []byte(path)
 	} else {
-		data, _, err = cache.Get(
+		data, expired, err = cache.Get(
 			obj,
 			time.Now().Unix(),
 			allowExpired,
@@ -68,5 +124,10 @@ func HandleRenderingRequestWithRemoteFetch(
 		)
 	}
 
+	if err != nil && expired && allowExpired {
+		log.Log.D("using expired cache", log.Error(err))
+		return data, nil
+	}
+
 	return data, err
 }
@@ -30,7 +30,7 @@ func TestHandleRenderingRequestWithRemoteFetch(t *testing.T) {
 			return cacheDir, nil
 		}), -1, -1, -1)
 		data, err := HandleRenderingRequestWithRemoteFetch(
-			c, obj, fetchRemoteAlwaysOk, []dukkha.RendererAttribute{"allow-expired"},
+			c, obj, fetchRemoteAlwaysOk, []dukkha.RendererAttribute{"allow-expired"}, FetchOptions{},
 		)
 		assert.NoError(t, err)
 		assert.EqualValues(t, cachedData, string(data))
@@ -44,7 +44,7 @@ func TestHandleRenderingRequestWithRemoteFetch(t *testing.T) {
 			return cacheDir, nil
 		}), -1, -1, -1)
 		data, err := HandleRenderingRequestWithRemoteFetch(
-			c, obj, fetchRemoteAlwaysOk, []dukkha.RendererAttribute{"cached-file"},
+			c, obj, fetchRemoteAlwaysOk, []dukkha.RendererAttribute{"cached-file"}, FetchOptions{},
 		)
 		assert.NoError(t, err)
 		assert.EqualValues(t, cacheDir, filepath.Dir(string(data)))
@@ -53,4 +53,51 @@ func TestHandleRenderingRequestWithRemoteFetch(t *testing.T) {
 		assert.NoError(t, err)
 		assert.EqualValues(t, cachedData, string(data))
 	})
+	t.Run("offline", func(t *testing.T) {
+		cacheDir := t.TempDir()
+		c := cache.NewTwoTierCache(fshelper.NewOSFS(false, func() (string, error) {
+			return cacheDir, nil
+		}), -1, -1, -1)
+
+		fetchRemoteNotCalled := cache.RemoteCacheRefreshFunc(func(obj cache.IdentifiableObject) (io.ReadCloser, error) {
+			assert.Fail(t, "unexpected remote fetch in offline mode")
+			return nil, io.EOF
+		})
+
+		SetOffline(true)
+		defer SetOffline(false)
+
+		_, err := HandleRenderingRequestWithRemoteFetch(c, obj, fetchRemoteNotCalled, nil, FetchOptions{})
+		assert.ErrorIs(t, err, ErrNotCached)
+		assert.Contains(t, err.Error(), `"test"`)
+
+		_, err = HandleRenderingRequestWithRemoteFetch(
+			c, obj, fetchRemoteNotCalled, nil, FetchOptions{Resource: "https://example.com/test"},
+		)
+		assert.ErrorIs(t, err, ErrNotCached)
+		assert.Contains(t, err.Error(), `"https://example.com/test"`)
+
+		SetOffline(false)
+		_, err = HandleRenderingRequestWithRemoteFetch(c, obj, fetchRemoteAlwaysOk, nil, FetchOptions{})
+		assert.NoError(t, err)
+
+		// always expired
+		SetOffline(true)
+		data, err := HandleRenderingRequestWithRemoteFetch(c, obj, fetchRemoteNotCalled, nil, FetchOptions{})
+		assert.NoError(t, err)
+		assert.EqualValues(t, cachedData, string(data))
+
+		data, err = HandleRenderingRequestWithRemoteFetch(
+			c, obj, fetchRemoteNotCalled, []dukkha.RendererAttribute{"cached-file"}, FetchOptions{},
+		)
+		assert.NoError(t, err)
+		assert.EqualValues(t, cacheDir, filepath.Dir(string(data)))
+
+		// local content is still fetched when not cached
+		data, err = HandleRenderingRequestWithRemoteFetch(
+			c, cache.IdentifiableString("local"), fetchRemoteAlwaysOk, nil, FetchOptions{FetchWhenOffline: true},
+		)
+		assert.NoError(t, err)
+		assert.EqualValues(t, cachedData, string(data))
+	})
 }
@@ -0,0 +1,215 @@
+package secret
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MetadataKey is the top-level key of Metadata in encrypted yaml document
+const MetadataKey = "dukkha_secret"
+
+// Metadata of encrypted yaml document
+type Metadata struct {
+	// Method used to encrypt values
+	Method Method `yaml:"method"`
+
+	// AgeRecipients are recipients values encrypted for
+	AgeRecipients []string `yaml:"age_recipients,omitempty"`
+}
+
+// ReadMetadata returns metadata in the yaml document, nil if not found
+func ReadMetadata(doc *yaml.Node) (*Metadata, error) {
+	_, node := findMetadata(doc)
+	if node == nil {
+		return nil, nil
+	}
+
+	md := &Metadata{}
+	err := node.Decode(md)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s metadata: %w", MetadataKey, err)
+	}
+
+	return md, nil
+}
+
+// findMetadata finds the metadata key and value node in document
+func findMetadata(doc *yaml.Node) (idx int, value *yaml.Node) {
+	root := documentRoot(doc)
+	if root == nil || root.Kind != yaml.MappingNode {
+		return -1, nil
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == MetadataKey {
+			return i, root.Content[i+1]
+		}
+	}
+
+	return -1, nil
+}
+
+func documentRoot(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode {
+		if len(doc.Content) == 0 {
+			return nil
+		}
+
+		return doc.Content[0]
+	}
+
+	return doc
+}
+
+// EncryptDocument encrypts all scalar values in the yaml document in place,
+// already encrypted values are kept as is, metadata is added or updated
+func (kr *Keyring) EncryptDocument(doc *yaml.Node, method Method) error {
+	root := documentRoot(doc)
+	if root == nil || root.Kind != yaml.MappingNode {
+		return fmt.Errorf("secret document must be a map")
+	}
+
+	idx, _ := findMetadata(doc)
+	if idx >= 0 {
+		root.Content = append(root.Content[:idx], root.Content[idx+2:]...)
+	}
+
+	err := kr.walk(root, "", func(path string, n *yaml.Node) error {
+		if IsEncryptedValue(n.Value) {
+			return nil
+		}
+
+		var typ ValueType
+		switch n.ShortTag() {
+		case "!!str", "!!binary", "!!timestamp":
+			typ = ValueType_Str
+		case "!!int":
+			typ = ValueType_Int
+		case "!!float":
+			typ = ValueType_Float
+		case "!!bool":
+			typ = ValueType_Bool
+		default:
+			// null
+			return nil
+		}
+
+		v, err := kr.EncryptValue(method, path, typ, []byte(n.Value))
+		if err != nil {
+			return err
+		}
+
+		n.Tag, n.Value, n.Style = "!!str", v, 0
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	md := &Metadata{Method: method}
+	if method == MethodAge {
+		for _, r := range kr.AgeRecipients {
+			md.AgeRecipients = append(md.AgeRecipients, fmt.Sprint(r))
+		}
+	}
+
+	mdNode := &yaml.Node{}
+	err = mdNode.Encode(md)
+	if err != nil {
+		return err
+	}
+
+	root.Content = append(root.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: MetadataKey},
+		mdNode,
+	)
+
+	return nil
+}
+
+// DecryptDocument decrypts all encrypted values in the yaml document in
+// place and removes the metadata, it returns decrypted plaintext values
+func (kr *Keyring) DecryptDocument(doc *yaml.Node) ([]string, error) {
+	root := documentRoot(doc)
+	if root == nil {
+		return nil, nil
+	}
+
+	if idx, _ := findMetadata(doc); idx >= 0 {
+		root.Content = append(root.Content[:idx], root.Content[idx+2:]...)
+	}
+
+	var plaintexts []string
+	err := kr.walk(root, "", func(path string, n *yaml.Node) error {
+		if !IsEncryptedValue(n.Value) {
+			return nil
+		}
+
+		typ, data, err := kr.DecryptValue(path, n.Value)
+		if err != nil {
+			return err
+		}
+
+		n.Value, n.Style = string(data), 0
+		switch typ {
+		case ValueType_Int:
+			n.Tag = "!!int"
+		case ValueType_Float:
+			n.Tag = "!!float"
+		case ValueType_Bool:
+			n.Tag = "!!bool"
+		default:
+			n.Tag = "!!str"
+			if strings.Contains(n.Value, "\n") {
+				n.Style = yaml.LiteralStyle
+			}
+		}
+
+		plaintexts = append(plaintexts, n.Value)
+		return nil
+	})
+
+	return plaintexts, err
+}
+
+// walk calls fn on every scalar value node with its key path
+// (e.g. `foo.bar[0].baz`)
+func (kr *Keyring) walk(n *yaml.Node, path string, fn func(path string, n *yaml.Node) error) error {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			err := kr.walk(c, path, fn)
+			if err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i].Value
+			if len(path) != 0 {
+				key = path + "." + key
+			}
+
+			err := kr.walk(n.Content[i+1], key, fn)
+			if err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			err := kr.walk(c, path+"["+strconv.Itoa(i)+"]", fn)
+			if err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		return fn(path, n)
+	case yaml.AliasNode:
+		// alias values are encrypted where they are defined
+	}
+
+	return nil
+}
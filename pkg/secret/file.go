@@ -0,0 +1,189 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"gopkg.in/yaml.v3"
+)
+
+// Decrypt decrypts data in any supported format, it returns the plaintext
+// and decrypted secret values
+//
+// for yaml documents, the plaintext is the decrypted yaml document without
+// metadata, for single encrypted value, the plaintext is the raw value
+func (kr *Keyring) Decrypt(data []byte) (plaintext []byte, secrets []string, err error) {
+	switch {
+	case IsAgeFile(data):
+		plaintext, err = kr.DecryptAgeFile(data)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return plaintext, []string{string(plaintext)}, nil
+	case IsEncryptedValue(string(data)):
+		_, plaintext, err = kr.DecryptValue("", string(data))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return plaintext, []string{string(plaintext)}, nil
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+
+	for {
+		doc := &yaml.Node{}
+		err = dec.Decode(doc)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, nil, fmt.Errorf("decoding secret document: %w", err)
+		}
+
+		var values []string
+		values, err = kr.DecryptDocument(doc)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		secrets = append(secrets, values...)
+
+		err = enc.Encode(doc)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	err = enc.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return buf.Bytes(), secrets, nil
+}
+
+// DecryptAgeFile decrypts age encrypted file (binary or armored)
+func (kr *Keyring) DecryptAgeFile(data []byte) ([]byte, error) {
+	if len(kr.AgeIdentities) == 0 {
+		return nil, fmt.Errorf("no age identity")
+	}
+
+	var src io.Reader = bytes.NewReader(data)
+	if !bytes.HasPrefix(data, []byte(ageHeader)) {
+		src = armor.NewReader(bytes.NewReader(bytes.TrimSpace(data)))
+	}
+
+	r, err := age.Decrypt(src, kr.AgeIdentities...)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting age file: %w", err)
+	}
+
+	return io.ReadAll(r)
+}
+
+// EncryptFile encrypts the whole file
+//
+// for age, the result is an age encrypted file, armored if requested,
+// for aes, the result is a single encrypted value
+func (kr *Keyring) EncryptFile(method Method, data []byte, armored bool) ([]byte, error) {
+	if method == MethodAESGCM {
+		v, err := kr.EncryptValue(method, "", ValueType_Bin, data)
+		if err != nil {
+			return nil, err
+		}
+
+		return []byte(v + "\n"), nil
+	}
+
+	if method != MethodAge {
+		return nil, fmt.Errorf("unsupported encryption method %q", method)
+	}
+
+	if len(kr.AgeRecipients) == 0 {
+		return nil, fmt.Errorf("no age recipient")
+	}
+
+	buf := &bytes.Buffer{}
+
+	var (
+		dst io.Writer = buf
+		aw  io.WriteCloser
+	)
+	if armored {
+		aw = armor.NewWriter(buf)
+		dst = aw
+	}
+
+	w, err := age.Encrypt(dst, kr.AgeRecipients...)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = w.Write(data)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	if aw != nil {
+		err = aw.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		buf.WriteString("\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// EncryptYaml encrypts values in all yaml documents in data
+func (kr *Keyring) EncryptYaml(method Method, data []byte) ([]byte, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+
+	for {
+		doc := &yaml.Node{}
+		err := dec.Decode(doc)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, fmt.Errorf("decoding yaml: %w", err)
+		}
+
+		err = kr.EncryptDocument(doc, method)
+		if err != nil {
+			return nil, err
+		}
+
+		err = enc.Encode(doc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := enc.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
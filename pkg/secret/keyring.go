@@ -0,0 +1,177 @@
+// Package secret implements encryption of in-repo secret files
+//
+// a secret file is either
+//   - a yaml document with keys in plaintext and every scalar value
+//     encrypted individually (like sops), or
+//   - a whole file encrypted in age format (binary or armored), or
+//   - a single encrypted value
+//
+// values are encrypted with age (X25519 recipients) or AES-256-GCM using
+// a shared key
+package secret
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+
+	"arhat.dev/dukkha/pkg/constant"
+)
+
+// Method of encryption
+type Method string
+
+const (
+	MethodAge    Method = "age"
+	MethodAESGCM Method = "aes256_gcm"
+)
+
+const (
+	// aesKeySize is the key size of AES-256
+	aesKeySize = 32
+
+	// ageHeader is the first line of age encrypted file
+	ageHeader = "age-encryption.org/v1\n"
+)
+
+// Keyring holds keys used to encrypt and decrypt secrets
+type Keyring struct {
+	// AgeIdentities to decrypt age encrypted data
+	AgeIdentities []age.Identity
+
+	// AgeRecipients to encrypt data with age
+	AgeRecipients []age.Recipient
+
+	// AESKey is the 256-bit key for AES-GCM
+	AESKey []byte
+}
+
+// AddAgeIdentities parses age identities (AGE-SECRET-KEY-1...), one per
+// line, comments (lines starting with `#`) are ignored
+//
+// recipients of X25519 identities are also added for re-encryption
+func (kr *Keyring) AddAgeIdentities(text string) error {
+	ids, err := age.ParseIdentities(strings.NewReader(text))
+	if err != nil {
+		return fmt.Errorf("parsing age identities: %w", err)
+	}
+
+	kr.AgeIdentities = append(kr.AgeIdentities, ids...)
+	for _, id := range ids {
+		if x, ok := id.(*age.X25519Identity); ok {
+			kr.AgeRecipients = append(kr.AgeRecipients, x.Recipient())
+		}
+	}
+
+	return nil
+}
+
+// AddAgeRecipients parses age recipients (age1...), one per line
+func (kr *Keyring) AddAgeRecipients(text string) error {
+	recipients, err := age.ParseRecipients(strings.NewReader(text))
+	if err != nil {
+		return fmt.Errorf("parsing age recipients: %w", err)
+	}
+
+	kr.AgeRecipients = append(kr.AgeRecipients, recipients...)
+	return nil
+}
+
+// SetAESKey sets AES key from base64 or hex encoded text
+func (kr *Keyring) SetAESKey(text string) error {
+	text = strings.TrimSpace(text)
+
+	key, err := base64.StdEncoding.DecodeString(text)
+	if err != nil || len(key) != aesKeySize {
+		key, err = hex.DecodeString(text)
+	}
+
+	if err != nil || len(key) != aesKeySize {
+		return fmt.Errorf("invalid aes key: expecting base64 or hex encoded %d bytes", aesKeySize)
+	}
+
+	kr.AESKey = key
+	return nil
+}
+
+// LoadEnv loads keys from environment variables
+//
+//   - DUKKHA_SECRET_AGE_IDENTITY: age identities
+//   - DUKKHA_SECRET_AGE_IDENTITY_FILE: path to age identity file
+//   - DUKKHA_SECRET_AES_KEY: base64 or hex encoded aes key
+//   - DUKKHA_SECRET_AES_KEY_FILE: path to aes key file
+func (kr *Keyring) LoadEnv(getenv func(name string) string, readFile func(name string) ([]byte, error)) error {
+	if v := getenv(constant.ENV_DUKKHA_SECRET_AGE_IDENTITY); len(v) != 0 {
+		err := kr.AddAgeIdentities(v)
+		if err != nil {
+			return fmt.Errorf("env %s: %w", constant.ENV_DUKKHA_SECRET_AGE_IDENTITY, err)
+		}
+	}
+
+	if v := getenv(constant.ENV_DUKKHA_SECRET_AGE_IDENTITY_FILE); len(v) != 0 {
+		data, err := readFile(v)
+		if err != nil {
+			return fmt.Errorf("env %s: %w", constant.ENV_DUKKHA_SECRET_AGE_IDENTITY_FILE, err)
+		}
+
+		err = kr.AddAgeIdentities(string(data))
+		if err != nil {
+			return fmt.Errorf("env %s: %w", constant.ENV_DUKKHA_SECRET_AGE_IDENTITY_FILE, err)
+		}
+	}
+
+	if v := getenv(constant.ENV_DUKKHA_SECRET_AES_KEY); len(v) != 0 {
+		err := kr.SetAESKey(v)
+		if err != nil {
+			return fmt.Errorf("env %s: %w", constant.ENV_DUKKHA_SECRET_AES_KEY, err)
+		}
+	}
+
+	if v := getenv(constant.ENV_DUKKHA_SECRET_AES_KEY_FILE); len(v) != 0 {
+		data, err := readFile(v)
+		if err != nil {
+			return fmt.Errorf("env %s: %w", constant.ENV_DUKKHA_SECRET_AES_KEY_FILE, err)
+		}
+
+		err = kr.SetAESKey(string(data))
+		if err != nil {
+			return fmt.Errorf("env %s: %w", constant.ENV_DUKKHA_SECRET_AES_KEY_FILE, err)
+		}
+	}
+
+	return nil
+}
+
+// GenerateAgeIdentity generates a new X25519 identity, returns the
+// identity and its recipient
+func GenerateAgeIdentity() (identity, recipient string, err error) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		return "", "", err
+	}
+
+	return id.String(), id.Recipient().String(), nil
+}
+
+// GenerateAESKey generates a base64 encoded random aes key
+func GenerateAESKey() (string, error) {
+	key := make([]byte, aesKeySize)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// IsAgeFile reports whether data is an age encrypted file (binary or armored)
+func IsAgeFile(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(ageHeader)) ||
+		bytes.HasPrefix(bytes.TrimSpace(data), []byte(armor.Header))
+}
@@ -0,0 +1,167 @@
+package secret
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// ValueType is the type of the plaintext value
+type ValueType string
+
+// nolint:revive
+const (
+	ValueType_Str   ValueType = "str"
+	ValueType_Int   ValueType = "int"
+	ValueType_Float ValueType = "float"
+	ValueType_Bool  ValueType = "bool"
+
+	// ValueType_Bin is used for whole file encrypted with aes
+	ValueType_Bin ValueType = "bin"
+)
+
+const (
+	encPrefix = "ENC["
+	encSuffix = "]"
+)
+
+// IsEncryptedValue reports whether s is an encrypted value in format
+// `ENC[<method>,<type>,<base64 data>]`
+func IsEncryptedValue(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, encPrefix) && strings.HasSuffix(s, encSuffix) &&
+		strings.Count(s, ",") == 2
+}
+
+// EncryptValue encrypts plaintext, path is authenticated along with the
+// plaintext so encrypted values can not be moved to other keys
+func (kr *Keyring) EncryptValue(method Method, path string, typ ValueType, plaintext []byte) (string, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	switch method {
+	case MethodAge:
+		if len(kr.AgeRecipients) == 0 {
+			return "", fmt.Errorf("no age recipient")
+		}
+
+		buf := &bytes.Buffer{}
+		var w io.WriteCloser
+		w, err = age.Encrypt(buf, kr.AgeRecipients...)
+		if err != nil {
+			return "", err
+		}
+
+		_, err = w.Write(append([]byte(path+"\x00"), plaintext...))
+		if err != nil {
+			return "", err
+		}
+
+		err = w.Close()
+		data = buf.Bytes()
+	case MethodAESGCM:
+		var aead cipher.AEAD
+		aead, err = kr.newAESGCM()
+		if err != nil {
+			return "", err
+		}
+
+		nonce := make([]byte, aead.NonceSize())
+		_, err = rand.Read(nonce)
+		if err != nil {
+			return "", err
+		}
+
+		data = aead.Seal(nonce, nonce, plaintext, []byte(path))
+	default:
+		return "", fmt.Errorf("unsupported encryption method %q", method)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("encrypting %q: %w", path, err)
+	}
+
+	return encPrefix + string(method) + "," + string(typ) + "," +
+		base64.StdEncoding.EncodeToString(data) + encSuffix, nil
+}
+
+// DecryptValue decrypts value encrypted by EncryptValue at the same path
+func (kr *Keyring) DecryptValue(path, value string) (ValueType, []byte, error) {
+	if !IsEncryptedValue(value) {
+		return "", nil, fmt.Errorf("%q: not an encrypted value", path)
+	}
+
+	value = strings.TrimSpace(value)
+	parts := strings.SplitN(value[len(encPrefix):len(value)-len(encSuffix)], ",", 3)
+	method, typ := Method(parts[0]), ValueType(parts[1])
+
+	data, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, fmt.Errorf("%q: invalid encrypted data: %w", path, err)
+	}
+
+	var plaintext []byte
+	switch method {
+	case MethodAge:
+		if len(kr.AgeIdentities) == 0 {
+			return "", nil, fmt.Errorf("%q: no age identity", path)
+		}
+
+		var r io.Reader
+		r, err = age.Decrypt(bytes.NewReader(data), kr.AgeIdentities...)
+		if err == nil {
+			plaintext, err = io.ReadAll(r)
+		}
+
+		if err == nil {
+			prefix := []byte(path + "\x00")
+			if !bytes.HasPrefix(plaintext, prefix) {
+				return "", nil, fmt.Errorf("%q: value was encrypted for another key", path)
+			}
+
+			plaintext = plaintext[len(prefix):]
+		}
+	case MethodAESGCM:
+		var aead cipher.AEAD
+		aead, err = kr.newAESGCM()
+		if err != nil {
+			return "", nil, err
+		}
+
+		if len(data) < aead.NonceSize() {
+			return "", nil, fmt.Errorf("%q: invalid encrypted data", path)
+		}
+
+		plaintext, err = aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(path))
+	default:
+		return "", nil, fmt.Errorf("%q: unsupported encryption method %q", path, method)
+	}
+
+	if err != nil {
+		return "", nil, fmt.Errorf("%q: decrypting value: %w", path, err)
+	}
+
+	return typ, plaintext, nil
+}
+
+func (kr *Keyring) newAESGCM() (cipher.AEAD, error) {
+	if len(kr.AESKey) == 0 {
+		return nil, fmt.Errorf("no aes key")
+	}
+
+	block, err := aes.NewCipher(kr.AESKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
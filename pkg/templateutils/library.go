@@ -0,0 +1,162 @@
+package templateutils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	di "arhat.dev/dukkha/internal"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/third_party/golang/text/template"
+	"arhat.dev/dukkha/third_party/golang/text/template/parse"
+)
+
+// TemplateLibrary is the set of named templates (`{{ define "name" }}` blocks)
+// parsed once and available to all templates created by CreateTemplate
+type TemplateLibrary struct {
+	mu    sync.RWMutex
+	trees map[string]*parse.Tree
+}
+
+// addTo associates all templates in the library with t
+func (l *TemplateLibrary) addTo(t *template.Template) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for name, tree := range l.trees {
+		_, _ = t.AddParseTree(name, tree)
+	}
+}
+
+// AddTemplateLibrary parses templates in texts and adds all defined templates
+// to the template library of rc, text outside `define` blocks is ignored
+//
+// when namespace is not empty, template names are prefixed with `<namespace>.`
+// (e.g. `{{ define "labels" }}` in namespace `k8s` is available as `k8s.labels`),
+// references to templates in texts by short name are rewritten to full name
+//
+// templates defined later replace existing ones with the same name
+func AddTemplateLibrary(rc dukkha.RenderingContext, namespace string, texts ...string) error {
+	const rootName = "#library"
+
+	// library templates are not required when parsing
+	tpl := newTemplate(rc)
+	for i, text := range texts {
+		_, err := tpl.New(rootName).Parse(text)
+		if err != nil {
+			return fmt.Errorf("parsing library template #%d: %w", i, err)
+		}
+	}
+
+	prefix := ""
+	if len(namespace) != 0 {
+		prefix = strings.TrimSuffix(namespace, ".") + "."
+	}
+
+	trees := make(map[string]*parse.Tree)
+	for _, t := range tpl.Templates() {
+		name := t.Name()
+		if name == rootName || t.Tree == nil {
+			continue
+		}
+
+		trees[name] = t.Tree
+	}
+
+	if len(prefix) != 0 {
+		prefixed := make(map[string]*parse.Tree, len(trees))
+		for name, tree := range trees {
+			// references to templates in the same library use unprefixed names
+			qualifyTemplateRefs(tree.Root, prefix, trees)
+			prefixed[prefix+name] = tree
+		}
+
+		trees = prefixed
+	}
+
+	lib := getTemplateLibrary(rc)
+	if lib == nil {
+		lib = &TemplateLibrary{trees: make(map[string]*parse.Tree)}
+		rc.(di.TemplateLibrarySetter).SetTemplateLibrary(lib)
+	}
+
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+	for name, tree := range trees {
+		lib.trees[name] = tree
+	}
+
+	return nil
+}
+
+// qualifyTemplateRefs prefixes names of templates in defined referenced by
+// `template` actions and `include` calls with string literal in node
+func qualifyTemplateRefs(node parse.Node, prefix string, defined map[string]*parse.Tree) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+
+		for _, c := range n.Nodes {
+			qualifyTemplateRefs(c, prefix, defined)
+		}
+	case *parse.ActionNode:
+		qualifyTemplateRefs(n.Pipe, prefix, defined)
+	case *parse.IfNode:
+		qualifyBranchTemplateRefs(&n.BranchNode, prefix, defined)
+	case *parse.RangeNode:
+		qualifyBranchTemplateRefs(&n.BranchNode, prefix, defined)
+	case *parse.WithNode:
+		qualifyBranchTemplateRefs(&n.BranchNode, prefix, defined)
+	case *parse.TemplateNode:
+		if _, ok := defined[n.Name]; ok {
+			n.Name = prefix + n.Name
+		}
+
+		qualifyTemplateRefs(n.Pipe, prefix, defined)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+
+		for _, cmd := range n.Cmds {
+			qualifyTemplateRefs(cmd, prefix, defined)
+		}
+	case *parse.CommandNode:
+		if len(n.Args) > 1 {
+			fn, isIdent := n.Args[0].(*parse.IdentifierNode)
+			name, isString := n.Args[1].(*parse.StringNode)
+			if isIdent && isString && fn.Ident == "include" {
+				if _, ok := defined[name.Text]; ok {
+					name.Text = prefix + name.Text
+					name.Quoted = strconv.Quote(name.Text)
+				}
+			}
+		}
+
+		for _, arg := range n.Args {
+			qualifyTemplateRefs(arg, prefix, defined)
+		}
+	case *parse.ChainNode:
+		qualifyTemplateRefs(n.Node, prefix, defined)
+	}
+}
+
+func qualifyBranchTemplateRefs(n *parse.BranchNode, prefix string, defined map[string]*parse.Tree) {
+	qualifyTemplateRefs(n.Pipe, prefix, defined)
+	qualifyTemplateRefs(n.List, prefix, defined)
+	qualifyTemplateRefs(n.ElseList, prefix, defined)
+}
+
+// getTemplateLibrary returns template library of rc, nil if not set
+func getTemplateLibrary(rc dukkha.RenderingContext) *TemplateLibrary {
+	lg, ok := rc.(di.TemplateLibraryGetter)
+	if !ok {
+		return nil
+	}
+
+	lib, _ := lg.TemplateLibrary().(*TemplateLibrary)
+	return lib
+}
@@ -0,0 +1,68 @@
+package templateutils
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+)
+
+func TestAddTemplateLibrary(t *testing.T) {
+	rc := dt.NewTestContext(context.TODO())
+
+	assert.NoError(t, AddTemplateLibrary(rc, "k8s",
+		`{{- define "labels" -}} app: {{ . }} {{- end -}}`,
+		`{{- define "metadata" -}} {{ include "k8s.labels" . }} {{- end -}}`,
+		// references in the same library can omit namespace
+		`{{- define "selector" -}} {{ template "labels" . }} {{- end -}}`,
+		`{{- define "spec" -}} {{ if . }}{{ include "selector" . | toUpper }}{{ end }} {{- end -}}`,
+	))
+	assert.NoError(t, AddTemplateLibrary(rc, "", `ignored {{- define "greet" -}} hello {{ . }} {{- end -}}`))
+
+	execute := func(t *testing.T, text string) string {
+		tpl, err := CreateTemplate(rc).Parse(text)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+
+		var buf strings.Builder
+		assert.NoError(t, tpl.Execute(&buf, "foo"))
+		return buf.String()
+	}
+
+	assert.Equal(t, "app: foo", execute(t, `{{ include "k8s.labels" . }}`))
+	assert.Equal(t, "app: foo", execute(t, `{{ template "k8s.metadata" . }}`))
+	assert.Equal(t, "hello foo", execute(t, `{{ template "greet" . }}`))
+	assert.Equal(t, "app: foo", execute(t, `{{ template "k8s.selector" . }}`))
+	assert.Equal(t, "APP: FOO", execute(t, `{{ include "k8s.spec" . }}`))
+
+	// unqualified name is not available in namespaced library
+	tpl, err := CreateTemplate(rc).Parse(`{{ template "labels" . }}`)
+	if assert.NoError(t, err) {
+		assert.Error(t, tpl.Execute(&strings.Builder{}, nil))
+	}
+
+	// later definitions override existing ones
+	assert.NoError(t, AddTemplateLibrary(rc, "", `{{- define "greet" -}} hi {{ . }} {{- end -}}`))
+	assert.Equal(t, "hi foo", execute(t, `{{ template "greet" . }}`))
+
+	t.Run("Derived Context", func(t *testing.T) {
+		tpl, err := CreateTemplate(rc.DeriveNew()).Parse(`{{ include "k8s.labels" "bar" }}`)
+		if assert.NoError(t, err) {
+			var buf strings.Builder
+			assert.NoError(t, tpl.Execute(&buf, nil))
+			assert.Equal(t, "app: bar", buf.String())
+		}
+	})
+
+	t.Run("Eval Template", func(t *testing.T) {
+		ret, err := createEvalNS(rc).Template(`{{ include "k8s.labels" "baz" }}`)
+		assert.NoError(t, err)
+		assert.Equal(t, "app: baz", ret)
+	})
+
+	assert.Error(t, AddTemplateLibrary(rc, "", `{{ define "bad" }}`))
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"os"
+	"strings"
 
 	"arhat.dev/pkg/md5helper"
 	"arhat.dev/pkg/textquery"
@@ -32,13 +33,26 @@ func RegisterTemplateFuncs(fm map[string]TemplateFuncFactory) {
 	}
 }
 
+// CreateTemplate creates a new template with all template funcs and
+// templates in the template library of rc
 func CreateTemplate(rc dukkha.RenderingContext) *template.Template {
+	tpl := newTemplate(rc)
+
+	if lib := getTemplateLibrary(rc); lib != nil {
+		lib.addTo(tpl)
+	}
+
+	return tpl
+}
+
+func newTemplate(rc dukkha.RenderingContext) *template.Template {
 	fm := make(map[string]interface{})
 	for k, createTemplateFunc := range toolSpecificTemplateFuncs {
 		fm[k] = createTemplateFunc(rc)
 	}
 
-	return template.New("tpl").
+	tpl := template.New("tpl")
+	return tpl.
 		// template func from sprig
 		Funcs(template.FuncMap(sprig.TxtFuncMap())).
 		// template func from gomplate
@@ -164,6 +178,9 @@ func CreateTemplate(rc dukkha.RenderingContext) *template.Template {
 
 			"totp": totpTemplateFunc,
 
+			// include like helm include
+			"include": createIncludeFunc(tpl),
+
 			"appendFile": func(filename string, data []byte) error {
 				f, err := rc.FS().OpenFile(filename, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0640)
 				if err != nil {
@@ -218,9 +235,26 @@ func CreateTemplate(rc dukkha.RenderingContext) *template.Template {
 		// placeholder functions to be overridden before Execute
 		Funcs(map[string]interface{}{
 			"var": func() map[string]interface{} { return nil },
-			// include like helm include
-			"include": func(name string, data interface{}) (string, error) {
-				return "", fmt.Errorf("no implementation")
-			},
 		})
 }
+
+// createIncludeFunc creates template func include executing named template
+// defined in tpl
+func createIncludeFunc(tpl *template.Template) func(name string, data interface{}) (string, error) {
+	// prevent infinite loop in template include
+	const maxIncludeCount = 1000
+	includedCount := make(map[string]int)
+
+	return func(name string, data interface{}) (string, error) {
+		if includedCount[name] >= maxIncludeCount {
+			return "", fmt.Errorf("too many include of %q", name)
+		}
+
+		includedCount[name]++
+		defer func() { includedCount[name]-- }()
+
+		var buf strings.Builder
+		err := tpl.ExecuteTemplate(&buf, name, data)
+		return buf.String(), err
+	}
+}
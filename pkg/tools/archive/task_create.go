@@ -7,6 +7,7 @@ import (
 
 	"arhat.dev/rs"
 
+	"arhat.dev/dukkha/pkg/compression"
 	"arhat.dev/dukkha/pkg/constant"
 	"arhat.dev/dukkha/pkg/dukkha"
 	"arhat.dev/dukkha/pkg/tools"
@@ -149,7 +150,7 @@ func (c *TaskCreate) GetExecSpecs(
 					var tarball io.WriteCloser = out
 
 					if method != nil {
-						tarball, err = createCompressionStream(out, *method, level)
+						tarball, err = compression.NewWriter(out, *method, level)
 						if err != nil {
 							return nil, err
 						}
@@ -15,6 +15,7 @@ import (
 
 	"arhat.dev/pkg/fshelper"
 
+	"arhat.dev/dukkha/pkg/compression"
 	"arhat.dev/dukkha/pkg/constant"
 )
 
@@ -31,7 +32,7 @@ func createZip(
 	if compressionMethod != nil {
 		switch method := *compressionMethod; method {
 		case constant.CompressionMethod_DEFLATE:
-			level, err := parseFlateCompressionLevel(compressionLevel)
+			level, err := compression.ParseFlateLevel(compressionLevel)
 			if err != nil {
 				return err
 			}
@@ -41,7 +42,7 @@ func createZip(
 				return flate.NewWriter(w, level)
 			})
 		case constant.CompressionMethod_Bzip2:
-			level, err := parseBzip2CompresssionLevel(compressionLevel)
+			level, err := compression.ParseBzip2Level(compressionLevel)
 			if err != nil {
 				return err
 			}
@@ -58,7 +59,7 @@ func createZip(
 				return lzma.WriterConfig{}.NewWriter(w)
 			})
 		case constant.CompressionMethod_ZSTD:
-			level, err := parseZstdCompressionLevel(compressionLevel)
+			level, err := compression.ParseZstdLevel(compressionLevel)
 			if err != nil {
 				return err
 			}
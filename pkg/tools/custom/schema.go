@@ -0,0 +1,169 @@
+package custom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+const (
+	schemaDefPrefix = "arhat.dev.dukkha.pkg.tools.custom."
+	patchSpecRef    = "#/definitions/PatchSpec"
+)
+
+// ExtendJSONSchema adds registered custom tool kinds and task kinds to json
+// schema of dukkha config (docs/generated/schema.json), task fields are
+// generated from declared field specs
+func ExtendJSONSchema(schemaJSON []byte) ([]byte, error) {
+	var scm map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(schemaJSON))
+	dec.UseNumber()
+	err := dec.Decode(&scm)
+	if err != nil {
+		return nil, fmt.Errorf("invalid json schema: %w", err)
+	}
+
+	defs, _ := scm["definitions"].(map[string]interface{})
+	top, _ := defs["Schema"].(map[string]interface{})
+	taskDef, _ := defs[schemaDefPrefix+"Task"].(map[string]interface{})
+	if top == nil || taskDef == nil {
+		return nil, fmt.Errorf("invalid json schema: missing definitions of config or custom task")
+	}
+
+	specsMu.RLock()
+	defer specsMu.RUnlock()
+
+	toolKinds := make([]string, 0, len(specs))
+	for k := range specs {
+		toolKinds = append(toolKinds, string(k))
+	}
+	sort.Strings(toolKinds)
+
+	for _, toolKind := range toolKinds {
+		s := specs[dukkha.ToolKind(toolKind)]
+
+		toolsDef := objectOf(objectOf(top, "properties"), "tools")
+		objectOf(toolsDef, "properties")[toolKind] = arrayOf(schemaDefPrefix + "Tool")
+		if toolsDef, ok := objectOf(top, "patternProperties")["^tools@.*"].(map[string]interface{}); ok {
+			objectOf(toolsDef, "properties")[toolKind] = arrayOf(schemaDefPrefix + "Tool")
+		}
+
+		taskKinds := make([]string, 0, len(s.tasks))
+		for k := range s.tasks {
+			taskKinds = append(taskKinds, string(k))
+		}
+		sort.Strings(taskKinds)
+
+		for _, taskKind := range taskKinds {
+			name := toolKind + ":" + taskKind
+			defName := schemaDefPrefix + "Task." + name
+			defs[defName] = s.tasks[dukkha.TaskKind(taskKind)].jsonSchema(taskDef, name)
+
+			objectOf(top, "properties")[name] = arrayOf(defName)
+
+			patterns := objectOf(top, "patternProperties")
+			patterns[fmt.Sprintf(`^%s(:.+){0,1}:%s$`, toolKind, taskKind)] = arrayOf(defName)
+			patterns[fmt.Sprintf(`^%s@.*`, name)] = arrayOf(defName)
+			patterns[fmt.Sprintf(`^%s@[^\|]*!`, name)] = map[string]interface{}{"$ref": patchSpecRef}
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	err = enc.Encode(scm)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// jsonSchema generates definition of the task kind by adding declared fields
+// to base definition of custom tasks
+func (s *TaskSpec) jsonSchema(base map[string]interface{}, name string) map[string]interface{} {
+	def := deepCopyJSON(base).(map[string]interface{})
+	def["description"] = "custom task " + name
+	def["x-intellij-html-description"] = "custom task " + name
+
+	names := make([]string, 0, len(s.Fields))
+	for n := range s.Fields {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	order, _ := def["preferredOrder"].([]interface{})
+	for _, n := range names {
+		f := s.Fields[n]
+
+		prop := make(map[string]interface{})
+		switch f.Type {
+		case "", "any":
+		default:
+			prop["type"] = f.Type
+		}
+
+		if len(f.Description) != 0 {
+			prop["description"] = f.Description
+			prop["x-intellij-html-description"] = f.Description
+		}
+
+		if f.Default != nil {
+			prop["default"] = f.Default
+		}
+
+		// required fields are not enforced, they can be set with rendering suffix
+		objectOf(def, "properties")[n] = prop
+		objectOf(def, "patternProperties")[fmt.Sprintf(`^%s@.*`, n)] = prop
+		objectOf(def, "patternProperties")[fmt.Sprintf(`^%s@[^\|]*!`, n)] = map[string]interface{}{
+			"$ref": patchSpecRef,
+		}
+
+		order = append(order, n)
+	}
+
+	def["preferredOrder"] = order
+	return def
+}
+
+// objectOf returns m[key] as json object, creates one if not exists
+func objectOf(m map[string]interface{}, key string) map[string]interface{} {
+	ret, ok := m[key].(map[string]interface{})
+	if !ok {
+		ret = make(map[string]interface{})
+		m[key] = ret
+	}
+
+	return ret
+}
+
+// arrayOf creates json schema of array with items of definition defName
+func arrayOf(defName string) map[string]interface{} {
+	return map[string]interface{}{
+		"items": map[string]interface{}{"$ref": "#/definitions/" + defName},
+		"type":  "array",
+	}
+}
+
+func deepCopyJSON(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			ret[k] = deepCopyJSON(e)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(t))
+		for i, e := range t {
+			ret[i] = deepCopyJSON(e)
+		}
+		return ret
+	default:
+		return v
+	}
+}
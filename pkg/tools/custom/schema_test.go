@@ -0,0 +1,91 @@
+package custom
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"arhat.dev/pkg/rshelper"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"arhat.dev/dukkha/docs"
+)
+
+func TestExtendJSONSchema(t *testing.T) {
+	spec := rshelper.InitAll(&ToolSpec{}, nil).(*ToolSpec)
+	if !assert.NoError(t, yaml.Unmarshal([]byte(testToolSpec), spec)) {
+		return
+	}
+
+	if !assert.NoError(t, Register([]*ToolSpec{spec})) {
+		return
+	}
+
+	schemaJSON, err := ExtendJSONSchema(docs.Schema)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	c := jsonschema.NewCompiler()
+	c.Draft = jsonschema.Draft7
+	if !assert.NoError(t, c.AddResource("schema.json", bytes.NewReader(schemaJSON))) {
+		return
+	}
+
+	scm, err := c.Compile("schema.json")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	validate := func(t *testing.T, config string) error {
+		var v interface{}
+		if !assert.NoError(t, yaml.Unmarshal([]byte(config), &v)) {
+			return nil
+		}
+
+		data, err := json.Marshal(v)
+		if !assert.NoError(t, err) {
+			return nil
+		}
+
+		assert.NoError(t, json.Unmarshal(data, &v))
+		return scm.Validate(v)
+	}
+
+	assert.NoError(t, validate(t, `
+tools:
+  test-custom:
+  - name: local
+  golang:
+  - name: local
+
+test-custom:build:
+- name: foo
+  package@env: ${PACKAGE:-foo}
+  release: true
+  features: [a, b]
+  matrix:
+    kernel: [linux]
+
+test-custom:local:build:
+- name: bar
+  package: bar
+
+golang:build:
+- name: foo
+`))
+
+	for name, config := range map[string]string{
+		"Unknown Field":   "test-custom:build: [{ name: foo, unknown: true }]",
+		"Invalid Type":    "test-custom:build: [{ name: foo, release: yes-please }]",
+		"Unknown Tool":    "tools: { not-declared: [{ name: foo }] }",
+		"Unknown Task":    "test-custom:test: [{ name: foo }]",
+		"Invalid Builtin": "golang:build: [{ name: foo, package: foo }]",
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, validate(t, config))
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package custom
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+)
+
+// ToolSpec declares a custom tool kind and its task kinds
+type ToolSpec struct {
+	rs.BaseField `yaml:"-"`
+
+	// Kind of the tool, e.g. `cargo`
+	Kind dukkha.ToolKind `yaml:"kind"`
+
+	// Executable is the default executable of this tool when `cmd` is not set
+	// in tool config
+	//
+	// defaults to the tool kind
+	Executable string `yaml:"executable"`
+
+	// Tasks supported by this tool kind
+	Tasks []*TaskSpec `yaml:"tasks"`
+}
+
+// TaskSpec declares a custom task kind
+type TaskSpec struct {
+	rs.BaseField `yaml:"-"`
+
+	// Kind of the task, e.g. `build`
+	Kind dukkha.TaskKind `yaml:"kind"`
+
+	// Fields of the task, in addition to common task fields (e.g. `env`, `matrix`)
+	//
+	// field name -> field spec
+	Fields map[string]*FieldSpec `yaml:"fields"`
+
+	// Steps to run for each task matrix
+	Steps []*StepSpec `yaml:"steps"`
+}
+
+// FieldSpec is the schema of a custom task field
+type FieldSpec struct {
+	rs.BaseField `yaml:"-"`
+
+	// Type of the field value, one of
+	// [string, boolean, integer, number, array, object, any]
+	//
+	// defaults to `any`
+	Type string `yaml:"type"`
+
+	// Required to report error when this field is not set
+	Required bool `yaml:"required"`
+
+	// Default value of this field
+	Default *rs.AnyObject `yaml:"default"`
+
+	// Description of this field
+	Description string `yaml:"description"`
+}
+
+// StepSpec is the template to generate a task exec spec
+//
+// all string values are golang templates, task field values are available
+// as `var.<field-name>`
+type StepSpec struct {
+	rs.BaseField `yaml:"-"`
+
+	// Args to the tool executable, args evaluated as empty string are dropped
+	Args []string `yaml:"args"`
+
+	// Chdir to run this step
+	Chdir string `yaml:"chdir"`
+
+	// IgnoreError to continue following steps when this step failed
+	IgnoreError bool `yaml:"ignore_error"`
+}
+
+var (
+	toolType = reflect.TypeOf((*dukkha.Tool)(nil)).Elem()
+	taskType = reflect.TypeOf((*dukkha.Task)(nil)).Elem()
+)
+
+var (
+	specs   = make(map[dukkha.ToolKind]*toolSpecs)
+	specsMu sync.RWMutex
+)
+
+type toolSpecs struct {
+	spec  *ToolSpec
+	tasks map[dukkha.TaskKind]*TaskSpec
+}
+
+// Register custom tool kinds and their task kinds
+//
+// custom tool kinds can be registered multiple times, last registered spec
+// is effective, but they cannot override built-in tool kinds
+func Register(toolSpecList []*ToolSpec) error {
+	specsMu.Lock()
+	defer specsMu.Unlock()
+
+	for _, spec := range toolSpecList {
+		err := register(spec)
+		if err != nil {
+			return fmt.Errorf("custom tool %q: %w", spec.Kind, err)
+		}
+	}
+
+	return nil
+}
+
+func register(spec *ToolSpec) error {
+	if len(spec.Kind) == 0 {
+		return fmt.Errorf("invalid empty tool kind")
+	}
+
+	if strings.Contains(string(spec.Kind), ":") {
+		return fmt.Errorf("invalid tool kind containing `:`")
+	}
+
+	existing, isCustom := specs[spec.Kind]
+	if !isCustom {
+		if dukkha.IsRegistered(toolType, string(spec.Kind)) {
+			return fmt.Errorf("conflict with built-in tool kind")
+		}
+
+		existing = &toolSpecs{}
+		specs[spec.Kind] = existing
+
+		toolKind := spec.Kind
+		dukkha.RegisterTool(toolKind, func() dukkha.Tool {
+			return &Tool{toolKind: toolKind}
+		})
+	}
+
+	tasks := make(map[dukkha.TaskKind]*TaskSpec, len(spec.Tasks))
+	for _, tsk := range spec.Tasks {
+		if len(tsk.Kind) == 0 {
+			return fmt.Errorf("invalid empty task kind")
+		}
+
+		if strings.Contains(string(tsk.Kind), ":") {
+			return fmt.Errorf("invalid task kind %q containing `:`", tsk.Kind)
+		}
+
+		if _, ok := tasks[tsk.Kind]; ok {
+			return fmt.Errorf("duplicate task kind %q", tsk.Kind)
+		}
+
+		for name, f := range tsk.Fields {
+			switch name {
+			case "name", "env", "matrix", "hooks", "continue_on_error", "cache":
+				return fmt.Errorf("task %q: field %q conflicts with common task field", tsk.Kind, name)
+			}
+
+			switch f.Type {
+			case "", "any", "string", "boolean", "integer", "number", "array", "object":
+			default:
+				return fmt.Errorf("task %q: invalid type %q of field %q", tsk.Kind, f.Type, name)
+			}
+		}
+
+		tasks[tsk.Kind] = tsk
+
+		// task kind may be registered by previous spec
+		if dukkha.IsRegistered(taskType, string(spec.Kind)+":"+string(tsk.Kind)) {
+			continue
+		}
+
+		toolKind, taskKind := spec.Kind, tsk.Kind
+		dukkha.RegisterTask(toolKind, taskKind, func(toolName string) dukkha.Task {
+			t := &Task{toolKind: toolKind, taskKind: taskKind}
+			t.InitBaseTask(toolKind, dukkha.ToolName(toolName), t)
+			return t
+		})
+	}
+
+	existing.spec = spec
+	existing.tasks = tasks
+
+	return nil
+}
+
+func getToolSpec(k dukkha.ToolKind) (*ToolSpec, bool) {
+	specsMu.RLock()
+	defer specsMu.RUnlock()
+
+	s, ok := specs[k]
+	if !ok {
+		return nil, false
+	}
+
+	return s.spec, true
+}
+
+func getTaskSpec(k dukkha.ToolKind, tk dukkha.TaskKind) (*TaskSpec, bool) {
+	specsMu.RLock()
+	defer specsMu.RUnlock()
+
+	s, ok := specs[k]
+	if !ok {
+		return nil, false
+	}
+
+	ts, ok := s.tasks[tk]
+	return ts, ok
+}
@@ -0,0 +1,211 @@
+package custom
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/templateutils"
+	"arhat.dev/dukkha/pkg/tools"
+)
+
+// Task of custom kind declared in config
+type Task struct {
+	rs.BaseField `yaml:"-"`
+
+	TaskName string `yaml:"name"`
+
+	tools.BaseTask `yaml:",inline"`
+
+	// Fields are custom task fields as declared in task spec
+	Fields map[string]*rs.AnyObject `yaml:",inline"`
+
+	toolKind dukkha.ToolKind
+	taskKind dukkha.TaskKind
+}
+
+func (c *Task) Kind() dukkha.TaskKind { return c.taskKind }
+func (c *Task) Name() dukkha.TaskName { return dukkha.TaskName(c.TaskName) }
+func (c *Task) Key() dukkha.TaskKey {
+	return dukkha.TaskKey{Kind: c.Kind(), Name: c.Name()}
+}
+
+func (c *Task) GetExecSpecs(
+	rc dukkha.TaskExecContext, options dukkha.TaskMatrixExecOptions,
+) ([]dukkha.TaskExecSpec, error) {
+	spec, ok := getTaskSpec(c.toolKind, c.taskKind)
+	if !ok {
+		return nil, fmt.Errorf("custom task kind %s:%s not declared", c.toolKind, c.taskKind)
+	}
+
+	var steps []dukkha.TaskExecSpec
+	err := c.DoAfterFieldsResolved(rc, -1, true, func() error {
+		values, err := spec.values(rc, c.Fields)
+		if err != nil {
+			return err
+		}
+
+		for i, s := range spec.Steps {
+			step, err := s.genSpec(rc, values)
+			if err != nil {
+				return fmt.Errorf("generating step #%d: %w", i, err)
+			}
+
+			steps = append(steps, *step)
+		}
+
+		return nil
+	})
+
+	return steps, err
+}
+
+// values validates fields of a task and returns values of all declared fields
+// with default values applied
+func (s *TaskSpec) values(
+	rc dukkha.RenderingContext,
+	fields map[string]*rs.AnyObject,
+) (map[string]interface{}, error) {
+	for name := range fields {
+		if _, ok := s.Fields[name]; !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+	}
+
+	names := make([]string, 0, len(s.Fields))
+	for name := range s.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ret := make(map[string]interface{}, len(s.Fields))
+	for _, name := range names {
+		f := s.Fields[name]
+
+		v, ok := fields[name]
+		if !ok {
+			if f.Required {
+				return nil, fmt.Errorf("required field %q not set", name)
+			}
+
+			if f.Default == nil {
+				ret[name] = nil
+				continue
+			}
+
+			err := f.Default.ResolveFields(rc, -1)
+			if err != nil {
+				return nil, fmt.Errorf("resolving default value of field %q: %w", name, err)
+			}
+
+			v = f.Default
+		}
+
+		val := v.NormalizedValue()
+		if !checkType(f.Type, val) {
+			return nil, fmt.Errorf("invalid value of field %q: want %s, got %T", name, f.Type, val)
+		}
+
+		ret[name] = val
+	}
+
+	return ret, nil
+}
+
+func (s *StepSpec) genSpec(
+	rc dukkha.RenderingContext,
+	values map[string]interface{},
+) (*dukkha.TaskExecSpec, error) {
+	ret := &dukkha.TaskExecSpec{
+		Command:     []string{constant.DUKKHA_TOOL_CMD},
+		IgnoreError: s.IgnoreError,
+	}
+
+	for _, arg := range s.Args {
+		v, err := executeTemplate(rc, values, arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(v) == 0 {
+			continue
+		}
+
+		ret.Command = append(ret.Command, v)
+	}
+
+	if len(s.Chdir) != 0 {
+		v, err := executeTemplate(rc, values, s.Chdir)
+		if err != nil {
+			return nil, err
+		}
+
+		ret.Chdir = v
+	}
+
+	return ret, nil
+}
+
+func executeTemplate(
+	rc dukkha.RenderingContext,
+	values map[string]interface{},
+	tplStr string,
+) (string, error) {
+	tpl, err := templateutils.CreateTemplate(rc).Parse(tplStr)
+	if err != nil {
+		return "", fmt.Errorf("parsing template %q: %w", tplStr, err)
+	}
+
+	tpl.Funcs(map[string]interface{}{
+		"var": func() map[string]interface{} {
+			return values
+		},
+	})
+
+	buf := &bytes.Buffer{}
+	err = tpl.Execute(buf, rc)
+	if err != nil {
+		return "", fmt.Errorf("executing template %q: %w", tplStr, err)
+	}
+
+	return buf.String(), nil
+}
+
+func checkType(typ string, v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	switch typ {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "integer":
+		switch v.(type) {
+		case int, int64, uint64:
+			return true
+		}
+		return false
+	case "number":
+		switch v.(type) {
+		case int, int64, uint64, float64:
+			return true
+		}
+		return false
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	default:
+		return true
+	}
+}
@@ -0,0 +1,130 @@
+package custom
+
+import (
+	"context"
+	"testing"
+
+	"arhat.dev/pkg/rshelper"
+	"arhat.dev/rs"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	di "arhat.dev/dukkha/internal"
+	"arhat.dev/dukkha/pkg/constant"
+	"arhat.dev/dukkha/pkg/dukkha"
+	dukkha_test "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/tools/tests"
+
+	_ "arhat.dev/dukkha/pkg/tools/golang"
+)
+
+const testToolSpec = `
+kind: test-custom
+executable: cargo
+tasks:
+- kind: build
+  fields:
+    release:
+      type: boolean
+      default: false
+    features:
+      type: array
+    package:
+      type: string
+      required: true
+  steps:
+  - args:
+    - build
+    - --package={{ var.package }}
+    - '{{ if var.release }}--release{{ end }}'
+    - '{{ if var.features }}--features={{ join var.features "," }}{{ end }}'
+  - chdir: '{{ var.package }}'
+    args: [test]
+    ignore_error: true
+`
+
+func TestTask_GetExecSpecs(t *testing.T) {
+	spec := rshelper.InitAll(&ToolSpec{}, nil).(*ToolSpec)
+	if !assert.NoError(t, yaml.Unmarshal([]byte(testToolSpec), spec)) {
+		return
+	}
+
+	if !assert.NoError(t, Register([]*ToolSpec{spec})) {
+		return
+	}
+
+	// register again to update spec
+	assert.NoError(t, Register([]*ToolSpec{spec}))
+
+	newTask := func(fields map[string]string) *Task {
+		ret := &Task{
+			TaskName: "foo",
+			toolKind: "test-custom",
+			taskKind: "build",
+			Fields:   make(map[string]*rs.AnyObject),
+		}
+
+		for k, v := range fields {
+			obj := rs.Init(&rs.AnyObject{}, nil).(*rs.AnyObject)
+			assert.NoError(t, yaml.Unmarshal([]byte(v), obj))
+			ret.Fields[k] = obj
+		}
+
+		return ret
+	}
+
+	testCases := []tests.ExecSpecGenerationTestCase{
+		{
+			Name:    "Defaults",
+			Task:    newTask(map[string]string{"package": "a"}),
+			Options: dukkha_test.CreateTaskMatrixExecOptions(),
+			Expected: []dukkha.TaskExecSpec{
+				{Command: []string{constant.DUKKHA_TOOL_CMD, "build", "--package=a"}},
+				{Command: []string{constant.DUKKHA_TOOL_CMD, "test"}, Chdir: "a", IgnoreError: true},
+			},
+		},
+		{
+			Name: "All Fields",
+			Task: newTask(map[string]string{
+				"package":  "a",
+				"release":  "true",
+				"features": "[x, y]",
+			}),
+			Options: dukkha_test.CreateTaskMatrixExecOptions(),
+			Expected: []dukkha.TaskExecSpec{
+				{Command: []string{constant.DUKKHA_TOOL_CMD, "build", "--package=a", "--release", "--features=x,y"}},
+				{Command: []string{constant.DUKKHA_TOOL_CMD, "test"}, Chdir: "a", IgnoreError: true},
+			},
+		},
+		{
+			Name:      "Missing Required",
+			Task:      newTask(nil),
+			Options:   dukkha_test.CreateTaskMatrixExecOptions(),
+			ExpectErr: true,
+		},
+		{
+			Name:      "Invalid Type",
+			Task:      newTask(map[string]string{"package": "a", "release": "[]"}),
+			Options:   dukkha_test.CreateTaskMatrixExecOptions(),
+			ExpectErr: true,
+		},
+		{
+			Name:      "Unknown Field",
+			Task:      newTask(map[string]string{"package": "a", "foo": "bar"}),
+			Options:   dukkha_test.CreateTaskMatrixExecOptions(),
+			ExpectErr: true,
+		},
+	}
+
+	ctx := dukkha_test.NewTestContext(context.TODO())
+	ctx.(di.CacheDirSetter).SetCacheDir(t.TempDir())
+
+	tests.RunTaskExecSpecGenerationTests(t, ctx, testCases)
+}
+
+func TestRegister(t *testing.T) {
+	spec := rshelper.InitAll(&ToolSpec{}, nil).(*ToolSpec)
+	assert.NoError(t, yaml.Unmarshal([]byte(`{kind: golang}`), spec))
+
+	assert.Error(t, Register([]*ToolSpec{spec}), "should not override built-in tool kind")
+}
@@ -0,0 +1,42 @@
+package custom
+
+import (
+	"fmt"
+
+	"arhat.dev/pkg/fshelper"
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/tools"
+)
+
+// Tool of custom kind declared in config
+type Tool struct {
+	rs.BaseField `yaml:"-"`
+
+	ToolName dukkha.ToolName `yaml:"name"`
+
+	tools.BaseTool `yaml:",inline"`
+
+	toolKind dukkha.ToolKind
+}
+
+func (t *Tool) Init(cacheFS *fshelper.OSFS) error {
+	spec, ok := getToolSpec(t.toolKind)
+	if !ok {
+		return fmt.Errorf("custom tool kind %q not declared", t.toolKind)
+	}
+
+	executable := spec.Executable
+	if len(executable) == 0 {
+		executable = string(t.toolKind)
+	}
+
+	return t.InitBaseTool(executable, cacheFS, t)
+}
+
+func (t *Tool) Name() dukkha.ToolName { return t.ToolName }
+func (t *Tool) Kind() dukkha.ToolKind { return t.toolKind }
+func (t *Tool) Key() dukkha.ToolKey {
+	return dukkha.ToolKey{Kind: t.Kind(), Name: t.Name()}
+}
@@ -120,6 +120,16 @@ func doRun(
 			stderr,
 		)
 
+		// mask secret values (e.g. decrypted by renderer `secret`)
+		stdoutMasking := output.NewMaskingWriter(stdout)
+		stderrMasking := output.NewMaskingWriter(stderr)
+		stdout, stderr = stdoutMasking, stderrMasking
+
+		flushMasking := func() {
+			_ = stdoutMasking.Flush()
+			_ = stderrMasking.Flush()
+		}
+
 		var (
 			stdoutBuf *bytes.Buffer
 			stderrBuf *bytes.Buffer
@@ -175,6 +185,7 @@ func doRun(
 			ctx.SetState(dukkha.TaskExecWorking)
 
 			subSpecs, err := es.AlterExecFunc(replace, stdin, stdout, stderr)
+			flushMasking()
 			setReplaceEntry(err)
 			if err != nil {
 				ctx.SetState(dukkha.TaskExecFailed)
@@ -322,6 +333,7 @@ func doRun(
 		}
 
 		_, err = p.Wait()
+		flushMasking()
 		setReplaceEntry(err)
 
 		if err != nil {
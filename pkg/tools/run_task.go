@@ -241,7 +241,9 @@ matrixRun:
 				return
 			}
 
-			err3 = doRun(mCtx, toolMatrixCmd, execSpecs, nil)
+			err3 = req.Task.RunWithOutputCache(mCtx, ms, execSpecs, func() error {
+				return doRun(mCtx, toolMatrixCmd, execSpecs, nil)
+			})
 
 			output.WriteExecResult(mCtx.PrefixColor(),
 				mCtx.CurrentTool(), mCtx.CurrentTask(),
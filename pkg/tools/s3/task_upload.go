@@ -0,0 +1,154 @@
+package tool_s3
+
+import (
+	"fmt"
+	"io"
+
+	"arhat.dev/rs"
+
+	"arhat.dev/dukkha/pkg/dukkha"
+	s3renderer "arhat.dev/dukkha/pkg/renderer/s3"
+	"arhat.dev/dukkha/pkg/tools"
+	"arhat.dev/dukkha/pkg/utils"
+)
+
+const TaskKindUpload = "upload"
+
+func init() {
+	dukkha.RegisterTask(ToolKind, TaskKindUpload, newUploadTask)
+}
+
+func newUploadTask(toolName string) dukkha.Task {
+	t := &TaskUpload{}
+	t.InitBaseTask(ToolKind, dukkha.ToolName(toolName), t)
+	return t
+}
+
+type TaskUpload struct {
+	rs.BaseField `yaml:"-"`
+
+	TaskName string `yaml:"name"`
+
+	tools.BaseTask `yaml:",inline"`
+
+	// Config of the s3 service, same as the config of s3 renderer
+	Config s3renderer.Config `yaml:"config"`
+
+	// Files to upload
+	Files []*uploadFileSpec `yaml:"files"`
+
+	// Checksums to calculate for each file, one or more of [md5, sha1, sha256, sha512]
+	//
+	// checksums are set as object metadata `X-Amz-Meta-<Algorithm>` (e.g. `X-Amz-Meta-Sha256`)
+	Checksums []string `yaml:"checksums"`
+
+	// ChecksumFiles uploads checksum files along with each object, named as
+	// `<object-path>.<algorithm>` (e.g. `foo.tar.gz.sha256`)
+	//
+	// Defaults to `false`
+	ChecksumFiles bool `yaml:"checksum_files"`
+
+	// Multipart upload configuration
+	Multipart multipartSpec `yaml:"multipart"`
+}
+
+type uploadFileSpec struct {
+	rs.BaseField
+
+	// From local file path, glob pattern is supported
+	From string `yaml:"from"`
+
+	// To is the object path relative to base_path of the config
+	//
+	// it is treated as a directory when ends with `/` or `from` matched
+	// multiple files, files are uploaded as `<to>/<file-name>`
+	//
+	// Defaults to the file name of the matched file
+	To string `yaml:"to"`
+
+	// ContentType of the object
+	//
+	// Defaults to the mime type detected by file extension,
+	// `application/octet-stream` when unknown
+	ContentType string `yaml:"content_type"`
+
+	// Metadata of the object, set as `X-Amz-Meta-<Key>`
+	Metadata map[string]string `yaml:"metadata"`
+}
+
+type multipartSpec struct {
+	rs.BaseField
+
+	// Disabled multipart upload, file is uploaded in one request
+	//
+	// Defaults to `false`
+	Disabled bool `yaml:"disabled"`
+
+	// PartSize is the size of each part, MUST be at least 5Mi
+	//
+	// Defaults to `16Mi`
+	PartSize utils.Size `yaml:"part_size"`
+
+	// Concurrency of part uploading
+	//
+	// Defaults to `4`
+	Concurrency uint `yaml:"concurrency"`
+}
+
+func (c *TaskUpload) Kind() dukkha.TaskKind { return TaskKindUpload }
+func (c *TaskUpload) Name() dukkha.TaskName { return dukkha.TaskName(c.TaskName) }
+func (c *TaskUpload) Key() dukkha.TaskKey {
+	return dukkha.TaskKey{Kind: c.Kind(), Name: c.Name()}
+}
+
+func (c *TaskUpload) GetExecSpecs(
+	rc dukkha.TaskExecContext, options dukkha.TaskMatrixExecOptions,
+) ([]dukkha.TaskExecSpec, error) {
+	var steps []dukkha.TaskExecSpec
+
+	err := c.DoAfterFieldsResolved(rc, -1, true, func() error {
+		for _, algo := range c.Checksums {
+			if _, ok := checksumAlgorithms[algo]; !ok {
+				return fmt.Errorf("unsupported checksum algorithm %q", algo)
+			}
+		}
+
+		files, err := collectFiles(rc.FS(), c.Files)
+		if err != nil {
+			return err
+		}
+
+		var (
+			config        = c.Config
+			checksums     = c.Checksums
+			checksumFiles = c.ChecksumFiles
+			multipart     = c.Multipart
+		)
+
+		steps = append(steps, dukkha.TaskExecSpec{
+			AlterExecFunc: func(
+				replace dukkha.ReplaceEntries,
+				stdin io.Reader,
+				stdout, stderr io.Writer,
+			) (dukkha.RunTaskOrRunCmd, error) {
+				client, err := config.CreateClient()
+				if err != nil {
+					return nil, err
+				}
+
+				for _, f := range files {
+					err = uploadFile(rc, rc.FS(), client, f, checksums, checksumFiles, &multipart)
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				return nil, nil
+			},
+		})
+
+		return nil
+	})
+
+	return steps, err
+}
@@ -0,0 +1,136 @@
+package tool_s3
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"arhat.dev/rs"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	di "arhat.dev/dukkha/internal"
+	"arhat.dev/dukkha/pkg/dukkha"
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+	st "arhat.dev/dukkha/pkg/renderer/s3/test"
+	"arhat.dev/dukkha/pkg/tools"
+)
+
+func runUploadTask(t *testing.T, spec string) error {
+	ctx := dt.NewTestContext(context.TODO())
+	ctx.(di.CacheDirSetter).SetCacheDir(t.TempDir())
+
+	task := rs.Init(newUploadTask(""), nil).(*TaskUpload)
+	if !assert.NoError(t, yaml.Unmarshal([]byte(spec), task)) {
+		t.FailNow()
+	}
+
+	tool := rs.Init(&Tool{}, nil).(*Tool)
+	assert.NoError(t, tool.Init(ctx.ToolCacheFS(tool)))
+	ctx.AddTool(tool.Key(), tool)
+	assert.NoError(t, tool.AddTasks([]dukkha.Task{task}))
+
+	return tools.RunTask(&tools.TaskExecRequest{
+		Context: ctx,
+		Tool:    tool,
+		Task:    task,
+	})
+}
+
+func TestTaskUpload(t *testing.T) {
+	srv := st.NewServer(t, "test")
+	config := fmt.Sprintf(`
+config:
+  endpoint_url: %s
+  region: us-east-1
+  bucket: test
+  base_path: artifacts
+`, srv.URL)
+
+	t.Run("Upload", func(t *testing.T) {
+		assert.NoError(t, runUploadTask(t, config+`
+checksums: [md5, sha256]
+checksum_files: true
+files:
+- from: testdata/*.txt
+  to: text
+  metadata:
+    foo: bar
+- from: testdata/data.json
+  to: data/
+- from: testdata/data.json
+  to: raw
+  content_type: application/octet-stream
+`))
+
+		for key, expected := range map[string]struct {
+			data        string
+			contentType string
+			metadata    map[string]string
+		}{
+			"text/a.txt": {
+				data:        "hello\n",
+				contentType: "text/plain; charset=utf-8",
+				metadata: map[string]string{
+					"foo":    "bar",
+					"md5":    "b1946ac92492d2347c6235b4d2611184",
+					"sha256": "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+				},
+			},
+			"text/b.txt": {
+				data:        "world\n",
+				contentType: "text/plain; charset=utf-8",
+				metadata: map[string]string{
+					"foo":    "bar",
+					"md5":    "591785b794601e212b260e25925636fd",
+					"sha256": "e258d248fda94c63753607f7c4494ee0fcbe92f1a76bfdac795c9d84101eb317",
+				},
+			},
+			"text/a.txt.sha256": {
+				data:        "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03  a.txt\n",
+				contentType: "text/plain; charset=utf-8",
+				metadata:    map[string]string{},
+			},
+			"data/data.json": {
+				data:        "{\"foo\":\"bar\"}\n",
+				contentType: "application/json",
+				metadata: map[string]string{
+					"md5":    "2f8acf3fe5e5c2839a04b7677d9399b8",
+					"sha256": "226e49e13d16e5e8aa0d62e58cd63361bf097d3e2b2444aa3044334628a2e8de",
+				},
+			},
+			"raw": {
+				data:        "{\"foo\":\"bar\"}\n",
+				contentType: "application/octet-stream",
+				metadata: map[string]string{
+					"md5":    "2f8acf3fe5e5c2839a04b7677d9399b8",
+					"sha256": "226e49e13d16e5e8aa0d62e58cd63361bf097d3e2b2444aa3044334628a2e8de",
+				},
+			},
+		} {
+			obj, ok := srv.Get("artifacts/" + key)
+			if !assert.True(t, ok, key) {
+				continue
+			}
+
+			assert.Equal(t, expected.data, string(obj.Data), key)
+			assert.Equal(t, expected.contentType, obj.ContentType, key)
+			assert.EqualValues(t, expected.metadata, obj.Metadata, key)
+		}
+	})
+
+	t.Run("Invalid Checksum", func(t *testing.T) {
+		assert.Error(t, runUploadTask(t, config+`
+checksums: [crc32]
+files:
+- from: testdata/a.txt
+`))
+	})
+
+	t.Run("No Match", func(t *testing.T) {
+		assert.Error(t, runUploadTask(t, config+`
+files:
+- from: testdata/*.not-found
+`))
+	})
+}
@@ -0,0 +1,191 @@
+package tool_s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"  // nolint:gosec
+	"crypto/sha1" // nolint:gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"arhat.dev/pkg/fshelper"
+
+	s3renderer "arhat.dev/dukkha/pkg/renderer/s3"
+)
+
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+type uploadEntry struct {
+	from string
+	to   string
+
+	contentType string
+	metadata    map[string]string
+}
+
+// collectFiles to be uploaded
+func collectFiles(ofs *fshelper.OSFS, files []*uploadFileSpec) ([]*uploadEntry, error) {
+	var ret []*uploadEntry
+	for _, f := range files {
+		matches, err := ofs.Glob(f.From)
+		if err != nil {
+			matches = []string{f.From}
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no file matches pattern %q", f.From)
+		}
+
+		toDir := strings.HasSuffix(f.To, "/") || len(matches) > 1
+		for _, m := range matches {
+			info, err := ofs.Stat(m)
+			if err != nil {
+				return nil, err
+			}
+
+			if info.IsDir() {
+				return nil, fmt.Errorf("unexpected directory %q, use glob pattern to match files", m)
+			}
+
+			to := f.To
+			switch {
+			case len(to) == 0:
+				to = filepath.Base(m)
+			case toDir:
+				to = path.Join(to, filepath.Base(m))
+			}
+
+			contentType := f.ContentType
+			if len(contentType) == 0 {
+				contentType = mime.TypeByExtension(filepath.Ext(m))
+			}
+
+			if len(contentType) == 0 {
+				contentType = "application/octet-stream"
+			}
+
+			ret = append(ret, &uploadEntry{
+				from: m,
+				to:   to,
+
+				contentType: contentType,
+				metadata:    f.Metadata,
+			})
+		}
+	}
+
+	return ret, nil
+}
+
+func uploadFile(
+	ctx context.Context,
+	ofs *fshelper.OSFS,
+	client *s3renderer.Client,
+	f *uploadEntry,
+	checksums []string,
+	checksumFiles bool,
+	multipart *multipartSpec,
+) error {
+	_file, err := ofs.Open(f.from)
+	if err != nil {
+		return err
+	}
+
+	file := _file.(*os.File)
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	metadata := make(map[string]string, len(f.metadata)+len(checksums))
+	for k, v := range f.metadata {
+		metadata[k] = v
+	}
+
+	var sums map[string]string
+	if len(checksums) != 0 {
+		sums, err = calculateChecksums(file, checksums)
+		if err != nil {
+			return fmt.Errorf("calculate checksums of %q: %w", f.from, err)
+		}
+
+		for algo, sum := range sums {
+			metadata[algo] = sum
+		}
+
+		_, err = file.Seek(0, io.SeekStart)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = client.Upload(ctx, f.to, file, info.Size(), &s3renderer.UploadOptions{
+		ContentType:      f.contentType,
+		Metadata:         metadata,
+		DisableMultipart: multipart.Disabled,
+		PartSize:         uint64(multipart.PartSize),
+		Concurrency:      multipart.Concurrency,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !checksumFiles {
+		return nil
+	}
+
+	for _, algo := range checksums {
+		// same format as the output of sha256sum and alike
+		data := []byte(sums[algo] + "  " + path.Base(f.to) + "\n")
+		err = client.Upload(ctx, f.to+"."+algo, bytes.NewReader(data), int64(len(data)), &s3renderer.UploadOptions{
+			ContentType: "text/plain; charset=utf-8",
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// calculateChecksums returns hex encoded checksums by algorithm
+func calculateChecksums(r io.Reader, algorithms []string) (map[string]string, error) {
+	var (
+		hashes  = make(map[string]hash.Hash, len(algorithms))
+		writers = make([]io.Writer, 0, len(algorithms))
+	)
+
+	for _, algo := range algorithms {
+		h := checksumAlgorithms[algo]()
+		hashes[algo] = h
+		writers = append(writers, h)
+	}
+
+	_, err := io.Copy(io.MultiWriter(writers...), r)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]string, len(hashes))
+	for algo, h := range hashes {
+		ret[algo] = hex.EncodeToString(h.Sum(nil))
+	}
+
+	return ret, nil
+}
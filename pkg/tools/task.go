@@ -8,6 +8,7 @@ import (
 	"arhat.dev/pkg/fshelper"
 	"arhat.dev/rs"
 
+	"arhat.dev/dukkha/pkg/cache"
 	"arhat.dev/dukkha/pkg/dukkha"
 	"arhat.dev/dukkha/pkg/matrix"
 )
@@ -37,6 +38,9 @@ type BaseTask struct {
 
 	ContinueOnErrorFlag bool `yaml:"continue_on_error"`
 
+	// Cache configures caching of task outputs
+	Cache *TaskCacheConfig `yaml:"cache,omitempty"`
+
 	// fields managed by BaseTask
 
 	CacheFS *fshelper.OSFS `yaml:"-"`
@@ -48,6 +52,9 @@ type BaseTask struct {
 
 	impl dukkha.Task
 
+	outputCache     *cache.TwoTierCache
+	outputCacheOnce sync.Once
+
 	mu sync.Mutex
 }
 
@@ -0,0 +1,497 @@
+package tools
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"arhat.dev/pkg/fshelper"
+	"arhat.dev/pkg/log"
+	"arhat.dev/rs"
+	"github.com/bmatcuk/doublestar/v4"
+
+	"arhat.dev/dukkha/pkg/cache"
+	"arhat.dev/dukkha/pkg/dukkha"
+	"arhat.dev/dukkha/pkg/matrix"
+)
+
+// TaskCacheConfig configures caching of task outputs
+type TaskCacheConfig struct {
+	rs.BaseField `yaml:"-"`
+
+	// Enabled activates task output caching
+	//
+	// when enabled, outputs of each matrix execution are cached by the
+	// fingerprint of commands to run and content of inputs, execution is
+	// skipped and outputs are restored from cache when the fingerprint
+	// has been seen before
+	//
+	// Defaults to `false`
+	Enabled bool `yaml:"enabled"`
+
+	// Inputs are glob patterns (`**` supported) of files affecting outputs
+	// of this task, relative to the working dir, matched directories
+	// are included recursively
+	Inputs []string `yaml:"inputs"`
+
+	// Outputs are files and directories produced by this task,
+	// relative to the working dir
+	Outputs []string `yaml:"outputs"`
+}
+
+var (
+	taskRemoteCache         cache.RemoteStore
+	taskRemoteCacheReadOnly bool
+)
+
+// SetRemoteCache sets the remote tier shared by output cache of all tasks,
+// MUST be called before tasks are executed
+func SetRemoteCache(store cache.RemoteStore, readOnly bool) {
+	taskRemoteCache = store
+	taskRemoteCacheReadOnly = readOnly
+}
+
+func (t *BaseTask) getOutputCache() *cache.TwoTierCache {
+	t.outputCacheOnce.Do(func() {
+		cacheFS := t.CacheFS
+		// outputs are addressed by content, they never expire
+		t.outputCache = cache.NewTwoTierCache(
+			fshelper.NewOSFS(false, func() (string, error) {
+				return cacheFS.Abs("outputs")
+			}),
+			0, 0, math.MaxInt32,
+		)
+
+		if taskRemoteCache != nil {
+			toolName := string(t.toolName)
+			if len(toolName) == 0 {
+				toolName = "_"
+			}
+
+			t.outputCache.SetRemote(taskRemoteCache, path.Join(
+				"task", string(t.toolKind), toolName,
+				string(t.impl.Kind()), string(t.impl.Name()),
+			), taskRemoteCacheReadOnly)
+		}
+	})
+
+	return t.outputCache
+}
+
+func (t *BaseTask) RunWithOutputCache(
+	rc dukkha.TaskExecContext,
+	ms matrix.Entry,
+	execSpecs []dukkha.TaskExecSpec,
+	run func() error,
+) error {
+	var spec TaskCacheConfig
+	err := t.DoAfterFieldsResolved(rc, -1, true, func() error {
+		if t.Cache != nil {
+			spec = TaskCacheConfig{
+				Enabled: t.Cache.Enabled,
+				Inputs:  append([]string{}, t.Cache.Inputs...),
+				Outputs: append([]string{}, t.Cache.Outputs...),
+			}
+		}
+
+		return nil
+	}, "BaseTask.cache")
+	if err != nil {
+		return fmt.Errorf("resolving task cache config: %w", err)
+	}
+
+	if !spec.Enabled {
+		return run()
+	}
+
+	for _, es := range execSpecs {
+		if es.AlterExecFunc != nil {
+			// commands generated at runtime cannot be fingerprinted
+			log.Log.I("task output caching not supported by this task, running without cache",
+				log.String("task", t.impl.Key().String()),
+			)
+			return run()
+		}
+	}
+
+	workDir := rc.WorkDir()
+	fingerprint, err := taskCacheFingerprint(
+		workDir, t.impl.Key().String(), ms.String(), &spec, execSpecs,
+	)
+	if err != nil {
+		return fmt.Errorf("calculating task fingerprint: %w", err)
+	}
+
+	var executed bool
+	file, _, err := t.getOutputCache().GetPath(
+		cache.IdentifiableString(fingerprint+".tgz"),
+		time.Now().Unix(),
+		false,
+		func(_ cache.IdentifiableObject) (io.ReadCloser, error) {
+			executed = true
+			err2 := run()
+			if err2 != nil {
+				return nil, err2
+			}
+
+			return archiveTaskOutputs(workDir, spec.Outputs)
+		},
+	)
+	if err != nil || executed {
+		return err
+	}
+
+	log.Log.I("restoring task outputs from cache",
+		log.String("task", t.impl.Key().String()),
+		log.String("fingerprint", fingerprint),
+	)
+
+	err = extractTaskOutputs(file, workDir, spec.Outputs)
+	if err != nil {
+		return fmt.Errorf("restoring task outputs from cache: %w", err)
+	}
+
+	return nil
+}
+
+// taskCacheFingerprint calculates sha256 sum of everything affecting
+// task outputs, absolute path to workDir is excluded so the fingerprint
+// is the same across machines
+func taskCacheFingerprint(
+	workDir, taskKey, matrixEntry string,
+	spec *TaskCacheConfig,
+	execSpecs []dukkha.TaskExecSpec,
+) (string, error) {
+	h := sha256.New()
+	relative := func(s string) string {
+		if len(workDir) == 0 {
+			return s
+		}
+
+		return strings.ReplaceAll(s, workDir, ".")
+	}
+
+	write := func(key string, values ...string) {
+		_, _ = fmt.Fprintf(h, "%s:%d\n", key, len(values))
+		for _, v := range values {
+			_, _ = fmt.Fprintf(h, "%q\n", relative(v))
+		}
+	}
+
+	write("task", taskKey)
+	write("matrix", matrixEntry)
+	write("outputs", spec.Outputs...)
+
+	for i, es := range execSpecs {
+		write("exec", fmt.Sprint(i))
+		write("command", es.Command...)
+		write("chdir", es.Chdir)
+		write("shell", fmt.Sprint(es.UseShell), es.ShellName)
+		write("ignore_error", fmt.Sprint(es.IgnoreError))
+
+		for _, env := range []dukkha.Env{es.EnvSuggest, es.EnvOverride} {
+			var kvs []string
+			for _, e := range env {
+				kvs = append(kvs, e.Name+"="+e.Value)
+			}
+			write("env", kvs...)
+		}
+	}
+
+	inputs, err := findTaskInputs(workDir, spec.Inputs)
+	if err != nil {
+		return "", err
+	}
+
+	for _, name := range inputs {
+		f, err := os.Open(filepath.Join(workDir, filepath.FromSlash(name)))
+		if err != nil {
+			return "", err
+		}
+
+		fh := sha256.New()
+		_, err = io.Copy(fh, f)
+		_ = f.Close()
+		if err != nil {
+			return "", err
+		}
+
+		write("input", name, hex.EncodeToString(fh.Sum(nil)))
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// findTaskInputs returns sorted slash separated paths of regular files
+// matching patterns
+func findTaskInputs(workDir string, patterns []string) ([]string, error) {
+	var (
+		dirFS = os.DirFS(workDir)
+		seen  = make(map[string]struct{})
+		ret   []string
+	)
+
+	for _, p := range patterns {
+		if filepath.IsAbs(p) {
+			return nil, fmt.Errorf("invalid absolute input pattern %q", p)
+		}
+
+		matches, err := doublestar.Glob(dirFS, path.Clean(filepath.ToSlash(p)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid input pattern %q: %w", p, err)
+		}
+
+		for _, m := range matches {
+			err = fs.WalkDir(dirFS, m, func(name string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+
+				if !d.Type().IsRegular() {
+					return nil
+				}
+
+				if _, ok := seen[name]; !ok {
+					seen[name] = struct{}{}
+					ret = append(ret, name)
+				}
+
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.Strings(ret)
+	return ret, nil
+}
+
+// archiveTaskOutputs creates a gzipped tarball of outputs in a temporary
+// file, the file is removed when the returned reader is closed
+func archiveTaskOutputs(workDir string, outputs []string) (_ io.ReadCloser, err error) {
+	f, err := os.CreateTemp("", "dukkha-task-outputs-*.tgz")
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}
+	}()
+
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+
+	for _, o := range outputs {
+		name, err := cleanTaskOutputPath(o)
+		if err != nil {
+			return nil, err
+		}
+
+		err = filepath.WalkDir(
+			filepath.Join(workDir, filepath.FromSlash(name)),
+			func(file string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+
+				return addTaskOutput(tw, workDir, file)
+			},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("archiving task output %q: %w", o, err)
+		}
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	err = gw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = f.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tempFile{File: f}, nil
+}
+
+func addTaskOutput(tw *tar.Writer, workDir, file string) error {
+	info, err := os.Lstat(file)
+	if err != nil {
+		return err
+	}
+
+	var link string
+	if info.Mode()&fs.ModeSymlink != 0 {
+		link, err = os.Readlink(file)
+		if err != nil {
+			return err
+		}
+	}
+
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(workDir, file)
+	if err != nil {
+		return err
+	}
+
+	hdr.Name = filepath.ToSlash(rel)
+	// keep the archive reproducible
+	hdr.ModTime = time.Time{}
+	hdr.AccessTime = time.Time{}
+	hdr.ChangeTime = time.Time{}
+	hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+
+	err = tw.WriteHeader(hdr)
+	if err != nil || !info.Mode().IsRegular() {
+		return err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// extractTaskOutputs replaces outputs in workDir with content of the
+// cached tarball
+func extractTaskOutputs(file, workDir string, outputs []string) error {
+	var allowed []string
+	for _, o := range outputs {
+		name, err := cleanTaskOutputPath(o)
+		if err != nil {
+			return err
+		}
+
+		allowed = append(allowed, name)
+
+		err = os.RemoveAll(filepath.Join(workDir, filepath.FromSlash(name)))
+		if err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = gr.Close() }()
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		if !isTaskOutput(hdr.Name, allowed) {
+			return fmt.Errorf("unexpected file %q in cached outputs", hdr.Name)
+		}
+
+		dest := filepath.Join(workDir, filepath.FromSlash(hdr.Name))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(dest, hdr.FileInfo().Mode().Perm()|0700)
+		case tar.TypeSymlink:
+			err = os.MkdirAll(filepath.Dir(dest), 0755)
+			if err == nil {
+				err = os.Symlink(hdr.Linkname, dest)
+			}
+		case tar.TypeReg:
+			err = extractTaskOutputFile(tr, dest, hdr.FileInfo().Mode().Perm())
+		default:
+			err = fmt.Errorf("unsupported file type of %q in cached outputs", hdr.Name)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func extractTaskOutputFile(r io.Reader, dest string, perm fs.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(dest), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, r)
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+
+	return err
+}
+
+func cleanTaskOutputPath(p string) (string, error) {
+	name := path.Clean(filepath.ToSlash(p))
+	if filepath.IsAbs(p) || name == "." || name == ".." || strings.HasPrefix(name, "../") {
+		return "", fmt.Errorf("invalid task output %q: must be a path inside working dir", p)
+	}
+
+	return name, nil
+}
+
+func isTaskOutput(name string, outputs []string) bool {
+	name = path.Clean(name)
+	for _, o := range outputs {
+		if name == o || strings.HasPrefix(name, o+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+type tempFile struct{ *os.File }
+
+func (f *tempFile) Close() error {
+	err := f.File.Close()
+	_ = os.Remove(f.File.Name())
+	return err
+}
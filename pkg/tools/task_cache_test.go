@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"arhat.dev/pkg/fshelper"
+	"arhat.dev/rs"
+	"github.com/stretchr/testify/assert"
+
+	di "arhat.dev/dukkha/internal"
+	"arhat.dev/dukkha/pkg/dukkha"
+	dt "arhat.dev/dukkha/pkg/dukkha/test"
+	"arhat.dev/dukkha/pkg/matrix"
+)
+
+type testRemoteStore map[string][]byte
+
+func (s testRemoteStore) Get(key string) (io.ReadCloser, time.Time, error) {
+	data, ok := s[key]
+	if !ok {
+		return nil, time.Time{}, fs.ErrNotExist
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(data)), time.Now(), nil
+}
+
+func (s testRemoteStore) Put(key string, r io.Reader, size int64) error {
+	data, err := io.ReadAll(r)
+	s[key] = data
+	return err
+}
+
+func TestBaseTask_RunWithOutputCache(t *testing.T) {
+	workDir := t.TempDir()
+	rc := dt.NewTestContext(context.TODO())
+	rc.(di.WorkDirOverrider).OverrideWorkDir(workDir)
+
+	newTask := func(t *testing.T, enabled bool) *_baseTaskWithGetExecSpecs {
+		tsk := &_baseTaskWithGetExecSpecs{}
+		rs.InitRecursively(reflect.ValueOf(tsk), nil)
+		tsk.InitBaseTask("test-tool", "", tsk)
+
+		cacheDir := t.TempDir()
+		assert.NoError(t, tsk.Init(fshelper.NewOSFS(false, func() (string, error) {
+			return cacheDir, nil
+		})))
+
+		tsk.Cache = rs.Init(&TaskCacheConfig{
+			Enabled: enabled,
+			Inputs:  []string{"src/**/*.txt"},
+			Outputs: []string{"out"},
+		}, nil).(*TaskCacheConfig)
+
+		return tsk
+	}
+
+	input := filepath.Join(workDir, "src", "a", "input.txt")
+	result := filepath.Join(workDir, "out", "result")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(input), 0755))
+
+	var executed int
+	run := func() error {
+		executed++
+
+		data, err := os.ReadFile(input)
+		if err != nil {
+			return err
+		}
+
+		err = os.MkdirAll(filepath.Dir(result), 0755)
+		if err != nil {
+			return err
+		}
+
+		return os.WriteFile(result, []byte(fmt.Sprintf("%s-%d", data, executed)), 0644)
+	}
+
+	execSpecs := []dukkha.TaskExecSpec{{Command: []string{"build", filepath.Join(workDir, "src")}}}
+	ms := matrix.Entry{"arch": "amd64"}
+
+	assertRun := func(t *testing.T, tsk dukkha.Task, ms matrix.Entry, expectedExecuted int, expectedResult string) {
+		assert.NoError(t, os.RemoveAll(filepath.Join(workDir, "out")))
+		assert.NoError(t, tsk.RunWithOutputCache(rc, ms, execSpecs, run))
+		assert.Equal(t, expectedExecuted, executed)
+
+		data, err := os.ReadFile(result)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedResult, string(data))
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		executed = 0
+		assert.NoError(t, os.WriteFile(input, []byte("v1"), 0644))
+
+		tsk := newTask(t, false)
+		assertRun(t, tsk, ms, 1, "v1-1")
+		assertRun(t, tsk, ms, 2, "v1-2")
+	})
+
+	t.Run("Local", func(t *testing.T) {
+		executed = 0
+		assert.NoError(t, os.WriteFile(input, []byte("v1"), 0644))
+
+		tsk := newTask(t, true)
+		assertRun(t, tsk, ms, 1, "v1-1")
+		// restored from cache
+		assertRun(t, tsk, ms, 1, "v1-1")
+
+		// inputs changed
+		assert.NoError(t, os.WriteFile(input, []byte("v2"), 0644))
+		assertRun(t, tsk, ms, 2, "v2-2")
+
+		// matrix changed
+		assertRun(t, tsk, matrix.Entry{"arch": "arm64"}, 3, "v2-3")
+
+		// back to cached inputs
+		assert.NoError(t, os.WriteFile(input, []byte("v1"), 0644))
+		assertRun(t, tsk, ms, 3, "v1-1")
+	})
+
+	t.Run("Remote", func(t *testing.T) {
+		executed = 0
+		assert.NoError(t, os.WriteFile(input, []byte("v1"), 0644))
+
+		store := make(testRemoteStore)
+		SetRemoteCache(store, false)
+		defer SetRemoteCache(nil, false)
+
+		assertRun(t, newTask(t, true), ms, 1, "v1-1")
+		assert.Len(t, store, 1)
+		for key := range store {
+			assert.Contains(t, key, "task/test-tool/_/_/_/")
+		}
+
+		// another machine with empty local cache
+		assertRun(t, newTask(t, true), ms, 1, "v1-1")
+	})
+
+	t.Run("Invalid Output", func(t *testing.T) {
+		tsk := newTask(t, true)
+		tsk.Cache.Outputs = []string{"../out"}
+
+		assert.Error(t, tsk.RunWithOutputCache(rc, ms, execSpecs, run))
+	})
+}